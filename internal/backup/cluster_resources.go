@@ -0,0 +1,59 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// clusterResourcesDir is the backup directory holding cluster-scoped resources other than namespaces
+const clusterResourcesDir = "cluster"
+
+// clusterResource describes a cluster-scoped resource type to be backed up
+type clusterResource struct {
+	gvr  schema.GroupVersionResource
+	kind string
+}
+
+// clusterResourceTypes defines the cluster-scoped Kubernetes resource types to be backed up
+var clusterResourceTypes = []clusterResource{
+	{gvr: schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"}, kind: "ClusterRole"},
+	{gvr: schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterrolebindings"}, kind: "ClusterRoleBinding"},
+	{gvr: schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumes"}, kind: "PersistentVolume"},
+	{gvr: schema.GroupVersionResource{Group: "storage.k8s.io", Version: "v1", Resource: "storageclasses"}, kind: "StorageClass"},
+	{gvr: schema.GroupVersionResource{Group: "scheduling.k8s.io", Version: "v1", Resource: "priorityclasses"}, kind: "PriorityClass"},
+	{gvr: schema.GroupVersionResource{Group: "networking.k8s.io", Version: "v1", Resource: "ingressclasses"}, kind: "IngressClass"},
+	{gvr: schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}, kind: "CustomResourceDefinition"},
+	{gvr: schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "mutatingwebhookconfigurations"}, kind: "MutatingWebhookConfiguration"},
+	{gvr: schema.GroupVersionResource{Group: "admissionregistration.k8s.io", Version: "v1", Resource: "validatingwebhookconfigurations"}, kind: "ValidatingWebhookConfiguration"},
+}
+
+// backupClusterResources backs up all cluster-scoped resources of the given type
+func (bm *Manager) backupClusterResources(ctx context.Context, resource clusterResource) error {
+	return bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		list, err := bm.client.ListResources(ctx, resource.gvr, "", opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing %s: %v", resource.gvr.Resource, err)
+		}
+
+		for _, item := range list.Items {
+			filename := filepath.Join(bm.backupDir, clusterResourcesDir, resource.gvr.Resource, item.GetName()+bm.fileExtension())
+			if bm.dryRun {
+				bm.logger.Infof("Would backup %s: %s", resource.kind, item.GetName())
+			} else {
+				if err := bm.saveResource(item.Object, resource.gvr.GroupVersion().WithKind(resource.kind), filename); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return list, nil
+	})
+}
+
+// countClusterResources counts the cluster-scoped resources of the given type
+func (bm *Manager) countClusterResources(ctx context.Context, resource clusterResource) (int, error) {
+	return bm.countDynamicResources(ctx, resource.gvr, "", nil)
+}
@@ -0,0 +1,148 @@
+package backup
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+// skippedResources lists the group resources that are never backed up in discovery mode.
+// Events are short-lived records of cluster activity and restoring them is meaningless. The others are
+// generated by controllers of the cluster from the resources that are backed up: Endpoints and
+// EndpointSlices from Services, ControllerRevisions from StatefulSets and DaemonSets, and Leases by
+// leader election. Restoring them would leave stale copies next to the ones the controllers create.
+var skippedResources = map[schema.GroupResource]bool{
+	{Group: "", Resource: "events"}:                         true,
+	{Group: "events.k8s.io", Resource: "events"}:            true,
+	{Group: "", Resource: "endpoints"}:                      true,
+	{Group: "discovery.k8s.io", Resource: "endpointslices"}: true,
+	{Group: "apps", Resource: "controllerrevisions"}:        true,
+	{Group: "coordination.k8s.io", Resource: "leases"}:      true,
+}
+
+// discoverResources returns the namespaced API resources to back up in discovery mode
+func (bm *Manager) discoverResources(ctx context.Context) ([]metav1.APIResource, error) {
+	apiResources, err := bm.client.ListNamespacedAPIResources(ctx)
+	if err != nil {
+		if !discovery.IsGroupDiscoveryFailedError(err) {
+			return nil, fmt.Errorf("error discovering API resources: %v", err)
+		}
+		// Back up what we can rather than failing because a single aggregated API is unavailable
+		bm.logger.Warnf("Some API groups could not be discovered and will not be backed up: %v", err)
+	}
+
+	var resources []metav1.APIResource
+	for _, apiResource := range apiResources {
+		if skippedResources[schema.GroupResource{Group: apiResource.Group, Resource: apiResource.Name}] {
+			continue
+		}
+		if !bm.resources.MatchesAny(apiResource.Name, resourceDirName(apiResource)) {
+			continue
+		}
+		resources = append(resources, apiResource)
+	}
+
+	bm.logger.Infof("Discovered %d namespaced resource types to back up", len(resources))
+	return resources, nil
+}
+
+// backupDynamicResource backs up all objects of a discovered resource in a given namespace
+func (bm *Manager) backupDynamicResource(ctx context.Context, apiResource metav1.APIResource, namespace string) error {
+	gvr := schema.GroupVersionResource{Group: apiResource.Group, Version: apiResource.Version, Resource: apiResource.Name}
+	gvk := schema.GroupVersionKind{Group: apiResource.Group, Version: apiResource.Version, Kind: apiResource.Kind}
+	return bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		list, err := bm.client.ListResources(ctx, gvr, namespace, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing %s in namespace %s: %v", resourceDirName(apiResource), namespace, err)
+		}
+
+		for _, item := range list.Items {
+			if isControllerManaged(&item) {
+				owner := metav1.GetControllerOf(&item)
+				bm.logger.Debugf("Skipping %s %s/%s: it is managed by %s %s", apiResource.Kind, namespace, item.GetName(), owner.Kind, owner.Name)
+				continue
+			}
+			filename := filepath.Join(bm.backupDir, namespace, resourceDirName(apiResource), item.GetName()+bm.fileExtension())
+			if bm.dryRun {
+				bm.logger.Infof("Would backup %s: %s/%s", apiResource.Kind, namespace, item.GetName())
+			} else {
+				if err := bm.saveResource(item.Object, gvk, filename); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return list, nil
+	})
+}
+
+// countDynamicResourcesInNamespace counts the objects of every discovered resource in a single namespace
+func (bm *Manager) countDynamicResourcesInNamespace(ctx context.Context, namespace string) int {
+	var wg sync.WaitGroup
+	counts := make(chan int, len(bm.apiResources))
+
+	for _, apiResource := range bm.apiResources {
+		wg.Add(1)
+		go func(apiResource metav1.APIResource) {
+			defer wg.Done()
+			gvr := schema.GroupVersionResource{Group: apiResource.Group, Version: apiResource.Version, Resource: apiResource.Name}
+			count, err := bm.countDynamicResources(ctx, gvr, namespace, isControllerManaged)
+			if err != nil {
+				bm.logger.Errorf("Error counting %s in namespace %s: %v", resourceDirName(apiResource), namespace, err)
+			}
+			counts <- count
+		}(apiResource)
+	}
+
+	go func() {
+		wg.Wait()
+		close(counts)
+	}()
+
+	total := 0
+	for count := range counts {
+		total += count
+	}
+
+	return total
+}
+
+// countDynamicResources counts the objects of a resource in a namespace, or cluster-wide if namespace is empty.
+// Objects for which skip returns true are not counted; a nil skip counts every object.
+func (bm *Manager) countDynamicResources(ctx context.Context, gvr schema.GroupVersionResource, namespace string, skip func(metav1.Object) bool) (int, error) {
+	count := 0
+	err := bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		list, err := bm.client.ListResources(ctx, gvr, namespace, opts)
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			if skip == nil || !skip(&list.Items[i]) {
+				count++
+			}
+		}
+		return list, nil
+	})
+	return count, err
+}
+
+// isControllerManaged reports whether an object is managed by a controller and is therefore skipped in
+// discovery mode. Such objects, like the ReplicaSets of a Deployment and their Pods, are created again by
+// their restored controller.
+func isControllerManaged(obj metav1.Object) bool {
+	return metav1.GetControllerOf(obj) != nil
+}
+
+// resourceDirName returns the backup directory name for a discovered resource.
+// Core resources use their plural name and all other resources are qualified with their group
+// (for example "configmaps" and "certificates.cert-manager.io").
+func resourceDirName(apiResource metav1.APIResource) string {
+	if apiResource.Group == "" {
+		return apiResource.Name
+	}
+	return apiResource.Name + "." + apiResource.Group
+}
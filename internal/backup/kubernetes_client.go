@@ -9,6 +9,9 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 // KubernetesClient defines the interface for interacting with Kubernetes resources
@@ -20,44 +23,53 @@ type KubernetesClient interface {
 	GetNamespaces(ctx context.Context) (*corev1.NamespaceList, error)
 
 	// ListDeployments returns a list of all deployments in the specified namespace
-	ListDeployments(ctx context.Context, namespace string) (*appsv1.DeploymentList, error)
+	ListDeployments(ctx context.Context, namespace string, opts metav1.ListOptions) (*appsv1.DeploymentList, error)
 
 	// ListServices returns a list of all services in the specified namespace
-	ListServices(ctx context.Context, namespace string) (*corev1.ServiceList, error)
+	ListServices(ctx context.Context, namespace string, opts metav1.ListOptions) (*corev1.ServiceList, error)
 
 	// ListConfigMaps returns a list of all config maps in the specified namespace
-	ListConfigMaps(ctx context.Context, namespace string) (*corev1.ConfigMapList, error)
+	ListConfigMaps(ctx context.Context, namespace string, opts metav1.ListOptions) (*corev1.ConfigMapList, error)
 
 	// ListDaemonSets returns a list of all daemon sets in the specified namespace
-	ListDaemonSets(ctx context.Context, namespace string) (*appsv1.DaemonSetList, error)
+	ListDaemonSets(ctx context.Context, namespace string, opts metav1.ListOptions) (*appsv1.DaemonSetList, error)
 
 	// ListSecrets returns a list of all secrets in the specified namespace
-	ListSecrets(ctx context.Context, namespace string) (*corev1.SecretList, error)
+	ListSecrets(ctx context.Context, namespace string, opts metav1.ListOptions) (*corev1.SecretList, error)
 
 	// ListServiceAccounts returns a list of all service accounts in the specified namespace
-	ListServiceAccounts(ctx context.Context, namespace string) (*corev1.ServiceAccountList, error)
+	ListServiceAccounts(ctx context.Context, namespace string, opts metav1.ListOptions) (*corev1.ServiceAccountList, error)
 
 	// ListStatefulSets returns a list of all stateful sets in the specified namespace
-	ListStatefulSets(ctx context.Context, namespace string) (*appsv1.StatefulSetList, error)
+	ListStatefulSets(ctx context.Context, namespace string, opts metav1.ListOptions) (*appsv1.StatefulSetList, error)
 
 	// ListHorizontalPodAutoscalers returns a list of all horizontal pod autoscalers in the specified namespace
-	ListHorizontalPodAutoscalers(ctx context.Context, namespace string) (*autoscalingv2.HorizontalPodAutoscalerList, error)
+	ListHorizontalPodAutoscalers(ctx context.Context, namespace string, opts metav1.ListOptions) (*autoscalingv2.HorizontalPodAutoscalerList, error)
 
 	// ListCronJobs returns a list of all cron jobs in the specified namespace
-	ListCronJobs(ctx context.Context, namespace string) (*batchv1.CronJobList, error)
+	ListCronJobs(ctx context.Context, namespace string, opts metav1.ListOptions) (*batchv1.CronJobList, error)
 
 	// ListJobs returns a list of all jobs in the specified namespace
-	ListJobs(ctx context.Context, namespace string) (*batchv1.JobList, error)
+	ListJobs(ctx context.Context, namespace string, opts metav1.ListOptions) (*batchv1.JobList, error)
 
 	// ListPersistentVolumeClaims returns a list of all persistent volume claims in the specified namespace
-	ListPersistentVolumeClaims(ctx context.Context, namespace string) (*corev1.PersistentVolumeClaimList, error)
+	ListPersistentVolumeClaims(ctx context.Context, namespace string, opts metav1.ListOptions) (*corev1.PersistentVolumeClaimList, error)
 
 	// ListIngresses returns a list of all ingresses in the specified namespace
-	ListIngresses(ctx context.Context, namespace string) (*networkingv1.IngressList, error)
+	ListIngresses(ctx context.Context, namespace string, opts metav1.ListOptions) (*networkingv1.IngressList, error)
 
 	// ListRoles returns a list of all roles in the specified namespace
-	ListRoles(ctx context.Context, namespace string) (*rbacv1.RoleList, error)
+	ListRoles(ctx context.Context, namespace string, opts metav1.ListOptions) (*rbacv1.RoleList, error)
+
+	// ListRoleBindings returns a list of all role bindings in the specified namespace
+	ListRoleBindings(ctx context.Context, namespace string, opts metav1.ListOptions) (*rbacv1.RoleBindingList, error)
 
 	// ListNetworkPolicies returns a list of all network policies in the specified namespace
-	ListNetworkPolicies(ctx context.Context, namespace string) (*networkingv1.NetworkPolicyList, error)
+	ListNetworkPolicies(ctx context.Context, namespace string, opts metav1.ListOptions) (*networkingv1.NetworkPolicyList, error)
+
+	// ListNamespacedAPIResources returns every namespaced API resource served by the cluster that supports listing
+	ListNamespacedAPIResources(ctx context.Context) ([]metav1.APIResource, error)
+
+	// ListResources returns a list of all objects of the given resource in the specified namespace
+	ListResources(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error)
 }
@@ -2,9 +2,17 @@ package backup
 
 import (
 	"context"
+	"crypto/ed25519"
 	"fmt"
+	"sync"
 
+	"github.com/chaoscypher/kube-save-restore/internal/encryption"
+	"github.com/chaoscypher/kube-save-restore/internal/filter"
+	"github.com/chaoscypher/kube-save-restore/internal/manifest"
+	"github.com/chaoscypher/kube-save-restore/internal/redact"
+	"github.com/chaoscypher/kube-save-restore/internal/storage"
 	"golang.org/x/sync/errgroup"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 type Logger interface {
@@ -20,53 +28,219 @@ type Logger interface {
 }
 
 // resourceTypes defines the Kubernetes resource types to be backed up
-var resourceTypes = []string{"deployments", "services", "configmaps", "secrets", "serviceaccounts", "hpas", "statefulsets", "daemonsets", "cronjobs", "jobs", "pvcs", "ingresses", "roles", "networkpolicies"}
+var resourceTypes = []string{"deployments", "services", "configmaps", "secrets", "serviceaccounts", "hpas", "statefulsets", "daemonsets", "cronjobs", "jobs", "pvcs", "ingresses", "roles", "rolebindings", "networkpolicies"}
 
 // Manager handles the backup process for Kubernetes resources
 type Manager struct {
-	client    KubernetesClient
-	backupDir string
-	dryRun    bool
-	logger    Logger
+	client        KubernetesClient
+	backupDir     string
+	dryRun        bool
+	logger        Logger
+	discovery     bool
+	apiResources  []metav1.APIResource
+	namespaces    *filter.Filter
+	resources     *filter.Filter
+	listOptions   metav1.ListOptions
+	pageSize      int64
+	outputFormat  string
+	archiveFormat string
+	storage       storage.Storage
+	writer        fileWriter
+	toolVersion   string
+	cluster       manifest.Cluster
+	signingKey    ed25519.PrivateKey
+	encrypter     *encryption.Encrypter
+	redactor      *redact.Redactor
+
+	mu    sync.Mutex
+	files []manifest.File
+}
+
+// Option configures optional behaviour of a Manager
+type Option func(*Manager)
+
+// WithDiscovery enables discovery-driven backups, which back up every namespaced resource
+// served by the cluster (including custom resources) instead of the built-in resource types
+func WithDiscovery(enabled bool) Option {
+	return func(bm *Manager) {
+		bm.discovery = enabled
+	}
+}
+
+// WithNamespaceFilter restricts the backup to the namespaces matched by f
+func WithNamespaceFilter(f *filter.Filter) Option {
+	return func(bm *Manager) {
+		bm.namespaces = f
+	}
+}
+
+// WithResourceFilter restricts the backup to the resource types matched by f.
+// Resource types are matched by their backup directory name, such as "secrets", "hpas" or
+// "certificates.cert-manager.io", and by their plural resource name.
+func WithResourceFilter(f *filter.Filter) Option {
+	return func(bm *Manager) {
+		bm.resources = f
+	}
+}
+
+// WithSelectors restricts the backup to resources matching the label and field selectors.
+// Namespaces themselves are never filtered by the selectors.
+func WithSelectors(labelSelector, fieldSelector string) Option {
+	return func(bm *Manager) {
+		bm.listOptions = metav1.ListOptions{LabelSelector: labelSelector, FieldSelector: fieldSelector}
+	}
+}
+
+// WithPageSize sets the number of objects requested per list call. A page size of zero disables
+// pagination and fetches every object of a type in a single call.
+func WithPageSize(pageSize int64) Option {
+	return func(bm *Manager) {
+		bm.pageSize = pageSize
+	}
+}
+
+// WithOutputFormat sets the format backup files are written in, either OutputFormatJSON or OutputFormatYAML
+func WithOutputFormat(format string) Option {
+	return func(bm *Manager) {
+		bm.outputFormat = format
+	}
+}
+
+// WithArchive streams the backup into a single compressed tar archive in the given format,
+// ArchiveFormatTarGz or ArchiveFormatTarZst, instead of writing a directory tree. The archive is
+// named after the backup directory, for example "k8s-backup-20240101-120000.tar.gz".
+func WithArchive(format string) Option {
+	return func(bm *Manager) {
+		bm.archiveFormat = format
+	}
+}
+
+// WithToolVersion sets the version of kube-save-restore recorded in the backup manifest
+func WithToolVersion(version string) Option {
+	return func(bm *Manager) {
+		bm.toolVersion = version
+	}
+}
+
+// WithCluster sets the description of the backed up cluster recorded in the backup manifest
+func WithCluster(cluster manifest.Cluster) Option {
+	return func(bm *Manager) {
+		bm.cluster = cluster
+	}
+}
+
+// WithSigningKey signs the backup manifest with key, so that restores can check the backup has not been
+// tampered with
+func WithSigningKey(key ed25519.PrivateKey) Option {
+	return func(bm *Manager) {
+		bm.signingKey = key
+	}
+}
+
+// WithEncryption encrypts the data of every Secret with encrypter before it is written to the backup
+func WithEncryption(encrypter *encryption.Encrypter) Option {
+	return func(bm *Manager) {
+		bm.encrypter = encrypter
+	}
+}
+
+// WithRedactor replaces sensitive values with placeholders before resources are written to the backup,
+// so that the backup can be shared
+func WithRedactor(redactor *redact.Redactor) Option {
+	return func(bm *Manager) {
+		bm.redactor = redactor
+	}
+}
+
+// WithStorage writes the backup to s instead of the local filesystem. The backup directory names the
+// backup within s.
+func WithStorage(s storage.Storage) Option {
+	return func(bm *Manager) {
+		bm.storage = s
+	}
 }
 
 // NewManager creates a new Manager instance
-func NewManager(client KubernetesClient, backupDir string, dryRun bool, logger Logger) *Manager {
-	return &Manager{
-		client:    client,
-		backupDir: backupDir,
-		dryRun:    dryRun,
-		logger:    logger,
+func NewManager(client KubernetesClient, backupDir string, dryRun bool, logger Logger, opts ...Option) *Manager {
+	bm := &Manager{
+		client:       client,
+		backupDir:    backupDir,
+		dryRun:       dryRun,
+		logger:       logger,
+		pageSize:     defaultPageSize,
+		outputFormat: OutputFormatJSON,
+		storage:      storage.NewLocal(""),
 	}
+	for _, opt := range opts {
+		opt(bm)
+	}
+	bm.writer = storageWriter{ctx: context.Background(), storage: bm.storage}
+	return bm
 }
 
 // PerformBackup initiates the backup process for all namespaces
 func (bm *Manager) PerformBackup(ctx context.Context) error {
 	bm.logger.Info("Starting backup operation")
 
-	// List all namespaces
-	namespaces, err := bm.client.ListNamespaces(ctx)
+	// List all namespaces selected for backup
+	namespaces, err := bm.listNamespaces(ctx)
 	if err != nil {
 		return fmt.Errorf("error listing namespaces: %v", err)
 	}
 
+	// Discover the resources to back up when running in discovery mode
+	if bm.discovery {
+		bm.apiResources, err = bm.discoverResources(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Count total resources to be backed up
 	totalResources := bm.countResources(ctx)
 
 	if bm.dryRun {
 		bm.logger.Info("Dry run mode: No files will be written")
+	} else if bm.archiveFormat != "" {
+		archive, err := newArchiveWriter(ctx, bm.storage, bm.backupDir, bm.archiveFormat)
+		if err != nil {
+			return err
+		}
+		bm.writer = archive
+	} else {
+		bm.writer = storageWriter{ctx: ctx, storage: bm.storage}
 	}
 
 	g, ctx := errgroup.WithContext(ctx)
 
 	// First, backup namespaces themselves
-	g.Go(func() error {
-		return bm.backupNamespaces(ctx)
-	})
+	if bm.resources.Matches("namespaces") {
+		g.Go(func() error {
+			return bm.backupNamespaces(ctx)
+		})
+	}
+
+	// Backup the other cluster-scoped resources
+	for _, resource := range bm.selectedClusterResourceTypes() {
+		resource := resource // capture range variable
+		g.Go(func() error {
+			return bm.backupClusterResources(ctx, resource)
+		})
+	}
 
 	// Enqueue backup tasks using errgroup
 	for _, ns := range namespaces {
-		for _, resourceType := range resourceTypes {
+		if bm.discovery {
+			for _, apiResource := range bm.apiResources {
+				apiResource := apiResource // capture range variable
+				ns := ns
+				g.Go(func() error {
+					return bm.backupDynamicResource(ctx, apiResource, ns)
+				})
+			}
+			continue
+		}
+		for _, resourceType := range bm.selectedResourceTypes() {
 			resourceType := resourceType // capture range variable
 			ns := ns
 			g.Go(func() error {
@@ -77,18 +251,78 @@ func (bm *Manager) PerformBackup(ctx context.Context) error {
 
 	// Wait for all goroutines to finish
 	if err := g.Wait(); err != nil {
+		bm.writer.Abort()
 		return fmt.Errorf("backup failed: %v", err)
 	}
+	if !bm.dryRun {
+		if err := bm.writeManifest(); err != nil {
+			bm.writer.Abort()
+			return err
+		}
+	}
+	if err := bm.writer.Close(); err != nil {
+		return err
+	}
 
 	bm.logCompletionMessage(totalResources)
 	return nil
 }
 
+// selectedResourceTypes returns the built-in resource types matched by the resource filter
+func (bm *Manager) selectedResourceTypes() []string {
+	var selected []string
+	for _, resourceType := range resourceTypes {
+		names := []string{resourceType}
+		if alias, ok := filter.ResourceTypeAliases[resourceType]; ok {
+			names = append(names, alias)
+		}
+		if bm.resources.MatchesAny(names...) {
+			selected = append(selected, resourceType)
+		}
+	}
+	return selected
+}
+
+// selectedClusterResourceTypes returns the cluster-scoped resource types matched by the resource filter
+func (bm *Manager) selectedClusterResourceTypes() []clusterResource {
+	var selected []clusterResource
+	for _, resource := range clusterResourceTypes {
+		if bm.resources.Matches(resource.gvr.Resource) {
+			selected = append(selected, resource)
+		}
+	}
+	return selected
+}
+
+// listNamespaces returns the names of the namespaces matched by the namespace filter
+func (bm *Manager) listNamespaces(ctx context.Context) ([]string, error) {
+	namespaces, err := bm.client.ListNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []string
+	for _, ns := range namespaces {
+		if bm.namespaces.Matches(ns) {
+			selected = append(selected, ns)
+		}
+	}
+	return selected, nil
+}
+
+// destination returns the directory or archive the backup is written to
+func (bm *Manager) destination() string {
+	if bm.archiveFormat != "" {
+		return bm.storage.Location(archivePath(bm.backupDir, bm.archiveFormat))
+	}
+	return bm.storage.Location(bm.backupDir)
+}
+
 // logCompletionMessage logs a message indicating the completion of the backup process
 func (bm *Manager) logCompletionMessage(totalResources int) {
 	if bm.dryRun {
-		bm.logger.Infof("Dry run completed. %d resources would be backed up to: %s", totalResources, bm.backupDir)
+		bm.logger.Infof("Dry run completed. %d resources would be backed up to: %s", totalResources, bm.destination())
 	} else {
-		bm.logger.Infof("Backup completed. %d resources saved to: %s", totalResources, bm.backupDir)
+		bm.logger.Infof("Backup completed. %d resources saved to: %s", totalResources, bm.destination())
 	}
 }
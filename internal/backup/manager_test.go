@@ -1,12 +1,25 @@
 package backup
 
 import (
+	"archive/tar"
+	"compress/gzip"
 	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
 
+	"github.com/chaoscypher/kube-save-restore/internal/encryption"
+	"github.com/chaoscypher/kube-save-restore/internal/filter"
 	"github.com/chaoscypher/kube-save-restore/internal/logger"
+	"github.com/chaoscypher/kube-save-restore/internal/manifest"
+	"github.com/chaoscypher/kube-save-restore/internal/redact"
+	"github.com/chaoscypher/kube-save-restore/internal/restore"
+	"github.com/chaoscypher/kube-save-restore/internal/storage"
+	"github.com/klauspost/compress/zstd"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 	appsv1 "k8s.io/api/apps/v1"
@@ -15,6 +28,10 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
 )
 
 // MockKubernetesClient is a mock implementation of the KubernetesClient interface
@@ -35,125 +52,149 @@ func (m *MockKubernetesClient) GetNamespaces(ctx context.Context) (*corev1.Names
 }
 
 // ListDeployments mocks the ListDeployments method of the KubernetesClient interface
-func (m *MockKubernetesClient) ListDeployments(ctx context.Context, namespace string) (*appsv1.DeploymentList, error) {
-	args := m.Called(ctx, namespace)
+func (m *MockKubernetesClient) ListDeployments(ctx context.Context, namespace string, opts metav1.ListOptions) (*appsv1.DeploymentList, error) {
+	args := m.Called(ctx, namespace, opts)
 	return args.Get(0).(*appsv1.DeploymentList), args.Error(1)
 }
 
 // ListServices mocks the ListServices method of the KubernetesClient interface
-func (m *MockKubernetesClient) ListServices(ctx context.Context, namespace string) (*corev1.ServiceList, error) {
-	args := m.Called(ctx, namespace)
+func (m *MockKubernetesClient) ListServices(ctx context.Context, namespace string, opts metav1.ListOptions) (*corev1.ServiceList, error) {
+	args := m.Called(ctx, namespace, opts)
 	return args.Get(0).(*corev1.ServiceList), args.Error(1)
 }
 
 // ListConfigMaps mocks the ListConfigMaps method of the KubernetesClient interface
-func (m *MockKubernetesClient) ListConfigMaps(ctx context.Context, namespace string) (*corev1.ConfigMapList, error) {
-	args := m.Called(ctx, namespace)
+func (m *MockKubernetesClient) ListConfigMaps(ctx context.Context, namespace string, opts metav1.ListOptions) (*corev1.ConfigMapList, error) {
+	args := m.Called(ctx, namespace, opts)
 	return args.Get(0).(*corev1.ConfigMapList), args.Error(1)
 }
 
 // ListSecrets mocks the ListSecrets method of the KubernetesClient interface
-func (m *MockKubernetesClient) ListSecrets(ctx context.Context, namespace string) (*corev1.SecretList, error) {
-	args := m.Called(ctx, namespace)
+func (m *MockKubernetesClient) ListSecrets(ctx context.Context, namespace string, opts metav1.ListOptions) (*corev1.SecretList, error) {
+	args := m.Called(ctx, namespace, opts)
 	return args.Get(0).(*corev1.SecretList), args.Error(1)
 }
 
 // ListServiceAccounts mocks the ListServiceAccounts method of the KubernetesClient interface
-func (m *MockKubernetesClient) ListServiceAccounts(ctx context.Context, namespace string) (*corev1.ServiceAccountList, error) {
-	args := m.Called(ctx, namespace)
+func (m *MockKubernetesClient) ListServiceAccounts(ctx context.Context, namespace string, opts metav1.ListOptions) (*corev1.ServiceAccountList, error) {
+	args := m.Called(ctx, namespace, opts)
 	return args.Get(0).(*corev1.ServiceAccountList), args.Error(1)
 }
 
 // ListStatefulSets mocks the ListStatefulSets method of the KubernetesClient interface
-func (m *MockKubernetesClient) ListStatefulSets(ctx context.Context, namespace string) (*appsv1.StatefulSetList, error) {
-	args := m.Called(ctx, namespace)
+func (m *MockKubernetesClient) ListStatefulSets(ctx context.Context, namespace string, opts metav1.ListOptions) (*appsv1.StatefulSetList, error) {
+	args := m.Called(ctx, namespace, opts)
 	return args.Get(0).(*appsv1.StatefulSetList), args.Error(1)
 }
 
 // ListHorizontalPodAutoscalers mocks the ListHorizontalPodAutoscalers method of the KubernetesClient interface
-func (m *MockKubernetesClient) ListHorizontalPodAutoscalers(ctx context.Context, namespace string) (*autoscalingv2.HorizontalPodAutoscalerList, error) {
-	args := m.Called(ctx, namespace)
+func (m *MockKubernetesClient) ListHorizontalPodAutoscalers(ctx context.Context, namespace string, opts metav1.ListOptions) (*autoscalingv2.HorizontalPodAutoscalerList, error) {
+	args := m.Called(ctx, namespace, opts)
 	return args.Get(0).(*autoscalingv2.HorizontalPodAutoscalerList), args.Error(1)
 }
 
 // ListCronJobs mocks the ListCronJobs method of the KubernetesClient interface.
-func (m *MockKubernetesClient) ListCronJobs(ctx context.Context, namespace string) (*batchv1.CronJobList, error) {
-	args := m.Called(ctx, namespace)
+func (m *MockKubernetesClient) ListCronJobs(ctx context.Context, namespace string, opts metav1.ListOptions) (*batchv1.CronJobList, error) {
+	args := m.Called(ctx, namespace, opts)
 	return args.Get(0).(*batchv1.CronJobList), args.Error(1)
 }
 
 // ListJobs mocks the ListJobs method of the KubernetesClient interface
-func (m *MockKubernetesClient) ListJobs(ctx context.Context, namespace string) (*batchv1.JobList, error) {
-	args := m.Called(ctx, namespace)
+func (m *MockKubernetesClient) ListJobs(ctx context.Context, namespace string, opts metav1.ListOptions) (*batchv1.JobList, error) {
+	args := m.Called(ctx, namespace, opts)
 	return args.Get(0).(*batchv1.JobList), args.Error(1)
 }
 
 // ListPersistentVolumeClaims mocks the ListPersistentVolumeClaims method of the KubernetesClient interface
-func (m *MockKubernetesClient) ListPersistentVolumeClaims(ctx context.Context, namespace string) (*corev1.PersistentVolumeClaimList, error) {
-	args := m.Called(ctx, namespace)
+func (m *MockKubernetesClient) ListPersistentVolumeClaims(ctx context.Context, namespace string, opts metav1.ListOptions) (*corev1.PersistentVolumeClaimList, error) {
+	args := m.Called(ctx, namespace, opts)
 	return args.Get(0).(*corev1.PersistentVolumeClaimList), args.Error(1)
 }
 
 // ListDaemonSets mocks the ListDaemonSets method of the KubernetesClient interface
-func (m *MockKubernetesClient) ListDaemonSets(ctx context.Context, namespace string) (*appsv1.DaemonSetList, error) {
-	args := m.Called(ctx, namespace)
+func (m *MockKubernetesClient) ListDaemonSets(ctx context.Context, namespace string, opts metav1.ListOptions) (*appsv1.DaemonSetList, error) {
+	args := m.Called(ctx, namespace, opts)
 	return args.Get(0).(*appsv1.DaemonSetList), args.Error(1)
 }
 
 // ListIngresses mocks the ListIngresses method of the KubernetesClient interface
-func (m *MockKubernetesClient) ListIngresses(ctx context.Context, namespace string) (*networkingv1.IngressList, error) {
-	args := m.Called(ctx, namespace)
+func (m *MockKubernetesClient) ListIngresses(ctx context.Context, namespace string, opts metav1.ListOptions) (*networkingv1.IngressList, error) {
+	args := m.Called(ctx, namespace, opts)
 	return args.Get(0).(*networkingv1.IngressList), args.Error(1)
 }
 
 // ListRoles mocks the ListRoles method of the KubernetesClient interface
-func (m *MockKubernetesClient) ListRoles(ctx context.Context, namespace string) (*rbacv1.RoleList, error) {
-	args := m.Called(ctx, namespace)
+func (m *MockKubernetesClient) ListRoles(ctx context.Context, namespace string, opts metav1.ListOptions) (*rbacv1.RoleList, error) {
+	args := m.Called(ctx, namespace, opts)
 	return args.Get(0).(*rbacv1.RoleList), args.Error(1)
 }
 
+// ListRoleBindings mocks the ListRoleBindings method of the KubernetesClient interface
+func (m *MockKubernetesClient) ListRoleBindings(ctx context.Context, namespace string, opts metav1.ListOptions) (*rbacv1.RoleBindingList, error) {
+	args := m.Called(ctx, namespace, opts)
+	return args.Get(0).(*rbacv1.RoleBindingList), args.Error(1)
+}
+
 // ListNetworkPolicies mocks the ListNetworkPolicies method of the KubernetesClient interface
-func (m *MockKubernetesClient) ListNetworkPolicies(ctx context.Context, namespace string) (*networkingv1.NetworkPolicyList, error) {
-	args := m.Called(ctx, namespace)
+func (m *MockKubernetesClient) ListNetworkPolicies(ctx context.Context, namespace string, opts metav1.ListOptions) (*networkingv1.NetworkPolicyList, error) {
+	args := m.Called(ctx, namespace, opts)
 	return args.Get(0).(*networkingv1.NetworkPolicyList), args.Error(1)
 }
 
+// ListNamespacedAPIResources mocks the ListNamespacedAPIResources method of the KubernetesClient interface
+func (m *MockKubernetesClient) ListNamespacedAPIResources(ctx context.Context) ([]metav1.APIResource, error) {
+	args := m.Called(ctx)
+	return args.Get(0).([]metav1.APIResource), args.Error(1)
+}
+
+// ListResources mocks the ListResources method of the KubernetesClient interface
+func (m *MockKubernetesClient) ListResources(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	args := m.Called(ctx, gvr, namespace, opts)
+	return args.Get(0).(*unstructured.UnstructuredList), args.Error(1)
+}
+
 // setupMockClient creates and configures a MockKubernetesClient with default expectations
 func setupMockClient() *MockKubernetesClient {
 	mockClient := new(MockKubernetesClient)
 	mockClient.On("ListNamespaces", mock.Anything).Return([]string{"default", "kube-system"}, nil)
-	mockClient.On("GetNamespaces", mock.Anything).Return(&corev1.NamespaceList{Items: make([]corev1.Namespace, 2)}, nil)
+	mockClient.On("GetNamespaces", mock.Anything).Return(&corev1.NamespaceList{Items: []corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+	}}, nil)
+	mockClient.On("ListResources", mock.Anything, mock.Anything, "", mock.Anything).Return(&unstructured.UnstructuredList{Items: []unstructured.Unstructured{newUnstructured("", "", "", "")}}, nil)
 
 	// Set up expectations for the default namespace
-	mockClient.On("ListDeployments", mock.Anything, "default").Return(&appsv1.DeploymentList{Items: make([]appsv1.Deployment, 1)}, nil)
-	mockClient.On("ListServices", mock.Anything, "default").Return(&corev1.ServiceList{Items: make([]corev1.Service, 1)}, nil)
-	mockClient.On("ListConfigMaps", mock.Anything, "default").Return(&corev1.ConfigMapList{Items: make([]corev1.ConfigMap, 1)}, nil)
-	mockClient.On("ListSecrets", mock.Anything, "default").Return(&corev1.SecretList{Items: make([]corev1.Secret, 1)}, nil)
-	mockClient.On("ListServiceAccounts", mock.Anything, "default").Return(&corev1.ServiceAccountList{Items: make([]corev1.ServiceAccount, 1)}, nil)
-	mockClient.On("ListStatefulSets", mock.Anything, "default").Return(&appsv1.StatefulSetList{Items: make([]appsv1.StatefulSet, 1)}, nil)
-	mockClient.On("ListHorizontalPodAutoscalers", mock.Anything, "default").Return(&autoscalingv2.HorizontalPodAutoscalerList{Items: make([]autoscalingv2.HorizontalPodAutoscaler, 1)}, nil)
-	mockClient.On("ListDaemonSets", mock.Anything, "default").Return(&appsv1.DaemonSetList{Items: make([]appsv1.DaemonSet, 1)}, nil)
-	mockClient.On("ListCronJobs", mock.Anything, "default").Return(&batchv1.CronJobList{Items: make([]batchv1.CronJob, 1)}, nil)
-	mockClient.On("ListJobs", mock.Anything, "default").Return(&batchv1.JobList{Items: make([]batchv1.Job, 1)}, nil)
-	mockClient.On("ListPersistentVolumeClaims", mock.Anything, "default").Return(&corev1.PersistentVolumeClaimList{Items: make([]corev1.PersistentVolumeClaim, 1)}, nil)
-	mockClient.On("ListIngresses", mock.Anything, "default").Return(&networkingv1.IngressList{Items: make([]networkingv1.Ingress, 1)}, nil)
-	mockClient.On("ListRoles", mock.Anything, "default").Return(&rbacv1.RoleList{Items: make([]rbacv1.Role, 1)}, nil)
-	mockClient.On("ListNetworkPolicies", mock.Anything, "default").Return(&networkingv1.NetworkPolicyList{Items: make([]networkingv1.NetworkPolicy, 1)}, nil)
+	mockClient.On("ListDeployments", mock.Anything, "default", mock.Anything).Return(&appsv1.DeploymentList{Items: make([]appsv1.Deployment, 1)}, nil)
+	mockClient.On("ListServices", mock.Anything, "default", mock.Anything).Return(&corev1.ServiceList{Items: make([]corev1.Service, 1)}, nil)
+	mockClient.On("ListConfigMaps", mock.Anything, "default", mock.Anything).Return(&corev1.ConfigMapList{Items: make([]corev1.ConfigMap, 1)}, nil)
+	mockClient.On("ListSecrets", mock.Anything, "default", mock.Anything).Return(&corev1.SecretList{Items: make([]corev1.Secret, 1)}, nil)
+	mockClient.On("ListServiceAccounts", mock.Anything, "default", mock.Anything).Return(&corev1.ServiceAccountList{Items: make([]corev1.ServiceAccount, 1)}, nil)
+	mockClient.On("ListStatefulSets", mock.Anything, "default", mock.Anything).Return(&appsv1.StatefulSetList{Items: make([]appsv1.StatefulSet, 1)}, nil)
+	mockClient.On("ListHorizontalPodAutoscalers", mock.Anything, "default", mock.Anything).Return(&autoscalingv2.HorizontalPodAutoscalerList{Items: make([]autoscalingv2.HorizontalPodAutoscaler, 1)}, nil)
+	mockClient.On("ListDaemonSets", mock.Anything, "default", mock.Anything).Return(&appsv1.DaemonSetList{Items: make([]appsv1.DaemonSet, 1)}, nil)
+	mockClient.On("ListCronJobs", mock.Anything, "default", mock.Anything).Return(&batchv1.CronJobList{Items: make([]batchv1.CronJob, 1)}, nil)
+	mockClient.On("ListJobs", mock.Anything, "default", mock.Anything).Return(&batchv1.JobList{Items: make([]batchv1.Job, 1)}, nil)
+	mockClient.On("ListPersistentVolumeClaims", mock.Anything, "default", mock.Anything).Return(&corev1.PersistentVolumeClaimList{Items: make([]corev1.PersistentVolumeClaim, 1)}, nil)
+	mockClient.On("ListIngresses", mock.Anything, "default", mock.Anything).Return(&networkingv1.IngressList{Items: make([]networkingv1.Ingress, 1)}, nil)
+	mockClient.On("ListRoles", mock.Anything, "default", mock.Anything).Return(&rbacv1.RoleList{Items: make([]rbacv1.Role, 1)}, nil)
+	mockClient.On("ListRoleBindings", mock.Anything, "default", mock.Anything).Return(&rbacv1.RoleBindingList{Items: make([]rbacv1.RoleBinding, 1)}, nil)
+	mockClient.On("ListNetworkPolicies", mock.Anything, "default", mock.Anything).Return(&networkingv1.NetworkPolicyList{Items: make([]networkingv1.NetworkPolicy, 1)}, nil)
 	// Set up expectations for the kube-system namespace
-	mockClient.On("ListDeployments", mock.Anything, "kube-system").Return(&appsv1.DeploymentList{Items: make([]appsv1.Deployment, 2)}, nil)
-	mockClient.On("ListServices", mock.Anything, "kube-system").Return(&corev1.ServiceList{Items: make([]corev1.Service, 3)}, nil)
-	mockClient.On("ListConfigMaps", mock.Anything, "kube-system").Return(&corev1.ConfigMapList{Items: make([]corev1.ConfigMap, 4)}, nil)
-	mockClient.On("ListSecrets", mock.Anything, "kube-system").Return(&corev1.SecretList{Items: make([]corev1.Secret, 5)}, nil)
-	mockClient.On("ListServiceAccounts", mock.Anything, "kube-system").Return(&corev1.ServiceAccountList{Items: make([]corev1.ServiceAccount, 3)}, nil)
-	mockClient.On("ListStatefulSets", mock.Anything, "kube-system").Return(&appsv1.StatefulSetList{Items: make([]appsv1.StatefulSet, 1)}, nil)
-	mockClient.On("ListHorizontalPodAutoscalers", mock.Anything, "kube-system").Return(&autoscalingv2.HorizontalPodAutoscalerList{Items: make([]autoscalingv2.HorizontalPodAutoscaler, 2)}, nil)
-	mockClient.On("ListDaemonSets", mock.Anything, "kube-system").Return(&appsv1.DaemonSetList{Items: make([]appsv1.DaemonSet, 2)}, nil)
-	mockClient.On("ListCronJobs", mock.Anything, "kube-system").Return(&batchv1.CronJobList{Items: make([]batchv1.CronJob, 1)}, nil)
-	mockClient.On("ListJobs", mock.Anything, "kube-system").Return(&batchv1.JobList{Items: make([]batchv1.Job, 1)}, nil)
-	mockClient.On("ListPersistentVolumeClaims", mock.Anything, "kube-system").Return(&corev1.PersistentVolumeClaimList{Items: make([]corev1.PersistentVolumeClaim, 2)}, nil)
-	mockClient.On("ListIngresses", mock.Anything, "kube-system").Return(&networkingv1.IngressList{Items: make([]networkingv1.Ingress, 2)}, nil)
-	mockClient.On("ListRoles", mock.Anything, "kube-system").Return(&rbacv1.RoleList{Items: make([]rbacv1.Role, 2)}, nil)
-	mockClient.On("ListNetworkPolicies", mock.Anything, "kube-system").Return(&networkingv1.NetworkPolicyList{Items: make([]networkingv1.NetworkPolicy, 2)}, nil)
+	mockClient.On("ListDeployments", mock.Anything, "kube-system", mock.Anything).Return(&appsv1.DeploymentList{Items: make([]appsv1.Deployment, 2)}, nil)
+	mockClient.On("ListServices", mock.Anything, "kube-system", mock.Anything).Return(&corev1.ServiceList{Items: make([]corev1.Service, 3)}, nil)
+	mockClient.On("ListConfigMaps", mock.Anything, "kube-system", mock.Anything).Return(&corev1.ConfigMapList{Items: make([]corev1.ConfigMap, 4)}, nil)
+	mockClient.On("ListSecrets", mock.Anything, "kube-system", mock.Anything).Return(&corev1.SecretList{Items: make([]corev1.Secret, 5)}, nil)
+	mockClient.On("ListServiceAccounts", mock.Anything, "kube-system", mock.Anything).Return(&corev1.ServiceAccountList{Items: make([]corev1.ServiceAccount, 3)}, nil)
+	mockClient.On("ListStatefulSets", mock.Anything, "kube-system", mock.Anything).Return(&appsv1.StatefulSetList{Items: make([]appsv1.StatefulSet, 1)}, nil)
+	mockClient.On("ListHorizontalPodAutoscalers", mock.Anything, "kube-system", mock.Anything).Return(&autoscalingv2.HorizontalPodAutoscalerList{Items: make([]autoscalingv2.HorizontalPodAutoscaler, 2)}, nil)
+	mockClient.On("ListDaemonSets", mock.Anything, "kube-system", mock.Anything).Return(&appsv1.DaemonSetList{Items: make([]appsv1.DaemonSet, 2)}, nil)
+	mockClient.On("ListCronJobs", mock.Anything, "kube-system", mock.Anything).Return(&batchv1.CronJobList{Items: make([]batchv1.CronJob, 1)}, nil)
+	mockClient.On("ListJobs", mock.Anything, "kube-system", mock.Anything).Return(&batchv1.JobList{Items: make([]batchv1.Job, 1)}, nil)
+	mockClient.On("ListPersistentVolumeClaims", mock.Anything, "kube-system", mock.Anything).Return(&corev1.PersistentVolumeClaimList{Items: make([]corev1.PersistentVolumeClaim, 2)}, nil)
+	mockClient.On("ListIngresses", mock.Anything, "kube-system", mock.Anything).Return(&networkingv1.IngressList{Items: make([]networkingv1.Ingress, 2)}, nil)
+	mockClient.On("ListRoles", mock.Anything, "kube-system", mock.Anything).Return(&rbacv1.RoleList{Items: make([]rbacv1.Role, 2)}, nil)
+	mockClient.On("ListRoleBindings", mock.Anything, "kube-system", mock.Anything).Return(&rbacv1.RoleBindingList{Items: make([]rbacv1.RoleBinding, 3)}, nil)
+	mockClient.On("ListNetworkPolicies", mock.Anything, "kube-system", mock.Anything).Return(&networkingv1.NetworkPolicyList{Items: make([]networkingv1.NetworkPolicy, 2)}, nil)
 	return mockClient
 }
 
@@ -220,11 +261,447 @@ func TestCountResources(t *testing.T) {
 
 	// The total should be the sum of all resources in both namespaces
 	// and cluster-wide resources
-	// default namespace: 14 (1 of each resource type)
-	// kube-system namespace: 32
-	// cluster-wide: 2
-	expectedCount := 48
+	// default namespace: 15 (1 of each resource type)
+	// kube-system namespace: 35
+	// cluster-wide: 2 namespaces and 1 of each other cluster-scoped resource type
+	expectedCount := 52 + len(clusterResourceTypes)
 	assert.Equal(t, expectedCount, count)
 
 	mockClient.AssertExpectations(t)
 }
+
+// TestPerformBackupDiscovery tests that discovery mode backs up every discovered resource, including custom resources
+func TestPerformBackupDiscovery(t *testing.T) {
+	backupDir, err := os.MkdirTemp("", "k8s-backup-test")
+	if err != nil {
+		t.Fatalf("Failed to create temporary backup directory: %v", err)
+	}
+	defer os.RemoveAll(backupDir)
+
+	certificates := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	pods := schema.GroupVersionResource{Version: "v1", Resource: "pods"}
+
+	controlledPod := newUnstructured("v1", "Pod", "default", "web-7d9f-abcde")
+	isController := true
+	controlledPod.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-7d9f", Controller: &isController}})
+
+	mockClient := new(MockKubernetesClient)
+	mockClient.On("ListNamespaces", mock.Anything).Return([]string{"default"}, nil)
+	mockClient.On("GetNamespaces", mock.Anything).Return(&corev1.NamespaceList{Items: make([]corev1.Namespace, 1)}, nil)
+	mockClient.On("ListResources", mock.Anything, mock.Anything, "", mock.Anything).Return(&unstructured.UnstructuredList{}, nil)
+	mockClient.On("ListNamespacedAPIResources", mock.Anything).Return([]metav1.APIResource{
+		{Name: "configmaps", Version: "v1", Kind: "ConfigMap", Namespaced: true},
+		{Name: "events", Version: "v1", Kind: "Event", Namespaced: true},
+		{Name: "endpoints", Version: "v1", Kind: "Endpoints", Namespaced: true},
+		{Name: "endpointslices", Group: "discovery.k8s.io", Version: "v1", Kind: "EndpointSlice", Namespaced: true},
+		{Name: "controllerrevisions", Group: "apps", Version: "v1", Kind: "ControllerRevision", Namespaced: true},
+		{Name: "leases", Group: "coordination.k8s.io", Version: "v1", Kind: "Lease", Namespaced: true},
+		{Name: "pods", Version: "v1", Kind: "Pod", Namespaced: true},
+		{Name: "certificates", Group: "cert-manager.io", Version: "v1", Kind: "Certificate", Namespaced: true},
+	}, nil)
+	mockClient.On("ListResources", mock.Anything, configMaps, "default", mock.Anything).Return(&unstructured.UnstructuredList{
+		Items: []unstructured.Unstructured{newUnstructured("v1", "ConfigMap", "default", "app-config")},
+	}, nil)
+	mockClient.On("ListResources", mock.Anything, certificates, "default", mock.Anything).Return(&unstructured.UnstructuredList{
+		Items: []unstructured.Unstructured{newUnstructured("cert-manager.io/v1", "Certificate", "default", "app-tls")},
+	}, nil)
+
+	mockClient.On("ListResources", mock.Anything, pods, "default", mock.Anything).Return(&unstructured.UnstructuredList{
+		Items: []unstructured.Unstructured{controlledPod, newUnstructured("v1", "Pod", "default", "debug")},
+	}, nil)
+
+	manager := NewManager(mockClient, backupDir, false, logger.NewLogger(os.Stdout, logger.DEBUG), WithDiscovery(true))
+
+	err = manager.PerformBackup(context.Background())
+	assert.NoError(t, err)
+
+	assert.FileExists(t, filepath.Join(backupDir, "default", "configmaps", "app-config.json"))
+	assert.FileExists(t, filepath.Join(backupDir, "default", "certificates.cert-manager.io", "app-tls.json"))
+	assert.NoDirExists(t, filepath.Join(backupDir, "default", "events"))
+	for _, dir := range []string{"endpoints", "endpointslices.discovery.k8s.io", "controllerrevisions.apps", "leases.coordination.k8s.io"} {
+		assert.NoDirExists(t, filepath.Join(backupDir, "default", dir))
+	}
+	assert.FileExists(t, filepath.Join(backupDir, "default", "pods", "debug.json"))
+	assert.NoFileExists(t, filepath.Join(backupDir, "default", "pods", "web-7d9f-abcde.json"))
+
+	mockClient.AssertExpectations(t)
+	mockClient.AssertNotCalled(t, "ListDeployments", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestCountDynamicResourcesMatchesBackup tests that discovery mode does not count the controller-managed objects it skips
+func TestCountDynamicResourcesMatchesBackup(t *testing.T) {
+	backupDir := t.TempDir()
+
+	pods := metav1.APIResource{Name: "pods", Version: "v1", Kind: "Pod", Namespaced: true}
+	controlledPod := newUnstructured("v1", "Pod", "default", "web-7d9f-abcde")
+	isController := true
+	controlledPod.SetOwnerReferences([]metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "ReplicaSet", Name: "web-7d9f", Controller: &isController}})
+
+	mockClient := new(MockKubernetesClient)
+	mockClient.On("ListResources", mock.Anything, schema.GroupVersionResource{Version: "v1", Resource: "pods"}, "default", mock.Anything).Return(&unstructured.UnstructuredList{
+		Items: []unstructured.Unstructured{controlledPod, newUnstructured("v1", "Pod", "default", "debug")},
+	}, nil)
+
+	manager := NewManager(mockClient, backupDir, false, logger.NewLogger(os.Stdout, logger.DEBUG), WithDiscovery(true))
+	manager.apiResources = []metav1.APIResource{pods}
+
+	count := manager.countDynamicResourcesInNamespace(context.Background(), "default")
+	assert.NoError(t, manager.backupDynamicResource(context.Background(), pods, "default"))
+
+	saved, err := filepath.Glob(filepath.Join(backupDir, "default", "pods", "*.json"))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+	assert.Len(t, saved, count)
+}
+
+// newUnstructured builds an unstructured object with the given identity
+func newUnstructured(apiVersion, kind, namespace, name string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{}
+	obj.SetAPIVersion(apiVersion)
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+// TestSaveResource tests that resources are saved as plain manifests with their full apiVersion
+func TestSaveResource(t *testing.T) {
+	backupDir := t.TempDir()
+	manager := setupManager(new(MockKubernetesClient), backupDir, false)
+
+	deployment := appsv1.Deployment{}
+	deployment.Name = "web"
+	deployment.Namespace = "default"
+	filename := filepath.Join(backupDir, "default", "deployments", "web.json")
+
+	err := manager.saveResource(&deployment, appsv1.SchemeGroupVersion.WithKind("Deployment"), filename)
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(filename)
+	assert.NoError(t, err)
+
+	var manifest map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &manifest))
+	assert.Equal(t, "apps/v1", manifest["apiVersion"])
+	assert.Equal(t, "Deployment", manifest["kind"])
+	assert.Equal(t, "web", manifest["metadata"].(map[string]interface{})["name"])
+	assert.NotContains(t, manifest, "resource")
+}
+
+// TestSaveResourceYAML tests that resources are written as plain YAML manifests with a .yaml extension
+func TestSaveResourceYAML(t *testing.T) {
+	backupDir := t.TempDir()
+	mockClient := new(MockKubernetesClient)
+	mockClient.On("ListConfigMaps", mock.Anything, "default", mock.Anything).Return(&corev1.ConfigMapList{
+		Items: []corev1.ConfigMap{{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default"}, Data: map[string]string{"mode": "fast"}}},
+	}, nil)
+	manager := NewManager(mockClient, backupDir, false, logger.NewLogger(os.Stdout, logger.DEBUG), WithOutputFormat(OutputFormatYAML))
+
+	err := manager.backupConfigMaps(context.Background(), "default")
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(backupDir, "default", "configmaps", "settings.yaml"))
+	assert.NoError(t, err)
+
+	var manifest map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(data, &manifest))
+	assert.Equal(t, "v1", manifest["apiVersion"])
+	assert.Equal(t, "ConfigMap", manifest["kind"])
+	assert.Equal(t, "settings", manifest["metadata"].(map[string]interface{})["name"])
+	assert.Equal(t, "fast", manifest["data"].(map[string]interface{})["mode"])
+	assert.NoFileExists(t, filepath.Join(backupDir, "default", "configmaps", "settings.json"))
+}
+
+// TestSaveResourceEncryptsSecrets tests that Secret data is encrypted when an encrypter is configured
+func TestSaveResourceEncryptsSecrets(t *testing.T) {
+	backupDir := t.TempDir()
+	encrypter, err := encryption.NewEncrypter("passphrase")
+	assert.NoError(t, err)
+
+	mockClient := new(MockKubernetesClient)
+	mockClient.On("ListSecrets", mock.Anything, "default", mock.Anything).Return(&corev1.SecretList{
+		Items: []corev1.Secret{{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"}, Data: map[string][]byte{"password": []byte("hunter2")}}},
+	}, nil)
+	mockClient.On("ListConfigMaps", mock.Anything, "default", mock.Anything).Return(&corev1.ConfigMapList{
+		Items: []corev1.ConfigMap{{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default"}, Data: map[string]string{"mode": "fast"}}},
+	}, nil)
+	manager := NewManager(mockClient, backupDir, false, logger.NewLogger(os.Stdout, logger.DEBUG), WithEncryption(encrypter))
+
+	assert.NoError(t, manager.backupSecrets(context.Background(), "default"))
+	assert.NoError(t, manager.backupConfigMaps(context.Background(), "default"))
+
+	data, err := os.ReadFile(filepath.Join(backupDir, "default", "secrets", "db.json"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), base64.StdEncoding.EncodeToString([]byte("hunter2")))
+
+	var secret map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &secret))
+	assert.True(t, encryption.IsEncrypted(secret))
+	assert.NoError(t, encryption.NewDecrypter("passphrase").DecryptSecret(secret))
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte("hunter2")), secret["data"].(map[string]interface{})["password"])
+
+	data, err = os.ReadFile(filepath.Join(backupDir, "default", "configmaps", "settings.json"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"mode": "fast"`)
+}
+
+// TestSaveResourceRedacts tests that sensitive values are replaced with placeholders when a redactor is configured
+func TestSaveResourceRedacts(t *testing.T) {
+	backupDir := t.TempDir()
+	redactor, err := redact.New(redact.DefaultPatterns)
+	assert.NoError(t, err)
+
+	mockClient := new(MockKubernetesClient)
+	mockClient.On("ListSecrets", mock.Anything, "default", mock.Anything).Return(&corev1.SecretList{
+		Items: []corev1.Secret{{ObjectMeta: metav1.ObjectMeta{Name: "db", Namespace: "default"}, Data: map[string][]byte{"password": []byte("hunter2")}}},
+	}, nil)
+	mockClient.On("ListDeployments", mock.Anything, "default", mock.Anything).Return(&appsv1.DeploymentList{
+		Items: []appsv1.Deployment{{
+			ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+			Spec: appsv1.DeploymentSpec{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+				Name: "web",
+				Env:  []corev1.EnvVar{{Name: "API_TOKEN", Value: "abc123"}, {Name: "PORT", Value: "8080"}},
+			}}}}},
+		}},
+	}, nil)
+	manager := NewManager(mockClient, backupDir, false, logger.NewLogger(os.Stdout, logger.DEBUG), WithRedactor(redactor))
+
+	assert.NoError(t, manager.backupSecrets(context.Background(), "default"))
+	assert.NoError(t, manager.backupDeployments(context.Background(), "default"))
+
+	data, err := os.ReadFile(filepath.Join(backupDir, "default", "secrets", "db.json"))
+	assert.NoError(t, err)
+	var secret map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &secret))
+	assert.True(t, redact.IsRedacted(secret))
+	assert.Equal(t, base64.StdEncoding.EncodeToString([]byte(redact.Placeholder)), secret["data"].(map[string]interface{})["password"])
+
+	data, err = os.ReadFile(filepath.Join(backupDir, "default", "deployments", "web.json"))
+	assert.NoError(t, err)
+	assert.NotContains(t, string(data), "abc123")
+	assert.Contains(t, string(data), `"value": "8080"`)
+}
+
+// TestSaveResourceStorage tests that backup files are written to the configured storage
+func TestSaveResourceStorage(t *testing.T) {
+	root := t.TempDir()
+	mockClient := new(MockKubernetesClient)
+	mockClient.On("ListConfigMaps", mock.Anything, "default", mock.Anything).Return(&corev1.ConfigMapList{
+		Items: []corev1.ConfigMap{{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default"}}},
+	}, nil)
+	manager := NewManager(mockClient, "k8s-backup-20240101-000000", false, logger.NewLogger(os.Stdout, logger.DEBUG),
+		WithStorage(storage.NewLocal(root)))
+
+	assert.NoError(t, manager.backupConfigMaps(context.Background(), "default"))
+	assert.FileExists(t, filepath.Join(root, "k8s-backup-20240101-000000", "default", "configmaps", "settings.json"))
+}
+
+// TestBackupClusterResources tests that cluster-scoped resources are saved under the cluster directory
+func TestBackupClusterResources(t *testing.T) {
+	backupDir := t.TempDir()
+	clusterRoles := clusterResourceTypes[0]
+
+	mockClient := new(MockKubernetesClient)
+	mockClient.On("ListResources", mock.Anything, clusterRoles.gvr, "", mock.Anything).Return(&unstructured.UnstructuredList{
+		Items: []unstructured.Unstructured{newUnstructured("", "", "", "view")},
+	}, nil)
+	manager := setupManager(mockClient, backupDir, false)
+
+	err := manager.backupClusterResources(context.Background(), clusterRoles)
+	assert.NoError(t, err)
+
+	data, err := os.ReadFile(filepath.Join(backupDir, "cluster", "clusterroles", "view.json"))
+	assert.NoError(t, err)
+
+	var manifest map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &manifest))
+	assert.Equal(t, "rbac.authorization.k8s.io/v1", manifest["apiVersion"])
+	assert.Equal(t, "ClusterRole", manifest["kind"])
+
+	mockClient.AssertExpectations(t)
+}
+
+// TestPerformBackupNamespaceFilter tests that excluded namespaces are neither backed up nor counted
+func TestPerformBackupNamespaceFilter(t *testing.T) {
+	backupDir := t.TempDir()
+
+	namespaceFilter, err := filter.New(nil, []string{"kube-*"})
+	assert.NoError(t, err)
+
+	mockClient := setupMockClient()
+	manager := NewManager(mockClient, backupDir, false, logger.NewLogger(os.Stdout, logger.DEBUG), WithNamespaceFilter(namespaceFilter))
+
+	// default namespace: 15, cluster-wide: 1 namespace and 1 of each other cluster-scoped resource type
+	assert.Equal(t, 16+len(clusterResourceTypes), manager.countResources(context.Background()))
+
+	err = manager.PerformBackup(context.Background())
+	assert.NoError(t, err)
+
+	assert.FileExists(t, filepath.Join(backupDir, "namespaces", "default.json"))
+	assert.NoFileExists(t, filepath.Join(backupDir, "namespaces", "kube-system.json"))
+	assert.NoDirExists(t, filepath.Join(backupDir, "kube-system"))
+	mockClient.AssertNotCalled(t, "ListDeployments", mock.Anything, "kube-system", mock.Anything)
+}
+
+// TestPerformBackupResourceFilter tests that excluded resource types are neither backed up nor counted
+func TestPerformBackupResourceFilter(t *testing.T) {
+	backupDir := t.TempDir()
+
+	resourceFilter, err := filter.New(nil, []string{"secrets", "jobs", "persistentvolumeclaims", "cluster*"})
+	assert.NoError(t, err)
+
+	mockClient := setupMockClient()
+	manager := NewManager(mockClient, backupDir, false, logger.NewLogger(os.Stdout, logger.DEBUG), WithResourceFilter(resourceFilter))
+
+	// default namespace: 12 (secrets, jobs and pvcs excluded), kube-system namespace: 27,
+	// cluster-wide: 2 namespaces and 1 of each cluster-scoped resource type except clusterroles and clusterrolebindings
+	assert.Equal(t, 12+27+2+len(clusterResourceTypes)-2, manager.countResources(context.Background()))
+
+	err = manager.PerformBackup(context.Background())
+	assert.NoError(t, err)
+
+	assert.DirExists(t, filepath.Join(backupDir, "default", "configmaps"))
+	assert.NoDirExists(t, filepath.Join(backupDir, "default", "secrets"))
+	assert.NoDirExists(t, filepath.Join(backupDir, "default", "pvcs"))
+	assert.NoDirExists(t, filepath.Join(backupDir, "cluster", "clusterroles"))
+	assert.DirExists(t, filepath.Join(backupDir, "cluster", "storageclasses"))
+	mockClient.AssertNotCalled(t, "ListSecrets", mock.Anything, mock.Anything, mock.Anything)
+	mockClient.AssertNotCalled(t, "ListJobs", mock.Anything, mock.Anything, mock.Anything)
+}
+
+// TestPerformBackupSelectors tests that the label and field selectors are passed to every list call
+func TestPerformBackupSelectors(t *testing.T) {
+	backupDir := t.TempDir()
+	listOptions := metav1.ListOptions{LabelSelector: "app.kubernetes.io/part-of=shop", FieldSelector: "metadata.name=web", Limit: defaultPageSize}
+
+	mockClient := setupMockClient()
+	manager := NewManager(mockClient, backupDir, false, logger.NewLogger(os.Stdout, logger.DEBUG), WithSelectors(listOptions.LabelSelector, listOptions.FieldSelector))
+
+	err := manager.PerformBackup(context.Background())
+	assert.NoError(t, err)
+
+	mockClient.AssertCalled(t, "ListDeployments", mock.Anything, "default", listOptions)
+	mockClient.AssertCalled(t, "ListSecrets", mock.Anything, "kube-system", listOptions)
+	mockClient.AssertCalled(t, "ListResources", mock.Anything, clusterResourceTypes[0].gvr, "", listOptions)
+	mockClient.AssertNotCalled(t, "ListDeployments", mock.Anything, mock.Anything, metav1.ListOptions{})
+}
+
+// TestPerformBackupPagination tests that list calls page through results and save the items of every page
+func TestPerformBackupPagination(t *testing.T) {
+	backupDir := t.TempDir()
+
+	firstPage := &corev1.ConfigMapList{
+		ListMeta: metav1.ListMeta{Continue: "page-2"},
+		Items:    []corev1.ConfigMap{{ObjectMeta: metav1.ObjectMeta{Name: "first", Namespace: "default"}}},
+	}
+	secondPage := &corev1.ConfigMapList{
+		Items: []corev1.ConfigMap{{ObjectMeta: metav1.ObjectMeta{Name: "second", Namespace: "default"}}},
+	}
+
+	mockClient := new(MockKubernetesClient)
+	mockClient.On("ListConfigMaps", mock.Anything, "default", metav1.ListOptions{Limit: 1}).Return(firstPage, nil)
+	mockClient.On("ListConfigMaps", mock.Anything, "default", metav1.ListOptions{Limit: 1, Continue: "page-2"}).Return(secondPage, nil)
+	manager := NewManager(mockClient, backupDir, false, logger.NewLogger(os.Stdout, logger.DEBUG), WithPageSize(1))
+
+	count, err := manager.countConfigMaps(context.Background(), "default")
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+
+	err = manager.backupConfigMaps(context.Background(), "default")
+	assert.NoError(t, err)
+
+	assert.FileExists(t, filepath.Join(backupDir, "default", "configmaps", "first.json"))
+	assert.FileExists(t, filepath.Join(backupDir, "default", "configmaps", "second.json"))
+	mockClient.AssertNumberOfCalls(t, "ListConfigMaps", 4)
+}
+
+// TestPerformBackupArchive tests that backups are streamed into a compressed archive instead of a directory
+func TestPerformBackupArchive(t *testing.T) {
+	for _, format := range []string{ArchiveFormatTarGz, ArchiveFormatTarZst} {
+		t.Run(format, func(t *testing.T) {
+			backupDir := filepath.Join(t.TempDir(), "k8s-backup")
+			mockClient := setupMockClient()
+			manager := NewManager(mockClient, backupDir, false, logger.NewLogger(os.Stdout, logger.DEBUG), WithArchive(format))
+
+			err := manager.PerformBackup(context.Background())
+			assert.NoError(t, err)
+
+			assert.NoDirExists(t, backupDir)
+			assert.NoFileExists(t, backupDir+"."+format+".partial")
+
+			file, err := os.Open(backupDir + "." + format)
+			assert.NoError(t, err)
+			defer file.Close()
+
+			var decompressed io.Reader
+			if format == ArchiveFormatTarZst {
+				decoder, err := zstd.NewReader(file)
+				assert.NoError(t, err)
+				defer decoder.Close()
+				decompressed = decoder
+			} else {
+				reader, err := gzip.NewReader(file)
+				assert.NoError(t, err)
+				decompressed = reader
+			}
+
+			entries := map[string]bool{}
+			archive := tar.NewReader(decompressed)
+			for {
+				header, err := archive.Next()
+				if err == io.EOF {
+					break
+				}
+				assert.NoError(t, err)
+				entries[header.Name] = true
+			}
+			assert.True(t, entries["namespaces/default.json"])
+			assert.True(t, entries["kube-system/secrets/.json"])
+			assert.True(t, entries["cluster/clusterroles/.json"])
+		})
+	}
+}
+
+// TestPerformBackupManifest tests that the backup writes a manifest that verifies against the backup
+func TestPerformBackupManifest(t *testing.T) {
+	backupDir := t.TempDir()
+	cluster := manifest.Cluster{Server: "https://cluster.example.com", Context: "prod", ServerVersion: "v1.31.0"}
+
+	resourceFilter, err := filter.New([]string{"namespaces", "configmaps"}, nil)
+	assert.NoError(t, err)
+
+	mockClient := new(MockKubernetesClient)
+	mockClient.On("ListNamespaces", mock.Anything).Return([]string{"default"}, nil)
+	mockClient.On("GetNamespaces", mock.Anything).Return(&corev1.NamespaceList{Items: []corev1.Namespace{
+		{ObjectMeta: metav1.ObjectMeta{Name: "default"}},
+	}}, nil)
+	mockClient.On("ListConfigMaps", mock.Anything, "default", mock.Anything).Return(&corev1.ConfigMapList{Items: []corev1.ConfigMap{
+		{ObjectMeta: metav1.ObjectMeta{Name: "settings", Namespace: "default"}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "features", Namespace: "default"}},
+	}}, nil)
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	assert.NoError(t, err)
+	manager := NewManager(mockClient, backupDir, false, logger.NewLogger(os.Stdout, logger.DEBUG),
+		WithToolVersion("v1.2.3"), WithCluster(cluster), WithResourceFilter(resourceFilter), WithSigningKey(privateKey))
+
+	err = manager.PerformBackup(context.Background())
+	assert.NoError(t, err)
+
+	m, problems, err := restore.VerifyBackup(context.Background(), storage.NewLocal(""), backupDir, publicKey)
+	assert.NoError(t, err)
+	assert.Empty(t, problems)
+	assert.Equal(t, "v1.2.3", m.ToolVersion)
+	assert.Equal(t, cluster, m.Cluster)
+	assert.Equal(t, []string{"default/configmaps/features.json", "default/configmaps/settings.json", "namespaces/default.json"},
+		[]string{m.Files[0].Path, m.Files[1].Path, m.Files[2].Path})
+	assert.Equal(t, manifest.File{
+		Path:      "default/configmaps/settings.json",
+		Kind:      "ConfigMap",
+		Namespace: "default",
+		Name:      "settings",
+		SHA256:    m.Files[1].SHA256,
+		Size:      m.Files[1].Size,
+	}, m.Files[1])
+	assert.Len(t, m.Files, 3)
+}
@@ -0,0 +1,26 @@
+package backup
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// defaultPageSize is the number of objects requested per list call unless configured otherwise
+const defaultPageSize = 500
+
+// forEachPage calls list for every page of a paginated list call until the API server reports no
+// more results. Each call receives the configured list options along with the page size and the
+// continue token of the previous page, so callers can process items as each page arrives.
+func (bm *Manager) forEachPage(list func(opts metav1.ListOptions) (metav1.ListInterface, error)) error {
+	opts := bm.listOptions
+	opts.Limit = bm.pageSize
+	for {
+		page, err := list(opts)
+		if err != nil {
+			return err
+		}
+		if page.GetContinue() == "" {
+			return nil
+		}
+		opts.Continue = page.GetContinue()
+	}
+}
@@ -3,31 +3,50 @@ package backup
 import (
 	"context"
 	"sync"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // countResources counts the total number of resources across specified namespaces concurrently
 func (bm *Manager) countResources(ctx context.Context) int {
-	namespaces, err := bm.client.ListNamespaces(ctx)
+	namespaces, err := bm.listNamespaces(ctx)
 	if err != nil {
 		bm.logger.Errorf("Error listing namespaces: %v", err)
 		return 0
 	}
 
 	var wg sync.WaitGroup
-	resourceCounts := make(chan int, len(namespaces)+1) // +1 for namespace count
+	resourceCounts := make(chan int, len(namespaces)+len(clusterResourceTypes)+1) // +1 for namespace count
 
 	// Count namespaces themselves
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		count, err := bm.countNamespaces(ctx)
-		if err != nil {
-			bm.logger.Errorf("Error counting namespaces: %v", err)
-			resourceCounts <- 0
-		} else {
-			resourceCounts <- count
-		}
-	}()
+	if bm.resources.Matches("namespaces") {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			count, err := bm.countNamespaces(ctx)
+			if err != nil {
+				bm.logger.Errorf("Error counting namespaces: %v", err)
+				resourceCounts <- 0
+			} else {
+				resourceCounts <- count
+			}
+		}()
+	}
+
+	// Count the other cluster-scoped resources
+	for _, resource := range bm.selectedClusterResourceTypes() {
+		wg.Add(1)
+		go func(resource clusterResource) {
+			defer wg.Done()
+			count, err := bm.countClusterResources(ctx, resource)
+			if err != nil {
+				bm.logger.Errorf("Error counting %s: %v", resource.gvr.Resource, err)
+				resourceCounts <- 0
+			} else {
+				resourceCounts <- count
+			}
+		}(resource)
+	}
 
 	for _, ns := range namespaces {
 		wg.Add(1)
@@ -53,7 +72,11 @@ func (bm *Manager) countResources(ctx context.Context) int {
 
 // countResourcesInNamespace counts the resources in a single namespace
 func (bm *Manager) countResourcesInNamespace(ctx context.Context, namespace string) int {
-	resourceTypes := map[string]func(context.Context, string) (int, error){
+	if bm.discovery {
+		return bm.countDynamicResourcesInNamespace(ctx, namespace)
+	}
+
+	countFuncs := map[string]func(context.Context, string) (int, error){
 		"deployments":     bm.countDeployments,
 		"services":        bm.countServices,
 		"configmaps":      bm.countConfigMaps,
@@ -67,13 +90,15 @@ func (bm *Manager) countResourcesInNamespace(ctx context.Context, namespace stri
 		"pvcs":            bm.countPersistentVolumeClaims,
 		"ingresses":       bm.countIngresses,
 		"roles":           bm.countRoles,
+		"rolebindings":    bm.countRoleBindings,
 		"networkpolicies": bm.countNetworkPolicies,
 	}
 
 	var wg sync.WaitGroup
-	counts := make(chan int, len(resourceTypes))
+	counts := make(chan int, len(countFuncs))
 
-	for name, countFn := range resourceTypes {
+	for _, name := range bm.selectedResourceTypes() {
+		countFn := countFuncs[name]
 		wg.Add(1)
 		go func(name string, countFn func(context.Context, string) (int, error)) {
 			defer wg.Done()
@@ -102,107 +127,172 @@ func (bm *Manager) countResourcesInNamespace(ctx context.Context, namespace stri
 
 // Helper functions to count each resource type
 func (bm *Manager) countDeployments(ctx context.Context, namespace string) (int, error) {
-	deployments, err := bm.client.ListDeployments(ctx, namespace)
-	if err != nil {
-		return 0, err
-	}
-	return len(deployments.Items), nil
+	count := 0
+	err := bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		deployments, err := bm.client.ListDeployments(ctx, namespace, opts)
+		if err != nil {
+			return nil, err
+		}
+		count += len(deployments.Items)
+		return deployments, nil
+	})
+	return count, err
 }
 
 func (bm *Manager) countServices(ctx context.Context, namespace string) (int, error) {
-	services, err := bm.client.ListServices(ctx, namespace)
-	if err != nil {
-		return 0, err
-	}
-	return len(services.Items), nil
+	count := 0
+	err := bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		services, err := bm.client.ListServices(ctx, namespace, opts)
+		if err != nil {
+			return nil, err
+		}
+		count += len(services.Items)
+		return services, nil
+	})
+	return count, err
 }
 
 func (bm *Manager) countConfigMaps(ctx context.Context, namespace string) (int, error) {
-	configMaps, err := bm.client.ListConfigMaps(ctx, namespace)
-	if err != nil {
-		return 0, err
-	}
-	return len(configMaps.Items), nil
+	count := 0
+	err := bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		configMaps, err := bm.client.ListConfigMaps(ctx, namespace, opts)
+		if err != nil {
+			return nil, err
+		}
+		count += len(configMaps.Items)
+		return configMaps, nil
+	})
+	return count, err
 }
 
 func (bm *Manager) countSecrets(ctx context.Context, namespace string) (int, error) {
-	secrets, err := bm.client.ListSecrets(ctx, namespace)
-	if err != nil {
-		return 0, err
-	}
-	return len(secrets.Items), nil
+	count := 0
+	err := bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		secrets, err := bm.client.ListSecrets(ctx, namespace, opts)
+		if err != nil {
+			return nil, err
+		}
+		count += len(secrets.Items)
+		return secrets, nil
+	})
+	return count, err
 }
 
 func (bm *Manager) countServiceAccounts(ctx context.Context, namespace string) (int, error) {
-	serviceAccounts, err := bm.client.ListServiceAccounts(ctx, namespace)
-	if err != nil {
-		return 0, err
-	}
-	return len(serviceAccounts.Items), nil
+	count := 0
+	err := bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		serviceAccounts, err := bm.client.ListServiceAccounts(ctx, namespace, opts)
+		if err != nil {
+			return nil, err
+		}
+		count += len(serviceAccounts.Items)
+		return serviceAccounts, nil
+	})
+	return count, err
 }
 
 func (bm *Manager) countStatefulSets(ctx context.Context, namespace string) (int, error) {
-	statefulSets, err := bm.client.ListStatefulSets(ctx, namespace)
-	if err != nil {
-		return 0, err
-	}
-	return len(statefulSets.Items), nil
+	count := 0
+	err := bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		statefulSets, err := bm.client.ListStatefulSets(ctx, namespace, opts)
+		if err != nil {
+			return nil, err
+		}
+		count += len(statefulSets.Items)
+		return statefulSets, nil
+	})
+	return count, err
 }
 
 func (bm *Manager) countDaemonSets(ctx context.Context, namespace string) (int, error) {
-	daemonSets, err := bm.client.ListDaemonSets(ctx, namespace)
-	if err != nil {
-		return 0, err
-	}
-	return len(daemonSets.Items), nil
+	count := 0
+	err := bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		daemonSets, err := bm.client.ListDaemonSets(ctx, namespace, opts)
+		if err != nil {
+			return nil, err
+		}
+		count += len(daemonSets.Items)
+		return daemonSets, nil
+	})
+	return count, err
 }
 
 func (bm *Manager) countHorizontalPodAutoscalers(ctx context.Context, namespace string) (int, error) {
-	hpas, err := bm.client.ListHorizontalPodAutoscalers(ctx, namespace)
-	if err != nil {
-		return 0, err
-	}
-	return len(hpas.Items), nil
+	count := 0
+	err := bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		hpas, err := bm.client.ListHorizontalPodAutoscalers(ctx, namespace, opts)
+		if err != nil {
+			return nil, err
+		}
+		count += len(hpas.Items)
+		return hpas, nil
+	})
+	return count, err
 }
 
 func (bm *Manager) countCronJobs(ctx context.Context, namespace string) (int, error) {
-	cronJobs, err := bm.client.ListCronJobs(ctx, namespace)
-	if err != nil {
-		return 0, err
-	}
-	return len(cronJobs.Items), nil
+	count := 0
+	err := bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		cronJobs, err := bm.client.ListCronJobs(ctx, namespace, opts)
+		if err != nil {
+			return nil, err
+		}
+		count += len(cronJobs.Items)
+		return cronJobs, nil
+	})
+	return count, err
 }
 
 func (bm *Manager) countPersistentVolumeClaims(ctx context.Context, namespace string) (int, error) {
-	pvcs, err := bm.client.ListPersistentVolumeClaims(ctx, namespace)
-	if err != nil {
-		return 0, err
-	}
-	return len(pvcs.Items), nil
+	count := 0
+	err := bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		pvcs, err := bm.client.ListPersistentVolumeClaims(ctx, namespace, opts)
+		if err != nil {
+			return nil, err
+		}
+		count += len(pvcs.Items)
+		return pvcs, nil
+	})
+	return count, err
 }
 
 func (bm *Manager) countJobs(ctx context.Context, namespace string) (int, error) {
-	jobs, err := bm.client.ListJobs(ctx, namespace)
-	if err != nil {
-		return 0, err
-	}
-	return len(jobs.Items), nil
+	count := 0
+	err := bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		jobs, err := bm.client.ListJobs(ctx, namespace, opts)
+		if err != nil {
+			return nil, err
+		}
+		count += len(jobs.Items)
+		return jobs, nil
+	})
+	return count, err
 }
 
 func (bm *Manager) countIngresses(ctx context.Context, namespace string) (int, error) {
-	ingresses, err := bm.client.ListIngresses(ctx, namespace)
-	if err != nil {
-		return 0, err
-	}
-	return len(ingresses.Items), nil
+	count := 0
+	err := bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		ingresses, err := bm.client.ListIngresses(ctx, namespace, opts)
+		if err != nil {
+			return nil, err
+		}
+		count += len(ingresses.Items)
+		return ingresses, nil
+	})
+	return count, err
 }
 
 func (bm *Manager) countNetworkPolicies(ctx context.Context, namespace string) (int, error) {
-	networkPolicies, err := bm.client.ListNetworkPolicies(ctx, namespace)
-	if err != nil {
-		return 0, err
-	}
-	return len(networkPolicies.Items), nil
+	count := 0
+	err := bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		networkPolicies, err := bm.client.ListNetworkPolicies(ctx, namespace, opts)
+		if err != nil {
+			return nil, err
+		}
+		count += len(networkPolicies.Items)
+		return networkPolicies, nil
+	})
+	return count, err
 }
 
 func (bm *Manager) countNamespaces(ctx context.Context) (int, error) {
@@ -210,13 +300,37 @@ func (bm *Manager) countNamespaces(ctx context.Context) (int, error) {
 	if err != nil {
 		return 0, err
 	}
-	return len(namespaces.Items), nil
+	count := 0
+	for _, namespace := range namespaces.Items {
+		if bm.namespaces.Matches(namespace.Name) {
+			count++
+		}
+	}
+	return count, nil
 }
 
 func (bm *Manager) countRoles(ctx context.Context, namespace string) (int, error) {
-	roles, err := bm.client.ListRoles(ctx, namespace)
-	if err != nil {
-		return 0, err
-	}
-	return len(roles.Items), nil
+	count := 0
+	err := bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		roles, err := bm.client.ListRoles(ctx, namespace, opts)
+		if err != nil {
+			return nil, err
+		}
+		count += len(roles.Items)
+		return roles, nil
+	})
+	return count, err
+}
+
+func (bm *Manager) countRoleBindings(ctx context.Context, namespace string) (int, error) {
+	count := 0
+	err := bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		roleBindings, err := bm.client.ListRoleBindings(ctx, namespace, opts)
+		if err != nil {
+			return nil, err
+		}
+		count += len(roleBindings.Items)
+		return roleBindings, nil
+	})
+	return count, err
 }
@@ -3,37 +3,139 @@ package backup
 import (
 	"encoding/json"
 	"fmt"
-	"os"
 	"path/filepath"
+	"time"
+
+	"github.com/chaoscypher/kube-save-restore/internal/manifest"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
 )
 
-// saveResource saves a Kubernetes resource to a JSON file.
-func (bm *Manager) saveResource(resource interface{}, kind, filename string) error {
-	// Create a wrapper struct to include the resource kind
-	wrapper := struct {
-		Kind     string      `json:"kind"`
-		Resource interface{} `json:"resource"`
-	}{
-		Kind:     kind,
-		Resource: resource,
+// Output formats supported for backup files
+const (
+	OutputFormatJSON = "json"
+	OutputFormatYAML = "yaml"
+)
+
+// saveResource saves a Kubernetes resource to a JSON or YAML file, depending on the output format.
+// The file is written to the backup directory or, for archived backups, added to the archive.
+// The resource is written as a plain manifest with its apiVersion and kind set from gvk,
+// so backup files can be applied directly with kubectl or read by other tooling.
+func (bm *Manager) saveResource(resource interface{}, gvk schema.GroupVersionKind, filename string) error {
+	manifest, err := toManifest(resource, gvk)
+	if err != nil {
+		return err
+	}
+
+	// Replace sensitive values with placeholders for shareable backups
+	if bm.redactor != nil && bm.redactor.Redact(manifest) {
+		bm.logger.Debugf("Redacted sensitive values of %s %s", gvk.Kind, filename)
+	}
+
+	// Encrypt Secret data so that it is never written to the backup in the clear
+	if bm.encrypter != nil && gvk.Group == "" && gvk.Kind == "Secret" {
+		if err := bm.encrypter.EncryptSecret(manifest); err != nil {
+			return fmt.Errorf("error encrypting secret: %v", err)
+		}
 	}
 
-	// Marshal the wrapper struct to JSON with indentation
-	data, err := json.MarshalIndent(wrapper, "", "  ")
+	data, err := bm.marshalManifest(manifest)
 	if err != nil {
 		return fmt.Errorf("error marshaling resource: %v", err)
 	}
 
-	// Create the directory structure if it doesn't exist
-	if err := os.MkdirAll(filepath.Dir(filename), 0755); err != nil {
-		return fmt.Errorf("error creating directory: %v", err)
+	if err := bm.writer.WriteFile(filename, data); err != nil {
+		return err
 	}
-
-	// Write the JSON data to the file
-	if err := os.WriteFile(filename, data, 0600); err != nil {
-		return fmt.Errorf("error writing file: %v", err)
+	if err := bm.recordFile(filename, gvk.Kind, manifest, data); err != nil {
+		return err
 	}
 
 	bm.logger.Debugf("Saved resource to file: %s", filename)
 	return nil
 }
+
+// recordFile adds a saved resource file to the backup manifest
+func (bm *Manager) recordFile(filename, kind string, resource map[string]interface{}, data []byte) error {
+	path, err := filepath.Rel(bm.backupDir, filename)
+	if err != nil {
+		return fmt.Errorf("error recording %s in manifest: %v", filename, err)
+	}
+	metadata, _ := resource["metadata"].(map[string]interface{})
+	namespace, _ := metadata["namespace"].(string)
+	name, _ := metadata["name"].(string)
+
+	bm.mu.Lock()
+	defer bm.mu.Unlock()
+	bm.files = append(bm.files, manifest.NewFile(path, kind, namespace, name, data))
+	return nil
+}
+
+// writeManifest writes the manifest describing every file saved by the backup, and its signature if a
+// signing key is configured
+func (bm *Manager) writeManifest() error {
+	m := &manifest.Manifest{
+		CreatedAt:   time.Now().UTC(),
+		ToolVersion: bm.toolVersion,
+		Cluster:     bm.cluster,
+		Files:       bm.files,
+	}
+	data, err := m.Marshal()
+	if err != nil {
+		return fmt.Errorf("error marshaling manifest: %v", err)
+	}
+	if err := bm.writer.WriteFile(filepath.Join(bm.backupDir, manifest.Filename), data); err != nil {
+		return err
+	}
+
+	// Sign the manifest, which covers every file through its checksums
+	if bm.signingKey != nil {
+		signature := manifest.Sign(data, bm.signingKey)
+		if err := bm.writer.WriteFile(filepath.Join(bm.backupDir, manifest.SignatureFilename), signature); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// marshalManifest encodes a manifest in the configured output format
+func (bm *Manager) marshalManifest(manifest map[string]interface{}) ([]byte, error) {
+	if bm.outputFormat == OutputFormatYAML {
+		return yaml.Marshal(manifest)
+	}
+	// Marshal the manifest to JSON with indentation
+	return json.MarshalIndent(manifest, "", "  ")
+}
+
+// fileExtension returns the extension of backup files in the configured output format
+func (bm *Manager) fileExtension() string {
+	if bm.outputFormat == OutputFormatYAML {
+		return ".yaml"
+	}
+	return ".json"
+}
+
+// toManifest converts a typed or unstructured resource into a new map with apiVersion and kind populated.
+// Objects returned by typed clients have an empty TypeMeta, so the type information is taken from gvk.
+func toManifest(resource interface{}, gvk schema.GroupVersionKind) (map[string]interface{}, error) {
+	var manifest map[string]interface{}
+	if object, ok := resource.(map[string]interface{}); ok {
+		manifest = make(map[string]interface{}, len(object)+2)
+		for key, value := range object {
+			manifest[key] = value
+		}
+	} else {
+		var err error
+		manifest, err = runtime.DefaultUnstructuredConverter.ToUnstructured(resource)
+		if err != nil {
+			return nil, fmt.Errorf("error converting resource: %v", err)
+		}
+	}
+
+	apiVersion, kind := gvk.ToAPIVersionAndKind()
+	manifest["apiVersion"] = apiVersion
+	manifest["kind"] = kind
+	return manifest, nil
+}
@@ -4,6 +4,14 @@ import (
 	"context"
 	"fmt"
 	"path/filepath"
+
+	appsv1 "k8s.io/api/apps/v1"
+	autoscalingv2 "k8s.io/api/autoscaling/v2"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 // backupResource handles the backup of a specific resource type in a namespace
@@ -36,6 +44,8 @@ func (bm *Manager) backupResource(ctx context.Context, resourceType, namespace s
 		err = bm.backupIngresses(ctx, namespace)
 	case "roles":
 		err = bm.backupRoles(ctx, namespace)
+	case "rolebindings":
+		err = bm.backupRoleBindings(ctx, namespace)
 	case "networkpolicies":
 		err = bm.backupNetworkPolicies(ctx, namespace)
 	default:
@@ -46,294 +56,332 @@ func (bm *Manager) backupResource(ctx context.Context, resourceType, namespace s
 
 // backupDeployments backs up all deployments in a given namespace
 func (bm *Manager) backupDeployments(ctx context.Context, namespace string) error {
-	deployments, err := bm.client.ListDeployments(ctx, namespace)
-	if err != nil {
-		return fmt.Errorf("error listing deployments in namespace %s: %v", namespace, err)
-	}
+	return bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		deployments, err := bm.client.ListDeployments(ctx, namespace, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing deployments in namespace %s: %v", namespace, err)
+		}
 
-	for _, deployment := range deployments.Items {
-		filename := filepath.Join(bm.backupDir, namespace, "deployments", deployment.Name+".json")
-		if bm.dryRun {
-			bm.logger.Infof("Would backup deployment: %s/%s", namespace, deployment.Name)
-		} else {
-			if err := bm.saveResource(deployment, "Deployment", filename); err != nil {
-				return err
+		for _, deployment := range deployments.Items {
+			filename := filepath.Join(bm.backupDir, namespace, "deployments", deployment.Name+bm.fileExtension())
+			if bm.dryRun {
+				bm.logger.Infof("Would backup deployment: %s/%s", namespace, deployment.Name)
+			} else {
+				if err := bm.saveResource(&deployment, appsv1.SchemeGroupVersion.WithKind("Deployment"), filename); err != nil {
+					return nil, err
+				}
 			}
 		}
-	}
-
-	return nil
+		return deployments, nil
+	})
 }
 
 // backupServices backs up all services in a given namespace
 func (bm *Manager) backupServices(ctx context.Context, namespace string) error {
-	services, err := bm.client.ListServices(ctx, namespace)
-	if err != nil {
-		return fmt.Errorf("error listing services in namespace %s: %v", namespace, err)
-	}
+	return bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		services, err := bm.client.ListServices(ctx, namespace, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing services in namespace %s: %v", namespace, err)
+		}
 
-	for _, service := range services.Items {
-		filename := filepath.Join(bm.backupDir, namespace, "services", service.Name+".json")
-		if bm.dryRun {
-			bm.logger.Infof("Would backup service: %s/%s", namespace, service.Name)
-		} else {
-			if err := bm.saveResource(service, "Service", filename); err != nil {
-				return err
+		for _, service := range services.Items {
+			filename := filepath.Join(bm.backupDir, namespace, "services", service.Name+bm.fileExtension())
+			if bm.dryRun {
+				bm.logger.Infof("Would backup service: %s/%s", namespace, service.Name)
+			} else {
+				if err := bm.saveResource(&service, corev1.SchemeGroupVersion.WithKind("Service"), filename); err != nil {
+					return nil, err
+				}
 			}
 		}
-	}
-
-	return nil
+		return services, nil
+	})
 }
 
 // backupConfigMaps backs up all config maps in a given namespace
 func (bm *Manager) backupConfigMaps(ctx context.Context, namespace string) error {
-	configMaps, err := bm.client.ListConfigMaps(ctx, namespace)
-	if err != nil {
-		return fmt.Errorf("error listing configmaps in namespace %s: %v", namespace, err)
-	}
+	return bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		configMaps, err := bm.client.ListConfigMaps(ctx, namespace, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing configmaps in namespace %s: %v", namespace, err)
+		}
 
-	for _, configMap := range configMaps.Items {
-		filename := filepath.Join(bm.backupDir, namespace, "configmaps", configMap.Name+".json")
-		if bm.dryRun {
-			bm.logger.Infof("Would backup configmap: %s/%s", namespace, configMap.Name)
-		} else {
-			if err := bm.saveResource(configMap, "ConfigMap", filename); err != nil {
-				return err
+		for _, configMap := range configMaps.Items {
+			filename := filepath.Join(bm.backupDir, namespace, "configmaps", configMap.Name+bm.fileExtension())
+			if bm.dryRun {
+				bm.logger.Infof("Would backup configmap: %s/%s", namespace, configMap.Name)
+			} else {
+				if err := bm.saveResource(&configMap, corev1.SchemeGroupVersion.WithKind("ConfigMap"), filename); err != nil {
+					return nil, err
+				}
 			}
 		}
-	}
-
-	return nil
+		return configMaps, nil
+	})
 }
 
 // backupSecrets backs up all secrets in a given namespace
 func (bm *Manager) backupSecrets(ctx context.Context, namespace string) error {
-	secrets, err := bm.client.ListSecrets(ctx, namespace)
-	if err != nil {
-		return fmt.Errorf("error listing secrets in namespace %s: %v", namespace, err)
-	}
+	return bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		secrets, err := bm.client.ListSecrets(ctx, namespace, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing secrets in namespace %s: %v", namespace, err)
+		}
 
-	for _, secret := range secrets.Items {
-		filename := filepath.Join(bm.backupDir, namespace, "secrets", secret.Name+".json")
-		if bm.dryRun {
-			bm.logger.Infof("Would backup secret: %s/%s", namespace, secret.Name)
-		} else {
-			if err := bm.saveResource(secret, "Secret", filename); err != nil {
-				return err
+		for _, secret := range secrets.Items {
+			filename := filepath.Join(bm.backupDir, namespace, "secrets", secret.Name+bm.fileExtension())
+			if bm.dryRun {
+				bm.logger.Infof("Would backup secret: %s/%s", namespace, secret.Name)
+			} else {
+				if err := bm.saveResource(&secret, corev1.SchemeGroupVersion.WithKind("Secret"), filename); err != nil {
+					return nil, err
+				}
 			}
 		}
-	}
-
-	return nil
+		return secrets, nil
+	})
 }
 
 // backupServiceAccounts backs up all service accounts in a given namespace
 func (bm *Manager) backupServiceAccounts(ctx context.Context, namespace string) error {
-	serviceAccounts, err := bm.client.ListServiceAccounts(ctx, namespace)
-	if err != nil {
-		return fmt.Errorf("error listing service accounts in namespace %s: %v", namespace, err)
-	}
+	return bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		serviceAccounts, err := bm.client.ListServiceAccounts(ctx, namespace, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing service accounts in namespace %s: %v", namespace, err)
+		}
 
-	for _, serviceAccount := range serviceAccounts.Items {
-		filename := filepath.Join(bm.backupDir, namespace, "serviceaccounts", serviceAccount.Name+".json")
-		if bm.dryRun {
-			bm.logger.Infof("Would backup service account: %s/%s", namespace, serviceAccount.Name)
-		} else {
-			if err := bm.saveResource(serviceAccount, "ServiceAccount", filename); err != nil {
-				return err
+		for _, serviceAccount := range serviceAccounts.Items {
+			filename := filepath.Join(bm.backupDir, namespace, "serviceaccounts", serviceAccount.Name+bm.fileExtension())
+			if bm.dryRun {
+				bm.logger.Infof("Would backup service account: %s/%s", namespace, serviceAccount.Name)
+			} else {
+				if err := bm.saveResource(&serviceAccount, corev1.SchemeGroupVersion.WithKind("ServiceAccount"), filename); err != nil {
+					return nil, err
+				}
 			}
 		}
-	}
-
-	return nil
+		return serviceAccounts, nil
+	})
 }
 
 // backupStatefulSets backs up all stateful sets in a given namespace
 func (bm *Manager) backupStatefulSets(ctx context.Context, namespace string) error {
-	statefulSets, err := bm.client.ListStatefulSets(ctx, namespace)
-	if err != nil {
-		return fmt.Errorf("error listing stateful sets in namespace %s: %v", namespace, err)
-	}
+	return bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		statefulSets, err := bm.client.ListStatefulSets(ctx, namespace, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing stateful sets in namespace %s: %v", namespace, err)
+		}
 
-	for _, statefulSet := range statefulSets.Items {
-		filename := filepath.Join(bm.backupDir, namespace, "statefulsets", statefulSet.Name+".json")
-		if bm.dryRun {
-			bm.logger.Infof("Would backup stateful set: %s/%s", namespace, statefulSet.Name)
-		} else {
-			if err := bm.saveResource(statefulSet, "StatefulSet", filename); err != nil {
-				return err
+		for _, statefulSet := range statefulSets.Items {
+			filename := filepath.Join(bm.backupDir, namespace, "statefulsets", statefulSet.Name+bm.fileExtension())
+			if bm.dryRun {
+				bm.logger.Infof("Would backup stateful set: %s/%s", namespace, statefulSet.Name)
+			} else {
+				if err := bm.saveResource(&statefulSet, appsv1.SchemeGroupVersion.WithKind("StatefulSet"), filename); err != nil {
+					return nil, err
+				}
 			}
 		}
-	}
-
-	return nil
+		return statefulSets, nil
+	})
 }
 
 // backupDaemonSets backs up all daemon sets in a given namespace
 func (bm *Manager) backupDaemonSets(ctx context.Context, namespace string) error {
-	daemonSets, err := bm.client.ListDaemonSets(ctx, namespace)
-	if err != nil {
-		return fmt.Errorf("error listing daemon sets in namespace %s: %v", namespace, err)
-	}
+	return bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		daemonSets, err := bm.client.ListDaemonSets(ctx, namespace, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing daemon sets in namespace %s: %v", namespace, err)
+		}
 
-	for _, daemonSet := range daemonSets.Items {
-		filename := filepath.Join(bm.backupDir, namespace, "daemonsets", daemonSet.Name+".json")
-		if bm.dryRun {
-			bm.logger.Infof("Would backup daemon set: %s/%s", namespace, daemonSet.Name)
-		} else {
-			if err := bm.saveResource(daemonSet, "DaemonSet", filename); err != nil {
-				return err
+		for _, daemonSet := range daemonSets.Items {
+			filename := filepath.Join(bm.backupDir, namespace, "daemonsets", daemonSet.Name+bm.fileExtension())
+			if bm.dryRun {
+				bm.logger.Infof("Would backup daemon set: %s/%s", namespace, daemonSet.Name)
+			} else {
+				if err := bm.saveResource(&daemonSet, appsv1.SchemeGroupVersion.WithKind("DaemonSet"), filename); err != nil {
+					return nil, err
+				}
 			}
 		}
-	}
-
-	return nil
+		return daemonSets, nil
+	})
 }
 
 // backupHorizontalPodAutoscalers backs up all horizontal pod autoscalers in a given namespace
 func (bm *Manager) backupHorizontalPodAutoscalers(ctx context.Context, namespace string) error {
-	hpas, err := bm.client.ListHorizontalPodAutoscalers(ctx, namespace)
-	if err != nil {
-		return fmt.Errorf("error listing HPAs in namespace %s: %v", namespace, err)
-	}
-
-	for _, hpa := range hpas.Items {
-		filename := filepath.Join(bm.backupDir, namespace, "hpas", hpa.Name+".json")
-		if bm.dryRun {
-			bm.logger.Infof("Would backup HPA: %s/%s", namespace, hpa.Name)
-		} else {
-			if err := bm.saveResource(hpa, "HorizontalPodAutoscaler", filename); err != nil {
+	return bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		hpas, err := bm.client.ListHorizontalPodAutoscalers(ctx, namespace, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing HPAs in namespace %s: %v", namespace, err)
+		}
 
-				return err
+		for _, hpa := range hpas.Items {
+			filename := filepath.Join(bm.backupDir, namespace, "hpas", hpa.Name+bm.fileExtension())
+			if bm.dryRun {
+				bm.logger.Infof("Would backup HPA: %s/%s", namespace, hpa.Name)
+			} else {
+				if err := bm.saveResource(&hpa, autoscalingv2.SchemeGroupVersion.WithKind("HorizontalPodAutoscaler"), filename); err != nil {
+					return nil, err
+				}
 			}
 		}
-	}
-
-	return nil
+		return hpas, nil
+	})
 }
 
 // backupCronJobs backs up all cron jobs in a given namespace
 func (bm *Manager) backupCronJobs(ctx context.Context, namespace string) error {
-	cronJobs, err := bm.client.ListCronJobs(ctx, namespace)
-	if err != nil {
-		return fmt.Errorf("error listing cron jobs in namespace %s: %v", namespace, err)
-	}
+	return bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		cronJobs, err := bm.client.ListCronJobs(ctx, namespace, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing cron jobs in namespace %s: %v", namespace, err)
+		}
 
-	for _, cronJob := range cronJobs.Items {
-		filename := filepath.Join(bm.backupDir, namespace, "cronjobs", cronJob.Name+".json")
-		if bm.dryRun {
-			bm.logger.Infof("Would backup cron job: %s/%s", namespace, cronJob.Name)
-		} else {
-			if err := bm.saveResource(cronJob, "CronJob", filename); err != nil {
-				return err
+		for _, cronJob := range cronJobs.Items {
+			filename := filepath.Join(bm.backupDir, namespace, "cronjobs", cronJob.Name+bm.fileExtension())
+			if bm.dryRun {
+				bm.logger.Infof("Would backup cron job: %s/%s", namespace, cronJob.Name)
+			} else {
+				if err := bm.saveResource(&cronJob, batchv1.SchemeGroupVersion.WithKind("CronJob"), filename); err != nil {
+					return nil, err
+				}
 			}
 		}
-	}
-
-	return nil
+		return cronJobs, nil
+	})
 }
 
 // backupPersistentVolumeClaims backs up all persistent volume claims in a given namespace
 func (bm *Manager) backupPersistentVolumeClaims(ctx context.Context, namespace string) error {
-	pvcs, err := bm.client.ListPersistentVolumeClaims(ctx, namespace)
-	if err != nil {
-		return fmt.Errorf("error listing persistant volume claims in namespace %s: %v", namespace, err)
-	}
-	for _, pvc := range pvcs.Items {
-		filename := filepath.Join(bm.backupDir, namespace, "pvcs", pvc.Name+".json")
-		if bm.dryRun {
-			bm.logger.Infof("Would backup pvc: %s/%s", namespace, pvc.Name)
-		} else {
-			if err := bm.saveResource(pvc, "PersistentVolumeClaim", filename); err != nil {
-				return err
+	return bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		pvcs, err := bm.client.ListPersistentVolumeClaims(ctx, namespace, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing persistant volume claims in namespace %s: %v", namespace, err)
+		}
+
+		for _, pvc := range pvcs.Items {
+			filename := filepath.Join(bm.backupDir, namespace, "pvcs", pvc.Name+bm.fileExtension())
+			if bm.dryRun {
+				bm.logger.Infof("Would backup pvc: %s/%s", namespace, pvc.Name)
+			} else {
+				if err := bm.saveResource(&pvc, corev1.SchemeGroupVersion.WithKind("PersistentVolumeClaim"), filename); err != nil {
+					return nil, err
+				}
 			}
 		}
-	}
-	return nil
+		return pvcs, nil
+	})
 }
 
 // backupJobs backs up all jobs in a given namespace
 func (bm *Manager) backupJobs(ctx context.Context, namespace string) error {
-	jobs, err := bm.client.ListJobs(ctx, namespace)
-	if err != nil {
-		return fmt.Errorf("error listing jobs in namespace %s: %v", namespace, err)
-	}
+	return bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		jobs, err := bm.client.ListJobs(ctx, namespace, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing jobs in namespace %s: %v", namespace, err)
+		}
 
-	for _, job := range jobs.Items {
-		filename := filepath.Join(bm.backupDir, namespace, "jobs", job.Name+".json")
-		if bm.dryRun {
-			bm.logger.Infof("Would backup job: %s/%s", namespace, job.Name)
-		} else {
-			if err := bm.saveResource(job, "Job", filename); err != nil {
-				return err
+		for _, job := range jobs.Items {
+			filename := filepath.Join(bm.backupDir, namespace, "jobs", job.Name+bm.fileExtension())
+			if bm.dryRun {
+				bm.logger.Infof("Would backup job: %s/%s", namespace, job.Name)
+			} else {
+				if err := bm.saveResource(&job, batchv1.SchemeGroupVersion.WithKind("Job"), filename); err != nil {
+					return nil, err
+				}
 			}
 		}
-	}
-	return nil
+		return jobs, nil
+	})
 }
 
 // backupIngresses backs up all ingresses in a given namespace
 func (bm *Manager) backupIngresses(ctx context.Context, namespace string) error {
-	ingresses, err := bm.client.ListIngresses(ctx, namespace)
-	if err != nil {
-		return fmt.Errorf("error listing ingresses in namespace %s: %v", namespace, err)
-	}
+	return bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		ingresses, err := bm.client.ListIngresses(ctx, namespace, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing ingresses in namespace %s: %v", namespace, err)
+		}
 
-	for _, ingress := range ingresses.Items {
-		filename := filepath.Join(bm.backupDir, namespace, "ingresses", ingress.Name+".json")
-		if bm.dryRun {
-			bm.logger.Infof("Would backup ingress: %s/%s", namespace, ingress.Name)
-		} else {
-			if err := bm.saveResource(ingress, "Ingress", filename); err != nil {
-				return err
+		for _, ingress := range ingresses.Items {
+			filename := filepath.Join(bm.backupDir, namespace, "ingresses", ingress.Name+bm.fileExtension())
+			if bm.dryRun {
+				bm.logger.Infof("Would backup ingress: %s/%s", namespace, ingress.Name)
+			} else {
+				if err := bm.saveResource(&ingress, networkingv1.SchemeGroupVersion.WithKind("Ingress"), filename); err != nil {
+					return nil, err
+				}
 			}
 		}
-	}
-
-	return nil
+		return ingresses, nil
+	})
 }
 
 // backupRoles backs up all roles in a given namespace
 func (bm *Manager) backupRoles(ctx context.Context, namespace string) error {
-	roles, err := bm.client.ListRoles(ctx, namespace)
-	if err != nil {
-		return fmt.Errorf("error listing roles in namespace %s: %v", namespace, err)
-	}
+	return bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		roles, err := bm.client.ListRoles(ctx, namespace, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing roles in namespace %s: %v", namespace, err)
+		}
 
-	for _, role := range roles.Items {
-		filename := filepath.Join(bm.backupDir, namespace, "roles", role.Name+".json")
-		if bm.dryRun {
-			bm.logger.Infof("Would backup role: %s/%s", namespace, role.Name)
-		} else {
-			if err := bm.saveResource(role, "Role", filename); err != nil {
-				return err
+		for _, role := range roles.Items {
+			filename := filepath.Join(bm.backupDir, namespace, "roles", role.Name+bm.fileExtension())
+			if bm.dryRun {
+				bm.logger.Infof("Would backup role: %s/%s", namespace, role.Name)
+			} else {
+				if err := bm.saveResource(&role, rbacv1.SchemeGroupVersion.WithKind("Role"), filename); err != nil {
+					return nil, err
+				}
 			}
 		}
-	}
+		return roles, nil
+	})
+}
 
-	return nil
+// backupRoleBindings backs up all role bindings in a given namespace
+func (bm *Manager) backupRoleBindings(ctx context.Context, namespace string) error {
+	return bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		roleBindings, err := bm.client.ListRoleBindings(ctx, namespace, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing role bindings in namespace %s: %v", namespace, err)
+		}
+
+		for _, roleBinding := range roleBindings.Items {
+			filename := filepath.Join(bm.backupDir, namespace, "rolebindings", roleBinding.Name+bm.fileExtension())
+			if bm.dryRun {
+				bm.logger.Infof("Would backup role binding: %s/%s", namespace, roleBinding.Name)
+			} else {
+				if err := bm.saveResource(&roleBinding, rbacv1.SchemeGroupVersion.WithKind("RoleBinding"), filename); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return roleBindings, nil
+	})
 }
 
 // backupNetworkPolicies backs up all network policies in a given namespace
 func (bm *Manager) backupNetworkPolicies(ctx context.Context, namespace string) error {
-	networkPolicies, err := bm.client.ListNetworkPolicies(ctx, namespace)
-	if err != nil {
-		return fmt.Errorf("error listing network policies in namespace %s: %v", namespace, err)
-	}
+	return bm.forEachPage(func(opts metav1.ListOptions) (metav1.ListInterface, error) {
+		networkPolicies, err := bm.client.ListNetworkPolicies(ctx, namespace, opts)
+		if err != nil {
+			return nil, fmt.Errorf("error listing network policies in namespace %s: %v", namespace, err)
+		}
 
-	for _, networkPolicy := range networkPolicies.Items {
-		filename := filepath.Join(bm.backupDir, namespace, "networkpolicies", networkPolicy.Name+".json")
-		if bm.dryRun {
-			bm.logger.Infof("Would backup network policy: %s/%s", namespace, networkPolicy.Name)
-		} else {
-			if err := bm.saveResource(networkPolicy, "NetworkPolicy", filename); err != nil {
-				return err
+		for _, networkPolicy := range networkPolicies.Items {
+			filename := filepath.Join(bm.backupDir, namespace, "networkpolicies", networkPolicy.Name+bm.fileExtension())
+			if bm.dryRun {
+				bm.logger.Infof("Would backup network policy: %s/%s", namespace, networkPolicy.Name)
+			} else {
+				if err := bm.saveResource(&networkPolicy, networkingv1.SchemeGroupVersion.WithKind("NetworkPolicy"), filename); err != nil {
+					return nil, err
+				}
 			}
 		}
-	}
-
-	return nil
+		return networkPolicies, nil
+	})
 }
 
 // backupNamespaces backs up all namespaces in the cluster
@@ -344,12 +392,15 @@ func (bm *Manager) backupNamespaces(ctx context.Context) error {
 	}
 
 	for _, namespace := range namespaces.Items {
+		if !bm.namespaces.Matches(namespace.Name) {
+			continue
+		}
 		// Namespaces are cluster-scoped, so we store them in a special directory
-		filename := filepath.Join(bm.backupDir, "namespaces", namespace.Name+".json")
+		filename := filepath.Join(bm.backupDir, "namespaces", namespace.Name+bm.fileExtension())
 		if bm.dryRun {
 			bm.logger.Infof("Would backup namespace: %s", namespace.Name)
 		} else {
-			if err := bm.saveResource(namespace, "Namespace", filename); err != nil {
+			if err := bm.saveResource(&namespace, corev1.SchemeGroupVersion.WithKind("Namespace"), filename); err != nil {
 				return err
 			}
 		}
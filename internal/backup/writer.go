@@ -0,0 +1,145 @@
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/chaoscypher/kube-save-restore/internal/storage"
+	"github.com/klauspost/compress/zstd"
+)
+
+// Archive formats supported for backups
+const (
+	ArchiveFormatTarGz  = "tar.gz"
+	ArchiveFormatTarZst = "tar.zst"
+)
+
+// fileWriter stores the files of a backup. Implementations must be safe for concurrent use.
+// Close completes a successful backup and Abort discards the output of a failed one where possible.
+type fileWriter interface {
+	WriteFile(filename string, data []byte) error
+	Close() error
+	Abort()
+}
+
+// storageWriter writes backup files to a storage, one file per resource
+type storageWriter struct {
+	ctx     context.Context
+	storage storage.Storage
+}
+
+// WriteFile stores data under filename
+func (w storageWriter) WriteFile(filename string, data []byte) error {
+	return w.storage.WriteFile(w.ctx, filename, data)
+}
+
+// Close is a no-op, as every file is complete once written
+func (storageWriter) Close() error {
+	return nil
+}
+
+// Abort leaves the files written so far in place, so that a failed backup can be inspected
+func (storageWriter) Abort() {}
+
+// archiveWriter streams backup files into a single compressed tar archive.
+// The archive only appears in the storage when the writer is closed, so an interrupted backup never
+// leaves a truncated archive behind under the final name.
+type archiveWriter struct {
+	mu         sync.Mutex
+	root       string
+	out        storage.Writer
+	compressor io.WriteCloser
+	tar        *tar.Writer
+}
+
+// archivePath returns the path of the archive holding a backup of root in the given format
+func archivePath(root, format string) string {
+	return filepath.Clean(root) + "." + format
+}
+
+// newArchiveWriter creates an archive in the given format for the backup directory root in s.
+// Files are stored in the archive relative to root.
+func newArchiveWriter(ctx context.Context, s storage.Storage, root, format string) (*archiveWriter, error) {
+	out, err := s.Create(ctx, archivePath(root, format))
+	if err != nil {
+		return nil, fmt.Errorf("error creating archive: %v", err)
+	}
+
+	var compressor io.WriteCloser
+	switch format {
+	case ArchiveFormatTarGz:
+		compressor = gzip.NewWriter(out)
+	case ArchiveFormatTarZst:
+		compressor, err = zstd.NewWriter(out)
+	default:
+		err = fmt.Errorf("unknown archive format: %s", format)
+	}
+	if err != nil {
+		out.Abort()
+		return nil, err
+	}
+
+	return &archiveWriter{
+		root:       root,
+		out:        out,
+		compressor: compressor,
+		tar:        tar.NewWriter(compressor),
+	}, nil
+}
+
+// WriteFile adds data to the archive under the path of filename relative to the backup directory
+func (w *archiveWriter) WriteFile(filename string, data []byte) error {
+	name, err := filepath.Rel(w.root, filename)
+	if err != nil {
+		return fmt.Errorf("error resolving archive entry for %s: %v", filename, err)
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	header := &tar.Header{
+		Name:    filepath.ToSlash(name),
+		Mode:    0600,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}
+	if err := w.tar.WriteHeader(header); err != nil {
+		return fmt.Errorf("error writing archive entry %s: %v", header.Name, err)
+	}
+	if _, err := w.tar.Write(data); err != nil {
+		return fmt.Errorf("error writing archive entry %s: %v", header.Name, err)
+	}
+	return nil
+}
+
+// Close flushes the archive and moves it to its final location
+func (w *archiveWriter) Close() error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if err := w.tar.Close(); err != nil {
+		w.out.Abort()
+		return fmt.Errorf("error finishing archive: %v", err)
+	}
+	if err := w.compressor.Close(); err != nil {
+		w.out.Abort()
+		return fmt.Errorf("error finishing archive: %v", err)
+	}
+	if err := w.out.Close(); err != nil {
+		return fmt.Errorf("error finishing archive: %v", err)
+	}
+	return nil
+}
+
+// Abort discards the unfinished archive
+func (w *archiveWriter) Abort() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.out.Abort()
+}
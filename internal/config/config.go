@@ -5,6 +5,16 @@ import (
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
+	"time"
+
+	"github.com/chaoscypher/kube-save-restore/internal/filter"
+	"github.com/chaoscypher/kube-save-restore/internal/prune"
+	"github.com/chaoscypher/kube-save-restore/internal/redact"
+	"github.com/chaoscypher/kube-save-restore/internal/sanitize"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/validation"
 )
 
 // Config holds the configuration for the application.
@@ -17,6 +27,51 @@ type Config struct {
 	DryRun     bool
 	LogLevel   string
 	LogFile    string
+	Discovery  bool
+
+	IncludeNamespaces       []string
+	ExcludeNamespaces       []string
+	IncludeClusterResources bool
+	IncludeResources        []string
+	ExcludeResources        []string
+
+	Selector      string
+	FieldSelector string
+
+	PageSize     int64
+	OutputFormat string
+	Archive      string
+
+	SigningKey string
+	VerifyKey  string
+
+	EncryptionPassphraseFile string
+
+	RedactSecrets  bool
+	RedactPatterns []string
+
+	Storage    string
+	S3Bucket   string
+	S3Prefix   string
+	S3Endpoint string
+	S3Region   string
+	S3Insecure bool
+
+	KeepLast   int
+	KeepDaily  int
+	KeepWeekly int
+	KeepWithin string
+
+	ExistingResourcePolicy string
+	ServerSide             bool
+	ForceConflicts         bool
+	PreserveFields         []string
+	NamespaceMapping       map[string]string
+	RestoreOrder           string
+	Wait                   bool
+	WaitTimeout            time.Duration
+	Atomic                 bool
+	MaxErrors              int
 }
 
 // ParseFlags parses command-line flags and environment variables into a Config struct.
@@ -26,11 +81,59 @@ func ParseFlags() *Config {
 	flag.StringVar(&config.Context, "context", getEnv("KUBE_CONTEXT", ""), "Kubernetes context to use")
 	flag.StringVar(&config.BackupDir, "backup-dir", getEnv("BACKUP_DIR", ""), "Directory to store backups")
 	flag.StringVar(&config.RestoreDir, "restore-dir", getEnv("RESTORE_DIR", ""), "Directory to restore from")
-	flag.StringVar(&config.Mode, "mode", getEnv("MODE", "backup"), "Mode: 'backup' or 'restore'")
+	flag.StringVar(&config.Mode, "mode", getEnv("MODE", "backup"), "Mode: 'backup', 'restore', 'verify' or 'prune'")
 	flag.BoolVar(&config.DryRun, "dry-run", getEnvAsBool("DRY_RUN", false), "Perform a dry run without making any changes")
 	flag.StringVar(&config.LogLevel, "log-level", getEnv("LOG_LEVEL", "info"), "Log level: debug, info, warn, error")
 	flag.StringVar(&config.LogFile, "log-file", getEnv("LOG_FILE", ""), "Path to log file (if not set, logs to stdout)")
+	flag.BoolVar(&config.Discovery, "discovery", getEnvAsBool("DISCOVERY", false), "Back up every namespaced resource served by the cluster, including custom resources")
+	includeNamespaces := flag.String("include-namespaces", getEnv("INCLUDE_NAMESPACES", ""), "Comma-separated glob patterns of namespaces to include (default is all namespaces)")
+	excludeNamespaces := flag.String("exclude-namespaces", getEnv("EXCLUDE_NAMESPACES", ""), "Comma-separated glob patterns of namespaces to exclude")
+	flag.BoolVar(&config.IncludeClusterResources, "include-cluster-resources", getEnvAsBool("INCLUDE_CLUSTER_RESOURCES", false), "Restore cluster-scoped resources, such as ClusterRoles and CRDs, even when a namespace filter is set")
+	includeResources := flag.String("include-resources", getEnv("INCLUDE_RESOURCES", ""), "Comma-separated glob patterns of resource types to include, e.g. 'deployments,configmaps' (default is all resource types)")
+	excludeResources := flag.String("exclude-resources", getEnv("EXCLUDE_RESOURCES", ""), "Comma-separated glob patterns of resource types to exclude, e.g. 'secrets,jobs'")
+	flag.StringVar(&config.Selector, "selector", getEnv("SELECTOR", ""), "Label selector to filter resources by, e.g. 'app.kubernetes.io/part-of=shop'")
+	flag.StringVar(&config.FieldSelector, "field-selector", getEnv("FIELD_SELECTOR", ""), "Field selector to filter resources by when backing up, e.g. 'metadata.name=web'")
+	flag.Int64Var(&config.PageSize, "page-size", getEnvAsInt64("PAGE_SIZE", 500), "Number of objects to request per list call when backing up (0 disables pagination)")
+	flag.StringVar(&config.OutputFormat, "output-format", getEnv("OUTPUT_FORMAT", "json"), "Format of backup files: 'json' or 'yaml'")
+	flag.StringVar(&config.Archive, "archive", getEnv("ARCHIVE", ""), "Write the backup to a single compressed archive: 'tar.gz' or 'tar.zst' (default is a directory)")
+	flag.StringVar(&config.SigningKey, "signing-key", getEnv("SIGNING_KEY", ""), "Path to a PEM-encoded ed25519 private key to sign the backup manifest with")
+	flag.StringVar(&config.VerifyKey, "verify-key", getEnv("VERIFY_KEY", ""), "Path to a PEM-encoded ed25519 public key; restore and verify refuse backups not signed with its private key")
+	flag.StringVar(&config.EncryptionPassphraseFile, "encryption-passphrase-file", getEnv("ENCRYPTION_PASSPHRASE_FILE", ""), "Path to a file holding the passphrase Secret data is encrypted with on backup and decrypted with on restore")
+	flag.BoolVar(&config.RedactSecrets, "redact-secrets", getEnvAsBool("REDACT_SECRETS", false), "Replace Secret values, and ConfigMap keys and container environment variables matching --redact-patterns, with placeholders")
+	redactPatterns := flag.String("redact-patterns", getEnv("REDACT_PATTERNS", strings.Join(redact.DefaultPatterns, ",")), "Comma-separated glob patterns of ConfigMap keys and environment variable names to redact, matched case-insensitively")
+	flag.StringVar(&config.Storage, "storage", getEnv("STORAGE", "local"), "Where backups are stored: 'local' or 's3'")
+	flag.StringVar(&config.S3Bucket, "s3-bucket", getEnv("S3_BUCKET", ""), "S3 bucket to store backups in")
+	flag.StringVar(&config.S3Prefix, "s3-prefix", getEnv("S3_PREFIX", ""), "Prefix of the S3 object keys of backups")
+	flag.StringVar(&config.S3Endpoint, "s3-endpoint", getEnv("S3_ENDPOINT", ""), "Host and port of an S3-compatible service (default is Amazon S3)")
+	flag.StringVar(&config.S3Region, "s3-region", getEnv("S3_REGION", ""), "Region of the S3 bucket (default is looked up from the service)")
+	flag.BoolVar(&config.S3Insecure, "s3-insecure", getEnvAsBool("S3_INSECURE", false), "Connect to the S3 endpoint over plain HTTP")
+	flag.IntVar(&config.KeepLast, "keep-last", int(getEnvAsInt64("KEEP_LAST", 0)), "Prune: keep the most recent n backups")
+	flag.IntVar(&config.KeepDaily, "keep-daily", int(getEnvAsInt64("KEEP_DAILY", 0)), "Prune: keep the most recent backup of each of the last n days with backups")
+	flag.IntVar(&config.KeepWeekly, "keep-weekly", int(getEnvAsInt64("KEEP_WEEKLY", 0)), "Prune: keep the most recent backup of each of the last n weeks with backups")
+	flag.StringVar(&config.KeepWithin, "keep-within", getEnv("KEEP_WITHIN", ""), "Prune: keep all backups taken within this duration, e.g. '72h', '30d' or '4w'")
+	flag.StringVar(&config.ExistingResourcePolicy, "existing-resource-policy", getEnv("EXISTING_RESOURCE_POLICY", "update"), "How restore handles resources that already exist: 'none' leaves them untouched, 'update' replaces them, 'fail' aborts the restore and 'recreate' deletes and creates them again")
+	flag.BoolVar(&config.ServerSide, "server-side", getEnvAsBool("SERVER_SIDE", false), "Restore resources with server-side apply as the 'kube-save-restore' field manager instead of updating or creating them")
+	flag.BoolVar(&config.ForceConflicts, "force-conflicts", getEnvAsBool("FORCE_CONFLICTS", false), "With --server-side, take over fields managed by others instead of failing with a conflict")
+	preserveFields := flag.String("preserve-fields", getEnv("PRESERVE_FIELDS", ""), "Comma-separated server-populated fields to keep on restore instead of removing them: "+strings.Join(sanitize.Fields(), ", "))
+	namespaceMapping := flag.String("namespace-mapping", getEnv("NAMESPACE_MAPPING", ""), "Comma-separated source:destination pairs of namespaces to restore resources into, e.g. 'prod:prod-copy'")
+	flag.StringVar(&config.RestoreOrder, "restore-order", getEnv("RESTORE_ORDER", ""), "Waves of kinds to restore one after the other, separated by ';', with kinds separated by ',' and '*' for all other kinds, e.g. 'Namespace;ConfigMap,Secret;*' (default is a dependency order)")
+	flag.BoolVar(&config.Wait, "wait", getEnvAsBool("WAIT", false), "After restoring, wait for Deployments, StatefulSets and DaemonSets to roll out, PersistentVolumeClaims to be bound and Jobs to complete, and report the ones that do not")
+	flag.DurationVar(&config.WaitTimeout, "wait-timeout", getEnvAsDuration("WAIT_TIMEOUT", 5*time.Minute), "How long --wait waits for restored resources to become ready")
+	flag.BoolVar(&config.Atomic, "atomic", getEnvAsBool("ATOMIC", false), "Roll back the restore if any resource fails to restore or, with --wait, does not become ready: delete the resources it created and put back the previous version of the ones it changed")
+	flag.IntVar(&config.MaxErrors, "max-errors", int(getEnvAsInt64("MAX_ERRORS", 0)), "Stop the restore once this many resources have failed to restore (0 attempts every resource)")
 	flag.Parse()
+	config.IncludeNamespaces = splitList(*includeNamespaces)
+	config.ExcludeNamespaces = splitList(*excludeNamespaces)
+	config.IncludeResources = splitList(*includeResources)
+	config.ExcludeResources = splitList(*excludeResources)
+	config.RedactPatterns = splitList(strings.ToLower(*redactPatterns))
+	config.PreserveFields = splitList(*preserveFields)
+	mapping, err := parseNamespaceMapping(*namespaceMapping)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	config.NamespaceMapping = mapping
 	if err := validateConfig(config); err != nil {
 		fmt.Println(err)
 		os.Exit(1)
@@ -40,13 +143,79 @@ func ParseFlags() *Config {
 
 // validateConfig validates the configuration values.
 func validateConfig(config *Config) error {
-	validModes := map[string]bool{"backup": true, "restore": true}
+	validModes := map[string]bool{"backup": true, "restore": true, "verify": true, "prune": true}
 	if !validModes[config.Mode] {
-		return fmt.Errorf("invalid mode: %s. Use 'backup' or 'restore'", config.Mode)
+		return fmt.Errorf("invalid mode: %s. Use 'backup', 'restore', 'verify' or 'prune'", config.Mode)
 	}
 	if config.Mode == "restore" && config.RestoreDir == "" {
 		return fmt.Errorf("--restore-dir flag is required for restore mode")
 	}
+	if config.Mode == "verify" && config.BackupDir == "" {
+		return fmt.Errorf("--backup-dir flag is required for verify mode")
+	}
+	if _, err := filter.New(config.IncludeNamespaces, config.ExcludeNamespaces); err != nil {
+		return fmt.Errorf("invalid namespace filter: %v", err)
+	}
+	if _, err := filter.New(config.IncludeResources, config.ExcludeResources); err != nil {
+		return fmt.Errorf("invalid resource filter: %v", err)
+	}
+	if _, err := labels.Parse(config.Selector); err != nil {
+		return fmt.Errorf("invalid label selector: %v", err)
+	}
+	if _, err := fields.ParseSelector(config.FieldSelector); err != nil {
+		return fmt.Errorf("invalid field selector: %v", err)
+	}
+	validOutputFormats := map[string]bool{"json": true, "yaml": true}
+	if !validOutputFormats[config.OutputFormat] {
+		return fmt.Errorf("invalid output format: %s. Use 'json' or 'yaml'", config.OutputFormat)
+	}
+	validArchives := map[string]bool{"": true, "tar.gz": true, "tar.zst": true}
+	if !validArchives[config.Archive] {
+		return fmt.Errorf("invalid archive format: %s. Use 'tar.gz' or 'tar.zst'", config.Archive)
+	}
+	validStorages := map[string]bool{"local": true, "s3": true}
+	if !validStorages[config.Storage] {
+		return fmt.Errorf("invalid storage: %s. Use 'local' or 's3'", config.Storage)
+	}
+	if config.Storage == "s3" && config.S3Bucket == "" {
+		return fmt.Errorf("--s3-bucket flag is required for s3 storage")
+	}
+	validExistingResourcePolicies := map[string]bool{"": true, "none": true, "update": true, "fail": true, "recreate": true}
+	if !validExistingResourcePolicies[config.ExistingResourcePolicy] {
+		return fmt.Errorf("invalid existing resource policy: %s. Use 'none', 'update', 'fail' or 'recreate'", config.ExistingResourcePolicy)
+	}
+	if config.ForceConflicts && !config.ServerSide {
+		return fmt.Errorf("--force-conflicts flag requires --server-side")
+	}
+	if _, err := sanitize.New(config.PreserveFields); err != nil {
+		return fmt.Errorf("invalid preserved fields: %v", err)
+	}
+	if config.Wait && config.WaitTimeout <= 0 {
+		return fmt.Errorf("invalid wait timeout: %s. Must be positive", config.WaitTimeout)
+	}
+	if err := validateNamespaceMapping(config.NamespaceMapping); err != nil {
+		return fmt.Errorf("invalid namespace mapping: %v", err)
+	}
+	if config.KeepLast < 0 || config.KeepDaily < 0 || config.KeepWeekly < 0 {
+		return fmt.Errorf("invalid retention policy: --keep-last, --keep-daily and --keep-weekly must not be negative")
+	}
+	if config.KeepWithin != "" {
+		if _, err := prune.ParseDuration(config.KeepWithin); err != nil {
+			return fmt.Errorf("invalid --keep-within: %v", err)
+		}
+	}
+	if config.Mode == "prune" && config.KeepLast == 0 && config.KeepDaily == 0 && config.KeepWeekly == 0 && config.KeepWithin == "" {
+		return fmt.Errorf("prune mode requires at least one of --keep-last, --keep-daily, --keep-weekly or --keep-within")
+	}
+	if _, err := redact.New(config.RedactPatterns); err != nil {
+		return fmt.Errorf("invalid redact patterns: %v", err)
+	}
+	if config.MaxErrors < 0 {
+		return fmt.Errorf("invalid max errors: %d. Must not be negative", config.MaxErrors)
+	}
+	if config.PageSize < 0 {
+		return fmt.Errorf("invalid page size: %d. Must not be negative", config.PageSize)
+	}
 	return nil
 }
 
@@ -68,3 +237,72 @@ func getEnvAsBool(name string, defaultVal bool) bool {
 	}
 	return defaultVal
 }
+
+// getEnvAsInt64 retrieves the value of the environment variable named by the key and parses it as an integer.
+// It returns the integer value, or the specified default value if the variable is not present or cannot be parsed.
+func getEnvAsInt64(name string, defaultVal int64) int64 {
+	valStr := getEnv(name, "")
+	if val, err := strconv.ParseInt(valStr, 10, 64); err == nil {
+		return val
+	}
+	return defaultVal
+}
+
+// getEnvAsDuration retrieves the value of the environment variable named by the key and parses it as a duration.
+// It returns the duration, or the specified default value if the variable is not present or cannot be parsed.
+func getEnvAsDuration(name string, defaultVal time.Duration) time.Duration {
+	valStr := getEnv(name, "")
+	if val, err := time.ParseDuration(valStr); err == nil {
+		return val
+	}
+	return defaultVal
+}
+
+// parseNamespaceMapping parses a comma-separated list of source:destination namespace pairs.
+// It returns nil if the list is empty.
+func parseNamespaceMapping(value string) (map[string]string, error) {
+	pairs := splitList(value)
+	if len(pairs) == 0 {
+		return nil, nil
+	}
+	mapping := make(map[string]string, len(pairs))
+	for _, pair := range pairs {
+		source, destination, ok := strings.Cut(pair, ":")
+		source, destination = strings.TrimSpace(source), strings.TrimSpace(destination)
+		if !ok || source == "" || destination == "" {
+			return nil, fmt.Errorf("invalid namespace mapping %q. Use 'source:destination'", pair)
+		}
+		if _, exists := mapping[source]; exists {
+			return nil, fmt.Errorf("invalid namespace mapping: namespace %s is mapped more than once", source)
+		}
+		mapping[source] = destination
+	}
+	return mapping, nil
+}
+
+// validateNamespaceMapping checks that the destinations of a namespace mapping are valid namespace names
+// and that no two namespaces are restored into the same one.
+func validateNamespaceMapping(mapping map[string]string) error {
+	sources := make(map[string]string, len(mapping))
+	for source, destination := range mapping {
+		if errs := validation.IsDNS1123Label(destination); len(errs) > 0 {
+			return fmt.Errorf("%s is not a valid namespace name: %s", destination, strings.Join(errs, ", "))
+		}
+		if other, exists := sources[destination]; exists {
+			return fmt.Errorf("namespaces %s and %s are both mapped to %s", other, source, destination)
+		}
+		sources[destination] = source
+	}
+	return nil
+}
+
+// splitList splits a comma-separated list into its trimmed, non-empty elements.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
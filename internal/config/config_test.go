@@ -3,7 +3,9 @@ package config
 import (
 	"flag"
 	"os"
+	"reflect"
 	"testing"
+	"time"
 )
 
 func TestParseFlags(t *testing.T) {
@@ -32,7 +34,9 @@ func TestParseFlags(t *testing.T) {
 				"LOG_FILE":     "",
 			},
 			expectFunc: func(config *Config) bool {
-				return config.Mode == "backup" && !config.DryRun && config.LogLevel == "info"
+				return config.Mode == "backup" && !config.DryRun && config.LogLevel == "info" && config.PageSize == 500 && config.OutputFormat == "json" &&
+					!config.RedactSecrets && len(config.RedactPatterns) > 0 && config.ExistingResourcePolicy == "update" &&
+					!config.Wait && config.WaitTimeout == 5*time.Minute && !config.Atomic
 			},
 		},
 		{
@@ -46,6 +50,29 @@ func TestParseFlags(t *testing.T) {
 				"--dry-run=true",
 				"--log-level=debug",
 				"--log-file=/path/to/logfile",
+				"--include-namespaces=tenant-*, team-a",
+				"--exclude-namespaces=kube-*",
+				"--exclude-resources=secrets,jobs",
+				"--selector=app.kubernetes.io/part-of=shop",
+				"--field-selector=metadata.name=web",
+				"--page-size=100",
+				"--output-format=yaml",
+				"--archive=tar.zst",
+				"--redact-secrets",
+				"--redact-patterns=*PASSWORD*,*dsn*",
+				"--storage=s3",
+				"--s3-bucket=backups",
+				"--s3-prefix=clusters/prod",
+				"--existing-resource-policy=none",
+				"--server-side",
+				"--force-conflicts",
+				"--preserve-fields=status,clusterIP",
+				"--namespace-mapping=prod:prod-copy-123, shop:shop-staging",
+				"--restore-order=Namespace;ConfigMap,Secret;*",
+				"--wait",
+				"--wait-timeout=90s",
+				"--atomic",
+				"--max-errors=5",
 			},
 			envVars: map[string]string{},
 			expectFunc: func(config *Config) bool {
@@ -56,7 +83,30 @@ func TestParseFlags(t *testing.T) {
 					config.Mode == "restore" &&
 					config.DryRun &&
 					config.LogLevel == "debug" &&
-					config.LogFile == "/path/to/logfile"
+					config.LogFile == "/path/to/logfile" &&
+					reflect.DeepEqual(config.IncludeNamespaces, []string{"tenant-*", "team-a"}) &&
+					reflect.DeepEqual(config.ExcludeNamespaces, []string{"kube-*"}) &&
+					reflect.DeepEqual(config.ExcludeResources, []string{"secrets", "jobs"}) &&
+					config.Selector == "app.kubernetes.io/part-of=shop" &&
+					config.FieldSelector == "metadata.name=web" &&
+					config.PageSize == 100 &&
+					config.OutputFormat == "yaml" &&
+					config.Archive == "tar.zst" &&
+					config.RedactSecrets &&
+					reflect.DeepEqual(config.RedactPatterns, []string{"*password*", "*dsn*"}) &&
+					config.Storage == "s3" &&
+					config.S3Bucket == "backups" &&
+					config.S3Prefix == "clusters/prod" &&
+					config.ExistingResourcePolicy == "none" &&
+					config.ServerSide &&
+					config.ForceConflicts &&
+					reflect.DeepEqual(config.PreserveFields, []string{"status", "clusterIP"}) &&
+					reflect.DeepEqual(config.NamespaceMapping, map[string]string{"prod": "prod-copy-123", "shop": "shop-staging"}) &&
+					config.RestoreOrder == "Namespace;ConfigMap,Secret;*" &&
+					config.Wait &&
+					config.WaitTimeout == 90*time.Second &&
+					config.Atomic &&
+					config.MaxErrors == 5
 			},
 		},
 	}
@@ -97,18 +147,41 @@ func TestValidateConfig(t *testing.T) {
 		{
 			name: "Valid backup mode",
 			config: &Config{
-				Mode: "backup",
+				Mode:         "backup",
+				OutputFormat: "json",
+				Storage:      "local",
 			},
 			expectErr: false,
 		},
 		{
 			name: "Valid restore mode with restore-dir",
 			config: &Config{
-				Mode:       "restore",
-				RestoreDir: "/path/to/restore",
+				Mode:         "restore",
+				RestoreDir:   "/path/to/restore",
+				OutputFormat: "json",
+				Storage:      "local",
 			},
 			expectErr: false,
 		},
+		{
+			name: "Valid verify mode with backup-dir",
+			config: &Config{
+				Mode:         "verify",
+				BackupDir:    "/path/to/backup",
+				OutputFormat: "json",
+				Storage:      "local",
+			},
+			expectErr: false,
+		},
+		{
+			name: "Verify mode without backup-dir",
+			config: &Config{
+				Mode:         "verify",
+				OutputFormat: "json",
+				Storage:      "local",
+			},
+			expectErr: true,
+		},
 		{
 			name: "Invalid mode",
 			config: &Config{
@@ -123,6 +196,253 @@ func TestValidateConfig(t *testing.T) {
 			},
 			expectErr: true,
 		},
+		{
+			name: "Invalid namespace pattern",
+			config: &Config{
+				Mode:              "backup",
+				IncludeNamespaces: []string{"tenant-["},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Invalid resource pattern",
+			config: &Config{
+				Mode:             "backup",
+				ExcludeResources: []string{"secret["},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Valid selectors",
+			config: &Config{
+				Mode:          "backup",
+				Selector:      "app.kubernetes.io/part-of in (shop,blog),tier!=cache",
+				FieldSelector: "metadata.name=web",
+				OutputFormat:  "yaml",
+				Storage:       "local",
+			},
+			expectErr: false,
+		},
+		{
+			name: "Invalid label selector",
+			config: &Config{
+				Mode:     "backup",
+				Selector: "app in (shop",
+			},
+			expectErr: true,
+		},
+		{
+			name: "Invalid field selector",
+			config: &Config{
+				Mode:          "backup",
+				FieldSelector: "metadata.name",
+			},
+			expectErr: true,
+		},
+		{
+			name: "Invalid output format",
+			config: &Config{
+				Mode:         "backup",
+				OutputFormat: "xml",
+			},
+			expectErr: true,
+		},
+		{
+			name: "Valid archive format",
+			config: &Config{
+				Mode:         "backup",
+				OutputFormat: "json",
+				Storage:      "local",
+				Archive:      "tar.gz",
+			},
+			expectErr: false,
+		},
+		{
+			name: "Invalid archive format",
+			config: &Config{
+				Mode:         "backup",
+				OutputFormat: "json",
+				Storage:      "local",
+				Archive:      "zip",
+			},
+			expectErr: true,
+		},
+		{
+			name: "S3 storage with bucket",
+			config: &Config{
+				Mode:         "backup",
+				OutputFormat: "json",
+				Storage:      "s3",
+				S3Bucket:     "backups",
+			},
+			expectErr: false,
+		},
+		{
+			name: "S3 storage without bucket",
+			config: &Config{
+				Mode:         "backup",
+				OutputFormat: "json",
+				Storage:      "s3",
+			},
+			expectErr: true,
+		},
+		{
+			name: "Invalid storage",
+			config: &Config{
+				Mode:         "backup",
+				OutputFormat: "json",
+				Storage:      "gcs",
+			},
+			expectErr: true,
+		},
+		{
+			name: "Valid existing resource policy",
+			config: &Config{
+				Mode:                   "restore",
+				RestoreDir:             "/path/to/restore",
+				OutputFormat:           "json",
+				Storage:                "local",
+				ExistingResourcePolicy: "recreate",
+			},
+			expectErr: false,
+		},
+		{
+			name: "Invalid existing resource policy",
+			config: &Config{
+				Mode:                   "restore",
+				RestoreDir:             "/path/to/restore",
+				OutputFormat:           "json",
+				Storage:                "local",
+				ExistingResourcePolicy: "skip",
+			},
+			expectErr: true,
+		},
+		{
+			name: "Invalid namespace mapping destination",
+			config: &Config{
+				Mode:             "restore",
+				RestoreDir:       "/path/to/restore",
+				OutputFormat:     "json",
+				Storage:          "local",
+				NamespaceMapping: map[string]string{"prod": "Prod_Copy"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Namespaces mapped to the same destination",
+			config: &Config{
+				Mode:             "restore",
+				RestoreDir:       "/path/to/restore",
+				OutputFormat:     "json",
+				Storage:          "local",
+				NamespaceMapping: map[string]string{"prod": "copy", "staging": "copy"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Unknown preserved field",
+			config: &Config{
+				Mode:           "restore",
+				RestoreDir:     "/path/to/restore",
+				OutputFormat:   "json",
+				Storage:        "local",
+				PreserveFields: []string{"spec"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Negative max errors",
+			config: &Config{
+				Mode:         "restore",
+				RestoreDir:   "/path/to/restore",
+				OutputFormat: "json",
+				Storage:      "local",
+				MaxErrors:    -1,
+			},
+			expectErr: true,
+		},
+		{
+			name: "Wait without timeout",
+			config: &Config{
+				Mode:         "restore",
+				RestoreDir:   "/path/to/restore",
+				OutputFormat: "json",
+				Storage:      "local",
+				Wait:         true,
+			},
+			expectErr: true,
+		},
+		{
+			name: "Force conflicts without server-side apply",
+			config: &Config{
+				Mode:           "restore",
+				RestoreDir:     "/path/to/restore",
+				OutputFormat:   "json",
+				Storage:        "local",
+				ForceConflicts: true,
+			},
+			expectErr: true,
+		},
+		{
+			name: "Valid prune mode",
+			config: &Config{
+				Mode:         "prune",
+				OutputFormat: "json",
+				Storage:      "local",
+				KeepDaily:    7,
+				KeepWithin:   "30d",
+			},
+			expectErr: false,
+		},
+		{
+			name: "Prune mode without retention policy",
+			config: &Config{
+				Mode:         "prune",
+				OutputFormat: "json",
+				Storage:      "local",
+			},
+			expectErr: true,
+		},
+		{
+			name: "Invalid keep-within",
+			config: &Config{
+				Mode:         "prune",
+				OutputFormat: "json",
+				Storage:      "local",
+				KeepWithin:   "a while",
+			},
+			expectErr: true,
+		},
+		{
+			name: "Negative keep-last",
+			config: &Config{
+				Mode:         "prune",
+				OutputFormat: "json",
+				Storage:      "local",
+				KeepLast:     -1,
+			},
+			expectErr: true,
+		},
+		{
+			name: "Invalid redact pattern",
+			config: &Config{
+				Mode:           "backup",
+				OutputFormat:   "json",
+				Storage:        "local",
+				RedactPatterns: []string{"[password"},
+			},
+			expectErr: true,
+		},
+		{
+			name: "Negative page size",
+			config: &Config{
+				Mode:         "backup",
+				OutputFormat: "json",
+				Storage:      "local",
+				PageSize:     -1,
+			},
+			expectErr: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -266,3 +586,106 @@ func TestGetEnvAsBool(t *testing.T) {
 		})
 	}
 }
+
+// TestSplitList tests the splitList function.
+func TestGetEnvAsInt64(t *testing.T) {
+	tests := []struct {
+		name         string
+		envValue     string
+		envSet       bool
+		defaultValue int64
+		expected     int64
+	}{
+		{name: "Environment variable is set", envValue: "100", envSet: true, defaultValue: 500, expected: 100},
+		{name: "Environment variable is not set, use default", envSet: false, defaultValue: 500, expected: 500},
+		{name: "Environment variable has invalid value, use default", envValue: "many", envSet: true, defaultValue: 500, expected: 500},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.envSet {
+				t.Setenv("TEST_INT_ENV", tt.envValue)
+			}
+
+			result := getEnvAsInt64("TEST_INT_ENV", tt.defaultValue)
+			if result != tt.expected {
+				t.Errorf("getEnvAsInt64(TEST_INT_ENV, %v) = %v; want %v", tt.defaultValue, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestGetEnvAsDuration(t *testing.T) {
+	tests := []struct {
+		name         string
+		envValue     string
+		envSet       bool
+		defaultValue time.Duration
+		expected     time.Duration
+	}{
+		{name: "Environment variable is set", envValue: "90s", envSet: true, defaultValue: time.Minute, expected: 90 * time.Second},
+		{name: "Environment variable is not set, use default", envSet: false, defaultValue: time.Minute, expected: time.Minute},
+		{name: "Environment variable has invalid value, use default", envValue: "soon", envSet: true, defaultValue: time.Minute, expected: time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.envSet {
+				t.Setenv("TEST_DURATION_ENV", tt.envValue)
+			}
+
+			result := getEnvAsDuration("TEST_DURATION_ENV", tt.defaultValue)
+			if result != tt.expected {
+				t.Errorf("getEnvAsDuration(TEST_DURATION_ENV, %v) = %v; want %v", tt.defaultValue, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSplitList(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected []string
+	}{
+		{name: "Empty value", value: "", expected: nil},
+		{name: "Single item", value: "default", expected: []string{"default"}},
+		{name: "Trims whitespace and skips empty items", value: " tenant-*, ,kube-system ", expected: []string{"tenant-*", "kube-system"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := splitList(tt.value)
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("splitList(%q) = %v; want %v", tt.value, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseNamespaceMapping(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		expected  map[string]string
+		expectErr bool
+	}{
+		{name: "Empty value", value: "", expected: nil},
+		{name: "Pairs", value: "prod:prod-copy, shop : shop-staging", expected: map[string]string{"prod": "prod-copy", "shop": "shop-staging"}},
+		{name: "Missing destination", value: "prod:", expectErr: true},
+		{name: "Missing separator", value: "prod", expectErr: true},
+		{name: "Source mapped twice", value: "prod:a,prod:b", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := parseNamespaceMapping(tt.value)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("parseNamespaceMapping(%q) error = %v; expectErr %v", tt.value, err, tt.expectErr)
+			}
+			if !reflect.DeepEqual(result, tt.expected) {
+				t.Errorf("parseNamespaceMapping(%q) = %v; want %v", tt.value, result, tt.expected)
+			}
+		})
+	}
+}
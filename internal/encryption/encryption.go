@@ -0,0 +1,227 @@
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"sync"
+)
+
+// Annotation marks a Secret whose data values have been encrypted by an Encrypter.
+// Its value is the version of the encryption format.
+const Annotation = "kube-save-restore.io/encryption"
+
+// lastAppliedAnnotation holds the configuration last applied by kubectl, which includes the Secret data
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+const (
+	// formatVersion is written by Encrypter. Version v1 did not bind values to the Secret holding them and
+	// is still read by Decrypter.
+	formatVersion       = "v2"
+	legacyFormatVersion = "v1"
+	saltSize            = 16
+	keySize             = 32
+	iterations          = 600000
+)
+
+// Encrypter encrypts Secret values with AES-256-GCM, using a key derived from a passphrase with
+// PBKDF2-SHA256. The key is derived once with a random salt, which is stored with every value so that
+// each one can be decrypted on its own. Encrypter is safe for concurrent use.
+type Encrypter struct {
+	salt []byte
+	aead cipher.AEAD
+}
+
+// NewEncrypter derives a key from passphrase with a new random salt
+func NewEncrypter(passphrase string) (*Encrypter, error) {
+	if passphrase == "" {
+		return nil, fmt.Errorf("encryption passphrase is empty")
+	}
+	salt := make([]byte, saltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("error generating salt: %v", err)
+	}
+	aead, err := deriveAEAD(passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	return &Encrypter{salt: salt, aead: aead}, nil
+}
+
+// EncryptSecret encrypts the data values of a Secret manifest in place and marks it with Annotation.
+// The kubectl last-applied-configuration annotation, which repeats the data, is encrypted as well.
+// Values are bound to the namespace and name of the Secret, which must not change before decryption.
+func (e *Encrypter) EncryptSecret(secret map[string]interface{}) error {
+	prefix := valuePrefix(formatVersion, secret)
+	data, _ := secret["data"].(map[string]interface{})
+	for key, value := range data {
+		encoded, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("data %s: expected a string, got %T", key, value)
+		}
+		plaintext, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return fmt.Errorf("data %s: %v", key, err)
+		}
+		data[key] = e.seal(plaintext, prefix+"data/"+key)
+	}
+
+	metadata, _ := secret["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+		secret["metadata"] = metadata
+	}
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	if annotations == nil {
+		annotations = map[string]interface{}{}
+		metadata["annotations"] = annotations
+	}
+	if lastApplied, ok := annotations[lastAppliedAnnotation].(string); ok {
+		annotations[lastAppliedAnnotation] = e.seal([]byte(lastApplied), prefix+"annotations/"+lastAppliedAnnotation)
+	}
+	annotations[Annotation] = formatVersion
+	return nil
+}
+
+// valuePrefix returns the prefix of the names of the values of secret in the encryption format version
+func valuePrefix(version string, secret map[string]interface{}) string {
+	if version == legacyFormatVersion {
+		return ""
+	}
+	metadata, _ := secret["metadata"].(map[string]interface{})
+	namespace, _ := metadata["namespace"].(string)
+	name, _ := metadata["name"].(string)
+	return namespace + "/" + name + "/"
+}
+
+// seal encrypts plaintext and returns the salt, nonce and ciphertext, base64-encoded.
+// The name of the value is authenticated so that values cannot be swapped between keys or Secrets.
+func (e *Encrypter) seal(plaintext []byte, name string) string {
+	nonce := make([]byte, e.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		// crypto/rand.Read never returns an error on supported platforms
+		panic(err)
+	}
+	sealed := make([]byte, 0, saltSize+len(nonce)+len(plaintext)+e.aead.Overhead())
+	sealed = append(sealed, e.salt...)
+	sealed = append(sealed, nonce...)
+	sealed = e.aead.Seal(sealed, nonce, plaintext, []byte(name))
+	return base64.StdEncoding.EncodeToString(sealed)
+}
+
+// IsEncrypted reports whether a resource manifest is a Secret encrypted by an Encrypter
+func IsEncrypted(resource map[string]interface{}) bool {
+	metadata, _ := resource["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	_, ok := annotations[Annotation]
+	return ok
+}
+
+// Decrypter decrypts Secrets encrypted by an Encrypter with the same passphrase.
+// Keys are derived once per salt. Decrypter is safe for concurrent use.
+type Decrypter struct {
+	passphrase string
+
+	mu   sync.Mutex
+	keys map[string]cipher.AEAD
+}
+
+// NewDecrypter returns a Decrypter for Secrets encrypted with passphrase
+func NewDecrypter(passphrase string) *Decrypter {
+	return &Decrypter{passphrase: passphrase, keys: make(map[string]cipher.AEAD)}
+}
+
+// DecryptSecret decrypts the data values of a Secret manifest marked with Annotation in place and
+// removes the annotation. It fails if the passphrase is wrong or any value has been tampered with.
+func (d *Decrypter) DecryptSecret(secret map[string]interface{}) error {
+	metadata, _ := secret["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	version, _ := annotations[Annotation].(string)
+	if version != formatVersion && version != legacyFormatVersion {
+		return fmt.Errorf("unsupported encryption format %q", version)
+	}
+	prefix := valuePrefix(version, secret)
+
+	data, _ := secret["data"].(map[string]interface{})
+	for key, value := range data {
+		sealed, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("data %s: expected a string, got %T", key, value)
+		}
+		plaintext, err := d.open(sealed, prefix+"data/"+key)
+		if err != nil {
+			return fmt.Errorf("data %s: %v", key, err)
+		}
+		data[key] = base64.StdEncoding.EncodeToString(plaintext)
+	}
+
+	if sealed, ok := annotations[lastAppliedAnnotation].(string); ok {
+		plaintext, err := d.open(sealed, prefix+"annotations/"+lastAppliedAnnotation)
+		if err != nil {
+			return fmt.Errorf("annotation %s: %v", lastAppliedAnnotation, err)
+		}
+		annotations[lastAppliedAnnotation] = string(plaintext)
+	}
+	delete(annotations, Annotation)
+	if len(annotations) == 0 {
+		delete(metadata, "annotations")
+	}
+	return nil
+}
+
+// open decrypts a value returned by seal
+func (d *Decrypter) open(value, name string) ([]byte, error) {
+	sealed, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding encrypted value: %v", err)
+	}
+	if len(sealed) < saltSize {
+		return nil, fmt.Errorf("encrypted value is truncated")
+	}
+	aead, err := d.aead(sealed[:saltSize])
+	if err != nil {
+		return nil, err
+	}
+	sealed = sealed[saltSize:]
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted value is truncated")
+	}
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, []byte(name))
+	if err != nil {
+		return nil, fmt.Errorf("decryption failed: wrong passphrase or modified value")
+	}
+	return plaintext, nil
+}
+
+// aead returns the cipher for the key derived from the passphrase and salt
+func (d *Decrypter) aead(salt []byte) (cipher.AEAD, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if aead, ok := d.keys[string(salt)]; ok {
+		return aead, nil
+	}
+	aead, err := deriveAEAD(d.passphrase, salt)
+	if err != nil {
+		return nil, err
+	}
+	d.keys[string(salt)] = aead
+	return aead, nil
+}
+
+// deriveAEAD derives an AES-256-GCM cipher from passphrase and salt
+func deriveAEAD(passphrase string, salt []byte) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, iterations, keySize)
+	if err != nil {
+		return nil, fmt.Errorf("error deriving encryption key: %v", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("error creating cipher: %v", err)
+	}
+	return cipher.NewGCM(block)
+}
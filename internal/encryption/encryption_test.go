@@ -0,0 +1,177 @@
+package encryption
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+)
+
+// newSecret returns a Secret manifest holding the given values
+func newSecret(values map[string]string, annotations map[string]interface{}) map[string]interface{} {
+	data := make(map[string]interface{}, len(values))
+	for key, value := range values {
+		data[key] = base64.StdEncoding.EncodeToString([]byte(value))
+	}
+	metadata := map[string]interface{}{"name": "db", "namespace": "default"}
+	if annotations != nil {
+		metadata["annotations"] = annotations
+	}
+	return map[string]interface{}{"apiVersion": "v1", "kind": "Secret", "metadata": metadata, "data": data}
+}
+
+func TestEncryptDecryptSecret(t *testing.T) {
+	encrypter, err := NewEncrypter("correct horse battery staple")
+	if err != nil {
+		t.Fatalf("NewEncrypter() error = %v", err)
+	}
+	lastApplied := `{"data":{"password":"aHVudGVyMg=="}}`
+	secret := newSecret(map[string]string{"username": "admin", "password": "hunter2"},
+		map[string]interface{}{lastAppliedAnnotation: lastApplied, "team": "payments"})
+	original := newSecret(map[string]string{"username": "admin", "password": "hunter2"}, nil)
+
+	if err := encrypter.EncryptSecret(secret); err != nil {
+		t.Fatalf("EncryptSecret() error = %v", err)
+	}
+	if !IsEncrypted(secret) {
+		t.Fatal("IsEncrypted() = false after EncryptSecret()")
+	}
+	data := secret["data"].(map[string]interface{})
+	for key, value := range data {
+		if value == original["data"].(map[string]interface{})[key] {
+			t.Errorf("data %s was not encrypted", key)
+		}
+	}
+	annotations := secret["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})
+	if strings.Contains(annotations[lastAppliedAnnotation].(string), "aHVudGVyMg==") {
+		t.Error("last-applied-configuration annotation was not encrypted")
+	}
+
+	if err := NewDecrypter("correct horse battery staple").DecryptSecret(secret); err != nil {
+		t.Fatalf("DecryptSecret() error = %v", err)
+	}
+	if IsEncrypted(secret) {
+		t.Error("IsEncrypted() = true after DecryptSecret()")
+	}
+	for key, value := range original["data"].(map[string]interface{}) {
+		if data[key] != value {
+			t.Errorf("data %s = %v after decryption; want %v", key, data[key], value)
+		}
+	}
+	if annotations[lastAppliedAnnotation] != lastApplied || annotations["team"] != "payments" {
+		t.Errorf("annotations = %v after decryption", annotations)
+	}
+}
+
+func TestDecryptSecretErrors(t *testing.T) {
+	encrypter, err := NewEncrypter("passphrase")
+	if err != nil {
+		t.Fatalf("NewEncrypter() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		passphrase string
+		modify     func(secret map[string]interface{})
+	}{
+		{
+			name:       "Wrong passphrase",
+			passphrase: "other passphrase",
+			modify:     func(secret map[string]interface{}) {},
+		},
+		{
+			name:       "Swapped values",
+			passphrase: "passphrase",
+			modify: func(secret map[string]interface{}) {
+				data := secret["data"].(map[string]interface{})
+				data["username"], data["password"] = data["password"], data["username"]
+			},
+		},
+		{
+			name:       "Renamed Secret",
+			passphrase: "passphrase",
+			modify: func(secret map[string]interface{}) {
+				secret["metadata"].(map[string]interface{})["name"] = "other"
+			},
+		},
+		{
+			name:       "Moved to another namespace",
+			passphrase: "passphrase",
+			modify: func(secret map[string]interface{}) {
+				secret["metadata"].(map[string]interface{})["namespace"] = "other"
+			},
+		},
+		{
+			name:       "Truncated value",
+			passphrase: "passphrase",
+			modify: func(secret map[string]interface{}) {
+				secret["data"].(map[string]interface{})["password"] = base64.StdEncoding.EncodeToString([]byte("short"))
+			},
+		},
+		{
+			name:       "Unknown format",
+			passphrase: "passphrase",
+			modify: func(secret map[string]interface{}) {
+				secret["metadata"].(map[string]interface{})["annotations"].(map[string]interface{})[Annotation] = "v0"
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := newSecret(map[string]string{"username": "admin", "password": "hunter2"}, nil)
+			if err := encrypter.EncryptSecret(secret); err != nil {
+				t.Fatalf("EncryptSecret() error = %v", err)
+			}
+			tt.modify(secret)
+			if err := NewDecrypter(tt.passphrase).DecryptSecret(secret); err == nil {
+				t.Error("DecryptSecret() error = nil; want an error")
+			}
+		})
+	}
+}
+
+func TestNewEncrypterEmptyPassphrase(t *testing.T) {
+	if _, err := NewEncrypter(""); err == nil {
+		t.Error("NewEncrypter() error = nil; want an error for an empty passphrase")
+	}
+}
+
+// TestDecryptSecretFromAnotherSecret tests that a value cannot be moved to the same key of another Secret.
+func TestDecryptSecretFromAnotherSecret(t *testing.T) {
+	encrypter, err := NewEncrypter("passphrase")
+	if err != nil {
+		t.Fatalf("NewEncrypter() error = %v", err)
+	}
+	source := newSecret(map[string]string{"password": "hunter2"}, nil)
+	target := newSecret(map[string]string{"password": "letmein"}, nil)
+	target["metadata"].(map[string]interface{})["name"] = "cache"
+	for _, secret := range []map[string]interface{}{source, target} {
+		if err := encrypter.EncryptSecret(secret); err != nil {
+			t.Fatalf("EncryptSecret() error = %v", err)
+		}
+	}
+
+	target["data"].(map[string]interface{})["password"] = source["data"].(map[string]interface{})["password"]
+	if err := NewDecrypter("passphrase").DecryptSecret(target); err == nil {
+		t.Error("DecryptSecret() error = nil; want an error for a value moved from another Secret")
+	}
+}
+
+// TestDecryptSecretLegacyFormat tests that Secrets encrypted in the v1 format, which did not bind values
+// to their Secret, can still be decrypted.
+func TestDecryptSecretLegacyFormat(t *testing.T) {
+	encrypter, err := NewEncrypter("passphrase")
+	if err != nil {
+		t.Fatalf("NewEncrypter() error = %v", err)
+	}
+	secret := newSecret(nil, map[string]interface{}{Annotation: legacyFormatVersion})
+	secret["data"] = map[string]interface{}{"password": encrypter.seal([]byte("hunter2"), "data/password")}
+
+	if err := NewDecrypter("passphrase").DecryptSecret(secret); err != nil {
+		t.Fatalf("DecryptSecret() error = %v", err)
+	}
+	want := base64.StdEncoding.EncodeToString([]byte("hunter2"))
+	if got := secret["data"].(map[string]interface{})["password"]; got != want {
+		t.Errorf("password = %v; want %v", got, want)
+	}
+}
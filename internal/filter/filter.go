@@ -0,0 +1,75 @@
+package filter
+
+import (
+	"fmt"
+	"path"
+)
+
+// ResourceTypeAliases maps the abbreviated resource types backups store resources under to their plural
+// resource name, which resource filters accept as well
+var ResourceTypeAliases = map[string]string{
+	"hpas": "horizontalpodautoscalers",
+	"pvcs": "persistentvolumeclaims",
+}
+
+// Filter matches names against include and exclude glob patterns.
+// A name matches when it matches at least one include pattern (or no include patterns are set)
+// and does not match any exclude pattern. A nil Filter matches every name.
+type Filter struct {
+	include []string
+	exclude []string
+}
+
+// New creates a Filter from include and exclude glob patterns using path.Match syntax.
+// It returns an error if any pattern is malformed.
+func New(include, exclude []string) (*Filter, error) {
+	for _, pattern := range append(append([]string{}, include...), exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return &Filter{include: include, exclude: exclude}, nil
+}
+
+// IsZero reports whether the filter has no patterns, in which case it matches every name
+func (f *Filter) IsZero() bool {
+	return f == nil || (len(f.include) == 0 && len(f.exclude) == 0)
+}
+
+// Matches reports whether name is selected by the filter
+func (f *Filter) Matches(name string) bool {
+	return f.MatchesAny(name)
+}
+
+// MatchesAny reports whether an object known by several names is selected by the filter.
+// It is included when any of its names matches an include pattern and excluded when any
+// of its names matches an exclude pattern.
+func (f *Filter) MatchesAny(names ...string) bool {
+	if f == nil {
+		return true
+	}
+	if len(f.include) > 0 && !anyMatches(f.include, names) {
+		return false
+	}
+	return !anyMatches(f.exclude, names)
+}
+
+// anyMatches reports whether any of the names matches at least one of the patterns
+func anyMatches(patterns, names []string) bool {
+	for _, name := range names {
+		if matchesAny(patterns, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesAny reports whether name matches at least one of the patterns
+func matchesAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
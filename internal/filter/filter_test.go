@@ -0,0 +1,65 @@
+package filter
+
+import (
+	"testing"
+)
+
+func TestFilterMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		input    string
+		expected bool
+	}{
+		{name: "No patterns", input: "default", expected: true},
+		{name: "Included by glob", include: []string{"tenant-*"}, input: "tenant-a", expected: true},
+		{name: "Not included", include: []string{"tenant-*"}, input: "default", expected: false},
+		{name: "Excluded", exclude: []string{"kube-*"}, input: "kube-system", expected: false},
+		{name: "Not excluded", exclude: []string{"kube-*"}, input: "default", expected: true},
+		{name: "Exclude wins over include", include: []string{"*"}, exclude: []string{"kube-system"}, input: "kube-system", expected: false},
+		{name: "Any include pattern", include: []string{"prod", "staging-?"}, input: "staging-1", expected: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := New(tt.include, tt.exclude)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			if got := f.Matches(tt.input); got != tt.expected {
+				t.Errorf("Matches(%q) = %v; want %v", tt.input, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestNilFilterMatches(t *testing.T) {
+	var f *Filter
+	if !f.Matches("anything") {
+		t.Error("expected a nil filter to match every name")
+	}
+}
+
+func TestNewInvalidPattern(t *testing.T) {
+	if _, err := New([]string{"tenant-["}, nil); err == nil {
+		t.Error("expected an error for a malformed pattern")
+	}
+}
+
+func TestFilterMatchesAny(t *testing.T) {
+	f, err := New([]string{"secrets", "deployments"}, []string{"jobs"})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if !f.MatchesAny("secret", "secrets") {
+		t.Error("expected a name matching an include pattern to be selected")
+	}
+	if f.MatchesAny("configmap", "configmaps") {
+		t.Error("expected names matching no include pattern to be rejected")
+	}
+	if f.MatchesAny("deployments", "jobs") {
+		t.Error("expected a name matching an exclude pattern to be rejected")
+	}
+}
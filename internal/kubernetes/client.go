@@ -3,8 +3,12 @@ package kubernetes
 import (
 	"fmt"
 
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -24,12 +28,20 @@ type ClientInterface interface {
 	PersistentVolumeClaimLister
 	IngressLister
 	RoleLister
+	RoleBindingLister
 	NetworkPolicyLister
+	APIResourceLister
 }
 
 // Client implements the ClientInterface
 type Client struct {
 	Clientset kubernetes.Interface
+	Dynamic   dynamic.Interface
+	Mapper    meta.RESTMapper
+
+	// Host is the address of the API server and Context the kubeconfig context the client was created for
+	Host    string
+	Context string
 }
 
 // ConfigModifier is a function type that modifies a rest.Config
@@ -53,6 +65,14 @@ func NewClient(kubeconfigPath, context string, modifier ConfigModifier) (*Client
 		return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
 	}
 
+	if context == "" {
+		rawConfig, err := loader.RawConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+		}
+		context = rawConfig.CurrentContext
+	}
+
 	if modifier != nil {
 		modifier(config)
 	}
@@ -62,5 +82,21 @@ func NewClient(kubeconfigPath, context string, modifier ConfigModifier) (*Client
 		return nil, fmt.Errorf("failed to create clientset: %w", err)
 	}
 
-	return &Client{Clientset: clientset}, nil
+	dynamicClient, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create dynamic client: %w", err)
+	}
+
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(memory.NewMemCacheClient(clientset.Discovery()))
+
+	return &Client{Clientset: clientset, Dynamic: dynamicClient, Mapper: mapper, Host: config.Host, Context: context}, nil
+}
+
+// ServerVersion returns the Kubernetes version of the API server, such as "v1.31.0"
+func (c *Client) ServerVersion() (string, error) {
+	info, err := c.Clientset.Discovery().ServerVersion()
+	if err != nil {
+		return "", fmt.Errorf("failed to get server version: %w", err)
+	}
+	return info.GitVersion, nil
 }
@@ -12,7 +12,14 @@ import (
 	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/version"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
 	"k8s.io/client-go/kubernetes/fake"
 	"k8s.io/client-go/rest"
 )
@@ -168,6 +175,9 @@ users:
 				if client == nil {
 					t.Fatalf("Expected client but got nil")
 				}
+				if client.Context != tt.context {
+					t.Errorf("Expected context to be %s, got %s", tt.context, client.Context)
+				}
 
 				// Check QPS and Burst values
 				if tt.configModifier != nil {
@@ -186,12 +196,26 @@ users:
 	}
 }
 
+func TestServerVersion(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).FakedServerVersion = &version.Info{GitVersion: "v1.31.0"}
+	client := &Client{Clientset: clientset}
+
+	serverVersion, err := client.ServerVersion()
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if serverVersion != "v1.31.0" {
+		t.Fatalf("expected server version to be 'v1.31.0', got %s", serverVersion)
+	}
+}
+
 func TestListConfigMaps(t *testing.T) {
 	client := &Client{Clientset: fake.NewSimpleClientset(&v1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{Name: "test-configmap", Namespace: "default"},
 	})}
 
-	configMaps, err := client.ListConfigMaps(context.Background(), "default")
+	configMaps, err := client.ListConfigMaps(context.Background(), "default", metav1.ListOptions{})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -210,7 +234,7 @@ func TestListDeployments(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{Name: "test-deployment", Namespace: "default"},
 	})}
 
-	deployments, err := client.ListDeployments(context.Background(), "default")
+	deployments, err := client.ListDeployments(context.Background(), "default", metav1.ListOptions{})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -224,6 +248,26 @@ func TestListDeployments(t *testing.T) {
 	}
 }
 
+func TestListDeploymentsWithLabelSelector(t *testing.T) {
+	client := &Client{Clientset: fake.NewSimpleClientset(
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "shop", Namespace: "default", Labels: map[string]string{"app.kubernetes.io/part-of": "shop"}}},
+		&appsv1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "blog", Namespace: "default", Labels: map[string]string{"app.kubernetes.io/part-of": "blog"}}},
+	)}
+
+	deployments, err := client.ListDeployments(context.Background(), "default", metav1.ListOptions{LabelSelector: "app.kubernetes.io/part-of=shop"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(deployments.Items) != 1 {
+		t.Fatalf("expected 1 deployment, got %d", len(deployments.Items))
+	}
+
+	if deployments.Items[0].Name != "shop" {
+		t.Fatalf("expected deployment name to be 'shop', got %s", deployments.Items[0].Name)
+	}
+}
+
 func TestListNamespaces(t *testing.T) {
 	client := &Client{Clientset: fake.NewSimpleClientset(&v1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{Name: "test-namespace"},
@@ -248,7 +292,7 @@ func TestListSecrets(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{Name: "test-secret", Namespace: "default"},
 	})}
 
-	secrets, err := client.ListSecrets(context.Background(), "default")
+	secrets, err := client.ListSecrets(context.Background(), "default", metav1.ListOptions{})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -267,7 +311,7 @@ func TestListServices(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{Name: "test-service", Namespace: "default"},
 	})}
 
-	services, err := client.ListServices(context.Background(), "default")
+	services, err := client.ListServices(context.Background(), "default", metav1.ListOptions{})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -286,7 +330,7 @@ func TestListStatefulSets(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{Name: "test-statefulset", Namespace: "default"},
 	})}
 
-	statefulSets, err := client.ListStatefulSets(context.Background(), "default")
+	statefulSets, err := client.ListStatefulSets(context.Background(), "default", metav1.ListOptions{})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -305,7 +349,7 @@ func TestListHorizontalPodAutoscalers(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{Name: "test-hpa", Namespace: "default"},
 	})}
 
-	hpas, err := client.ListHorizontalPodAutoscalers(context.Background(), "default")
+	hpas, err := client.ListHorizontalPodAutoscalers(context.Background(), "default", metav1.ListOptions{})
 
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
@@ -325,7 +369,7 @@ func TestListCronJobs(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{Name: "test-cronjob", Namespace: "default"},
 	})}
 
-	cronJobs, err := client.ListCronJobs(context.Background(), "default")
+	cronJobs, err := client.ListCronJobs(context.Background(), "default", metav1.ListOptions{})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -344,7 +388,7 @@ func TestListPersistentVolumeClaims(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{Name: "test-pvc", Namespace: "default"},
 	})}
 
-	pvcs, err := client.ListPersistentVolumeClaims(context.Background(), "default")
+	pvcs, err := client.ListPersistentVolumeClaims(context.Background(), "default", metav1.ListOptions{})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -363,7 +407,7 @@ func TestListIngresses(t *testing.T) {
 		ObjectMeta: metav1.ObjectMeta{Name: "test-ingress", Namespace: "default"},
 	})}
 
-	ingresses, err := client.ListIngresses(context.Background(), "default")
+	ingresses, err := client.ListIngresses(context.Background(), "default", metav1.ListOptions{})
 	if err != nil {
 		t.Fatalf("expected no error, got %v", err)
 	}
@@ -376,3 +420,95 @@ func TestListIngresses(t *testing.T) {
 		t.Fatalf("expected ingress name to be 'test-ingress', got %s", ingresses.Items[0].Name)
 	}
 }
+
+func TestListNamespacedAPIResources(t *testing.T) {
+	clientset := fake.NewSimpleClientset()
+	clientset.Discovery().(*fakediscovery.FakeDiscovery).Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: "v1",
+			APIResources: []metav1.APIResource{
+				{Name: "configmaps", Kind: "ConfigMap", Namespaced: true, Verbs: metav1.Verbs{"get", "list"}},
+				{Name: "namespaces", Kind: "Namespace", Namespaced: false, Verbs: metav1.Verbs{"get", "list"}},
+				{Name: "bindings", Kind: "Binding", Namespaced: true, Verbs: metav1.Verbs{"create"}},
+			},
+		},
+		{
+			GroupVersion: "cert-manager.io/v1",
+			APIResources: []metav1.APIResource{
+				{Name: "certificates", Kind: "Certificate", Namespaced: true, Verbs: metav1.Verbs{"get", "list"}},
+			},
+		},
+	}
+	client := &Client{Clientset: clientset}
+
+	resources, err := client.ListNamespacedAPIResources(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(resources) != 2 {
+		t.Fatalf("expected 2 resources, got %d", len(resources))
+	}
+
+	for _, resource := range resources {
+		switch resource.Name {
+		case "configmaps":
+			if resource.Group != "" || resource.Version != "v1" {
+				t.Fatalf("expected configmaps in v1, got %s/%s", resource.Group, resource.Version)
+			}
+		case "certificates":
+			if resource.Group != "cert-manager.io" || resource.Version != "v1" {
+				t.Fatalf("expected certificates in cert-manager.io/v1, got %s/%s", resource.Group, resource.Version)
+			}
+		default:
+			t.Fatalf("unexpected resource %s", resource.Name)
+		}
+	}
+}
+
+func TestListResources(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+	certificate := &unstructured.Unstructured{}
+	certificate.SetAPIVersion("cert-manager.io/v1")
+	certificate.SetKind("Certificate")
+	certificate.SetNamespace("default")
+	certificate.SetName("test-certificate")
+
+	client := &Client{Dynamic: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(
+		runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "CertificateList"},
+		certificate,
+	)}
+
+	certificates, err := client.ListResources(context.Background(), gvr, "default", metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(certificates.Items) != 1 {
+		t.Fatalf("expected 1 certificate, got %d", len(certificates.Items))
+	}
+
+	if certificates.Items[0].GetName() != "test-certificate" {
+		t.Fatalf("expected certificate name to be 'test-certificate', got %s", certificates.Items[0].GetName())
+	}
+}
+
+func TestListRoleBindings(t *testing.T) {
+	client := &Client{Clientset: fake.NewSimpleClientset(&rbacv1.RoleBinding{
+		ObjectMeta: metav1.ObjectMeta{Name: "test-rolebinding", Namespace: "default"},
+	})}
+
+	roleBindings, err := client.ListRoleBindings(context.Background(), "default", metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+
+	if len(roleBindings.Items) != 1 {
+		t.Fatalf("expected 1 role binding, got %d", len(roleBindings.Items))
+	}
+
+	if roleBindings.Items[0].Name != "test-rolebinding" {
+		t.Fatalf("expected role binding name to be 'test-rolebinding', got %s", roleBindings.Items[0].Name)
+	}
+}
@@ -9,10 +9,10 @@ import (
 
 // ConfigMapLister defines the method to list ConfigMaps
 type ConfigMapLister interface {
-	ListConfigMaps(ctx context.Context, namespace string) (*v1.ConfigMapList, error)
+	ListConfigMaps(ctx context.Context, namespace string, opts metav1.ListOptions) (*v1.ConfigMapList, error)
 }
 
-// ListConfigMaps lists all ConfigMaps in the specified namespace
-func (c *Client) ListConfigMaps(ctx context.Context, namespace string) (*v1.ConfigMapList, error) {
-	return c.Clientset.CoreV1().ConfigMaps(namespace).List(ctx, metav1.ListOptions{})
+// ListConfigMaps lists all ConfigMaps in the specified namespace matching the list options
+func (c *Client) ListConfigMaps(ctx context.Context, namespace string, opts metav1.ListOptions) (*v1.ConfigMapList, error) {
+	return c.Clientset.CoreV1().ConfigMaps(namespace).List(ctx, opts)
 }
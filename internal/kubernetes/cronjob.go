@@ -9,10 +9,10 @@ import (
 
 // CronJobLister is an interface that lists cronjobs.
 type CronJobLister interface {
-	ListCronJobs(ctx context.Context, namespace string) (*batchv1.CronJobList, error)
+	ListCronJobs(ctx context.Context, namespace string, opts metav1.ListOptions) (*batchv1.CronJobList, error)
 }
 
-// ListCronJobs lists all cronjobs in the given namespace.
-func (c *Client) ListCronJobs(ctx context.Context, namespace string) (*batchv1.CronJobList, error) {
-	return c.Clientset.BatchV1().CronJobs(namespace).List(ctx, metav1.ListOptions{})
+// ListCronJobs lists all cronjobs in the given namespace matching the list options.
+func (c *Client) ListCronJobs(ctx context.Context, namespace string, opts metav1.ListOptions) (*batchv1.CronJobList, error) {
+	return c.Clientset.BatchV1().CronJobs(namespace).List(ctx, opts)
 }
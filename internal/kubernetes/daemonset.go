@@ -9,10 +9,10 @@ import (
 
 // DaemonSetLister defines the method to list DaemonSets
 type DaemonSetLister interface {
-	ListDaemonSets(ctx context.Context, namespace string) (*appsv1.DaemonSetList, error)
+	ListDaemonSets(ctx context.Context, namespace string, opts metav1.ListOptions) (*appsv1.DaemonSetList, error)
 }
 
-// ListDaemonSets lists all DaemonSets in the specified namespace
-func (c *Client) ListDaemonSets(ctx context.Context, namespace string) (*appsv1.DaemonSetList, error) {
-	return c.Clientset.AppsV1().DaemonSets(namespace).List(ctx, metav1.ListOptions{})
+// ListDaemonSets lists all DaemonSets in the specified namespace matching the list options
+func (c *Client) ListDaemonSets(ctx context.Context, namespace string, opts metav1.ListOptions) (*appsv1.DaemonSetList, error) {
+	return c.Clientset.AppsV1().DaemonSets(namespace).List(ctx, opts)
 }
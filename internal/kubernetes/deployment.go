@@ -9,10 +9,10 @@ import (
 
 // DeploymentLister defines the method to list Deployments
 type DeploymentLister interface {
-	ListDeployments(ctx context.Context, namespace string) (*appsv1.DeploymentList, error)
+	ListDeployments(ctx context.Context, namespace string, opts metav1.ListOptions) (*appsv1.DeploymentList, error)
 }
 
-// ListDeployments lists all Deployments in the specified namespace
-func (c *Client) ListDeployments(ctx context.Context, namespace string) (*appsv1.DeploymentList, error) {
-	return c.Clientset.AppsV1().Deployments(namespace).List(ctx, metav1.ListOptions{})
+// ListDeployments lists all Deployments in the specified namespace matching the list options
+func (c *Client) ListDeployments(ctx context.Context, namespace string, opts metav1.ListOptions) (*appsv1.DeploymentList, error) {
+	return c.Clientset.AppsV1().Deployments(namespace).List(ctx, opts)
 }
@@ -0,0 +1,99 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/dynamic"
+)
+
+// APIResourceLister defines the methods to discover and list arbitrary API resources
+type APIResourceLister interface {
+	ListNamespacedAPIResources(ctx context.Context) ([]metav1.APIResource, error)
+	ListResources(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error)
+}
+
+// ListNamespacedAPIResources discovers every namespaced resource served by the cluster that supports the list verb.
+// The returned resources have their Group and Version fields populated with the preferred version of each group.
+// If some API groups cannot be discovered, the resources of the remaining groups are returned together with
+// a *discovery.ErrGroupDiscoveryFailed error.
+func (c *Client) ListNamespacedAPIResources(ctx context.Context) ([]metav1.APIResource, error) {
+	resourceLists, discoveryErr := discovery.ServerPreferredNamespacedResources(c.Clientset.Discovery())
+	if discoveryErr != nil && !discovery.IsGroupDiscoveryFailedError(discoveryErr) {
+		return nil, discoveryErr
+	}
+
+	var resources []metav1.APIResource
+	for _, resourceList := range resourceLists {
+		gv, err := schema.ParseGroupVersion(resourceList.GroupVersion)
+		if err != nil {
+			return nil, fmt.Errorf("invalid group version %q: %w", resourceList.GroupVersion, err)
+		}
+		for _, resource := range resourceList.APIResources {
+			if !resource.Namespaced || !containsVerb(resource.Verbs, "list") {
+				continue
+			}
+			resource.Group = gv.Group
+			resource.Version = gv.Version
+			resources = append(resources, resource)
+		}
+	}
+	return resources, discoveryErr
+}
+
+// ListResources lists all objects of the given resource in the specified namespace matching the list options,
+// using the dynamic client. An empty namespace lists cluster-scoped resources.
+func (c *Client) ListResources(ctx context.Context, gvr schema.GroupVersionResource, namespace string, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	if namespace == "" {
+		return c.Dynamic.Resource(gvr).List(ctx, opts)
+	}
+	return c.Dynamic.Resource(gvr).Namespace(namespace).List(ctx, opts)
+}
+
+// ResourceFor resolves the API resource serving the given object through the client's RESTMapper and returns
+// a dynamic client for it, scoped to the object's namespace when the resource is namespaced.
+// If the kind is unknown the mapper is reset once and the lookup retried, so that kinds introduced by
+// CustomResourceDefinitions created earlier in the same run can be resolved.
+func (c *Client) ResourceFor(obj *unstructured.Unstructured) (dynamic.ResourceInterface, *meta.RESTMapping, error) {
+	gvk := obj.GroupVersionKind()
+	mapping, err := c.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if meta.IsNoMatchError(err) {
+		if resettable, ok := c.Mapper.(meta.ResettableRESTMapper); ok {
+			resettable.Reset()
+			mapping, err = c.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+		}
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("error mapping %s to an API resource: %w", gvk, err)
+	}
+
+	if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
+		return c.Dynamic.Resource(mapping.Resource).Namespace(obj.GetNamespace()), mapping, nil
+	}
+	return c.Dynamic.Resource(mapping.Resource), mapping, nil
+}
+
+// IsNamespaced reports whether objects of the given kind are namespaced, according to the client's RESTMapper.
+// It returns an error if the RESTMapper does not know the kind.
+func (c *Client) IsNamespaced(gvk schema.GroupVersionKind) (bool, error) {
+	mapping, err := c.Mapper.RESTMapping(gvk.GroupKind(), gvk.Version)
+	if err != nil {
+		return false, fmt.Errorf("error mapping %s to an API resource: %w", gvk, err)
+	}
+	return mapping.Scope.Name() == meta.RESTScopeNameNamespace, nil
+}
+
+// containsVerb reports whether verb is present in verbs
+func containsVerb(verbs metav1.Verbs, verb string) bool {
+	for _, v := range verbs {
+		if v == verb {
+			return true
+		}
+	}
+	return false
+}
@@ -9,10 +9,10 @@ import (
 
 // HorizontalPodAutoscalerLister defines the methods to list HorizontalPodAutoscalers
 type HorizontalPodAutoscalerLister interface {
-	ListHorizontalPodAutoscalers(ctx context.Context, namespace string) (*autoscalingv2.HorizontalPodAutoscalerList, error)
+	ListHorizontalPodAutoscalers(ctx context.Context, namespace string, opts metav1.ListOptions) (*autoscalingv2.HorizontalPodAutoscalerList, error)
 }
 
-// ListHPAs lists all HPAs in the specified namespace
-func (c *Client) ListHorizontalPodAutoscalers(ctx context.Context, namespace string) (*autoscalingv2.HorizontalPodAutoscalerList, error) {
-	return c.Clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(ctx, metav1.ListOptions{})
+// ListHPAs lists all HPAs in the specified namespace matching the list options
+func (c *Client) ListHorizontalPodAutoscalers(ctx context.Context, namespace string, opts metav1.ListOptions) (*autoscalingv2.HorizontalPodAutoscalerList, error) {
+	return c.Clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).List(ctx, opts)
 }
@@ -8,9 +8,9 @@ import (
 )
 
 type IngressLister interface {
-	ListIngresses(ctx context.Context, namespace string) (*networkingv1.IngressList, error)
+	ListIngresses(ctx context.Context, namespace string, opts metav1.ListOptions) (*networkingv1.IngressList, error)
 }
 
-func (c *Client) ListIngresses(ctx context.Context, namespace string) (*networkingv1.IngressList, error) {
-	return c.Clientset.NetworkingV1().Ingresses(namespace).List(ctx, metav1.ListOptions{})
+func (c *Client) ListIngresses(ctx context.Context, namespace string, opts metav1.ListOptions) (*networkingv1.IngressList, error) {
+	return c.Clientset.NetworkingV1().Ingresses(namespace).List(ctx, opts)
 }
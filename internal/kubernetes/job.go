@@ -8,9 +8,9 @@ import (
 )
 
 type JobLister interface {
-	ListJobs(ctx context.Context, namespace string) (*batchv1.JobList, error)
+	ListJobs(ctx context.Context, namespace string, opts metav1.ListOptions) (*batchv1.JobList, error)
 }
 
-func (c *Client) ListJobs(ctx context.Context, namespace string) (*batchv1.JobList, error) {
-	return c.Clientset.BatchV1().Jobs(namespace).List(ctx, metav1.ListOptions{})
+func (c *Client) ListJobs(ctx context.Context, namespace string, opts metav1.ListOptions) (*batchv1.JobList, error) {
+	return c.Clientset.BatchV1().Jobs(namespace).List(ctx, opts)
 }
@@ -9,10 +9,10 @@ import (
 
 // PersistentVolumeClaimLister defines the methods to list persistent volume claims
 type PersistentVolumeClaimLister interface {
-	ListPersistentVolumeClaims(ctx context.Context, namespace string) (*v1.PersistentVolumeClaimList, error)
+	ListPersistentVolumeClaims(ctx context.Context, namespace string, opts metav1.ListOptions) (*v1.PersistentVolumeClaimList, error)
 }
 
-// ListPersistentVolumeClaims lists all persistent volume claims in the specified namespace
-func (c *Client) ListPersistentVolumeClaims(ctx context.Context, namespace string) (*v1.PersistentVolumeClaimList, error) {
-	return c.Clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx, metav1.ListOptions{})
+// ListPersistentVolumeClaims lists all persistent volume claims in the specified namespace matching the list options
+func (c *Client) ListPersistentVolumeClaims(ctx context.Context, namespace string, opts metav1.ListOptions) (*v1.PersistentVolumeClaimList, error) {
+	return c.Clientset.CoreV1().PersistentVolumeClaims(namespace).List(ctx, opts)
 }
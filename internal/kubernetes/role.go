@@ -9,10 +9,10 @@ import (
 
 // RoleLister defines the method to list Roles
 type RoleLister interface {
-	ListRoles(ctx context.Context, namespace string) (*rbacv1.RoleList, error)
+	ListRoles(ctx context.Context, namespace string, opts metav1.ListOptions) (*rbacv1.RoleList, error)
 }
 
-// ListRoles lists all Roles in the specified namespace
-func (c *Client) ListRoles(ctx context.Context, namespace string) (*rbacv1.RoleList, error) {
-	return c.Clientset.RbacV1().Roles(namespace).List(ctx, metav1.ListOptions{})
+// ListRoles lists all Roles in the specified namespace matching the list options
+func (c *Client) ListRoles(ctx context.Context, namespace string, opts metav1.ListOptions) (*rbacv1.RoleList, error) {
+	return c.Clientset.RbacV1().Roles(namespace).List(ctx, opts)
 }
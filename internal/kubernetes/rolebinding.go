@@ -0,0 +1,18 @@
+package kubernetes
+
+import (
+	"context"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// RoleBindingLister defines the method to list RoleBindings
+type RoleBindingLister interface {
+	ListRoleBindings(ctx context.Context, namespace string, opts metav1.ListOptions) (*rbacv1.RoleBindingList, error)
+}
+
+// ListRoleBindings lists all RoleBindings in the specified namespace matching the list options
+func (c *Client) ListRoleBindings(ctx context.Context, namespace string, opts metav1.ListOptions) (*rbacv1.RoleBindingList, error) {
+	return c.Clientset.RbacV1().RoleBindings(namespace).List(ctx, opts)
+}
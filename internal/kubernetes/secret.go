@@ -9,10 +9,10 @@ import (
 
 // SecretLister defines the method to list Secrets
 type SecretLister interface {
-	ListSecrets(ctx context.Context, namespace string) (*v1.SecretList, error)
+	ListSecrets(ctx context.Context, namespace string, opts metav1.ListOptions) (*v1.SecretList, error)
 }
 
-// ListSecrets lists all Secrets in the specified namespace
-func (c *Client) ListSecrets(ctx context.Context, namespace string) (*v1.SecretList, error) {
-	return c.Clientset.CoreV1().Secrets(namespace).List(ctx, metav1.ListOptions{})
+// ListSecrets lists all Secrets in the specified namespace matching the list options
+func (c *Client) ListSecrets(ctx context.Context, namespace string, opts metav1.ListOptions) (*v1.SecretList, error) {
+	return c.Clientset.CoreV1().Secrets(namespace).List(ctx, opts)
 }
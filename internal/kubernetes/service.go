@@ -9,10 +9,10 @@ import (
 
 // ServiceLister defines the method to list Services
 type ServiceLister interface {
-	ListServices(ctx context.Context, namespace string) (*v1.ServiceList, error)
+	ListServices(ctx context.Context, namespace string, opts metav1.ListOptions) (*v1.ServiceList, error)
 }
 
-// ListServices lists all Services in the specified namespace
-func (c *Client) ListServices(ctx context.Context, namespace string) (*v1.ServiceList, error) {
-	return c.Clientset.CoreV1().Services(namespace).List(ctx, metav1.ListOptions{})
+// ListServices lists all Services in the specified namespace matching the list options
+func (c *Client) ListServices(ctx context.Context, namespace string, opts metav1.ListOptions) (*v1.ServiceList, error) {
+	return c.Clientset.CoreV1().Services(namespace).List(ctx, opts)
 }
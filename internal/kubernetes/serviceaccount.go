@@ -9,10 +9,10 @@ import (
 
 // ServiceAccountLister defines the method to list ServiceAccounts
 type ServiceAccountLister interface {
-	ListServiceAccounts(ctx context.Context, namespace string) (*v1.ServiceAccountList, error)
+	ListServiceAccounts(ctx context.Context, namespace string, opts metav1.ListOptions) (*v1.ServiceAccountList, error)
 }
 
-// ListServiceAccounts lists all ServiceAccounts in the specified namespace
-func (c *Client) ListServiceAccounts(ctx context.Context, namespace string) (*v1.ServiceAccountList, error) {
-	return c.Clientset.CoreV1().ServiceAccounts(namespace).List(ctx, metav1.ListOptions{})
+// ListServiceAccounts lists all ServiceAccounts in the specified namespace matching the list options
+func (c *Client) ListServiceAccounts(ctx context.Context, namespace string, opts metav1.ListOptions) (*v1.ServiceAccountList, error) {
+	return c.Clientset.CoreV1().ServiceAccounts(namespace).List(ctx, opts)
 }
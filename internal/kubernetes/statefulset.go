@@ -9,10 +9,10 @@ import (
 
 // StatefulSetLister defines the method to list StatefulSets
 type StatefulSetLister interface {
-	ListStatefulSets(ctx context.Context, namespace string) (*appsv1.StatefulSetList, error)
+	ListStatefulSets(ctx context.Context, namespace string, opts metav1.ListOptions) (*appsv1.StatefulSetList, error)
 }
 
-// ListStatefulSets lists all StatefulSets in the specified namespace
-func (c *Client) ListStatefulSets(ctx context.Context, namespace string) (*appsv1.StatefulSetList, error) {
-	return c.Clientset.AppsV1().StatefulSets(namespace).List(ctx, metav1.ListOptions{})
+// ListStatefulSets lists all StatefulSets in the specified namespace matching the list options
+func (c *Client) ListStatefulSets(ctx context.Context, namespace string, opts metav1.ListOptions) (*appsv1.StatefulSetList, error) {
+	return c.Clientset.AppsV1().StatefulSets(namespace).List(ctx, opts)
 }
@@ -0,0 +1,130 @@
+package manifest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Filename is the name of the manifest file at the root of a backup
+const Filename = "manifest.json"
+
+// Manifest records the contents of a backup, so that a backup can be checked for missing,
+// modified or unexpected files before it is relied upon.
+type Manifest struct {
+	CreatedAt   time.Time `json:"createdAt"`
+	ToolVersion string    `json:"toolVersion"`
+	Cluster     Cluster   `json:"cluster"`
+	Files       []File    `json:"files"`
+}
+
+// Cluster describes the cluster a backup was taken from
+type Cluster struct {
+	Server        string `json:"server,omitempty"`
+	Context       string `json:"context,omitempty"`
+	ServerVersion string `json:"serverVersion,omitempty"`
+}
+
+// File describes a single resource file of a backup. Path is relative to the root of the backup
+// and always uses forward slashes.
+type File struct {
+	Path      string `json:"path"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+	SHA256    string `json:"sha256"`
+	Size      int64  `json:"size"`
+}
+
+// NewFile describes the resource file at path with the given content
+func NewFile(path, kind, namespace, name string, data []byte) File {
+	sum := sha256.Sum256(data)
+	return File{
+		Path:      filepath.ToSlash(path),
+		Kind:      kind,
+		Namespace: namespace,
+		Name:      name,
+		SHA256:    hex.EncodeToString(sum[:]),
+		Size:      int64(len(data)),
+	}
+}
+
+// Marshal encodes the manifest as indented JSON, with files sorted by path
+func (m *Manifest) Marshal() ([]byte, error) {
+	sort.Slice(m.Files, func(i, j int) bool {
+		return m.Files[i].Path < m.Files[j].Path
+	})
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// Parse decodes a manifest
+func Parse(data []byte) (*Manifest, error) {
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("error parsing manifest: %v", err)
+	}
+	return &m, nil
+}
+
+// Verifier checks the files of a backup against a manifest as they are read, so that backups can
+// be verified from any source, such as a directory or an archive
+type Verifier struct {
+	files    map[string]File
+	seen     map[string]bool
+	problems []error
+}
+
+// NewVerifier returns a Verifier for the files listed in the manifest
+func (m *Manifest) NewVerifier() *Verifier {
+	files := make(map[string]File, len(m.Files))
+	for _, file := range m.Files {
+		files[file.Path] = file
+	}
+	return &Verifier{files: files, seen: make(map[string]bool, len(m.Files))}
+}
+
+// Check verifies the content of the backup file at path, relative to the root of the backup
+func (v *Verifier) Check(path string, content io.Reader) {
+	path = filepath.ToSlash(path)
+	file, ok := v.files[path]
+	if !ok {
+		v.problems = append(v.problems, fmt.Errorf("%s: not listed in the manifest", path))
+		return
+	}
+	v.seen[path] = true
+
+	hash := sha256.New()
+	size, err := io.Copy(hash, content)
+	if err != nil {
+		v.problems = append(v.problems, fmt.Errorf("%s: %v", path, err))
+		return
+	}
+	if size != file.Size {
+		v.problems = append(v.problems, fmt.Errorf("%s: size is %d bytes, expected %d", path, size, file.Size))
+		return
+	}
+	if sum := hex.EncodeToString(hash.Sum(nil)); sum != file.SHA256 {
+		v.problems = append(v.problems, fmt.Errorf("%s: checksum is %s, expected %s", path, sum, file.SHA256))
+	}
+}
+
+// Problems returns the problems found by Check, followed by an error for every listed file that was never checked
+func (v *Verifier) Problems() []error {
+	problems := v.problems
+	var missing []string
+	for path := range v.files {
+		if !v.seen[path] {
+			missing = append(missing, path)
+		}
+	}
+	sort.Strings(missing)
+	for _, path := range missing {
+		problems = append(problems, fmt.Errorf("%s: missing", path))
+	}
+	return problems
+}
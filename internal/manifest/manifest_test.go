@@ -0,0 +1,62 @@
+package manifest
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestVerifier(t *testing.T) {
+	m := &Manifest{Files: []File{
+		NewFile("default/configmaps/a.json", "ConfigMap", "default", "a", []byte("{}")),
+		NewFile("default/configmaps/b.json", "ConfigMap", "default", "b", []byte("{}")),
+	}}
+
+	tests := []struct {
+		name     string
+		files    map[string]string
+		expected []string
+	}{
+		{
+			name:  "Intact backup",
+			files: map[string]string{"default/configmaps/a.json": "{}", "default/configmaps/b.json": "{}"},
+		},
+		{
+			name:     "Missing file",
+			files:    map[string]string{"default/configmaps/b.json": "{}"},
+			expected: []string{"default/configmaps/a.json: missing"},
+		},
+		{
+			name:     "Truncated file",
+			files:    map[string]string{"default/configmaps/a.json": "{", "default/configmaps/b.json": "{}"},
+			expected: []string{"default/configmaps/a.json: size is 1 bytes, expected 2"},
+		},
+		{
+			name:     "Modified file",
+			files:    map[string]string{"default/configmaps/a.json": "{}", "default/configmaps/b.json": "[]"},
+			expected: []string{"default/configmaps/b.json: checksum is"},
+		},
+		{
+			name:     "Unexpected file",
+			files:    map[string]string{"default/configmaps/a.json": "{}", "default/configmaps/b.json": "{}", "default/configmaps/c.json": "{}"},
+			expected: []string{"default/configmaps/c.json: not listed in the manifest"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verifier := m.NewVerifier()
+			for path, content := range tt.files {
+				verifier.Check(path, strings.NewReader(content))
+			}
+			problems := verifier.Problems()
+			if len(problems) != len(tt.expected) {
+				t.Fatalf("Problems() = %v; want %d problems", problems, len(tt.expected))
+			}
+			for i, problem := range problems {
+				if !strings.HasPrefix(problem.Error(), tt.expected[i]) {
+					t.Errorf("Problems() problem = %q; want prefix %q", problem, tt.expected[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,80 @@
+package manifest
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// SignatureFilename is the name of the file holding the signature of the manifest at the root of a backup
+const SignatureFilename = Filename + ".sig"
+
+// Sign signs the encoded manifest with key and returns the base64-encoded signature
+func Sign(data []byte, key ed25519.PrivateKey) []byte {
+	signature := ed25519.Sign(key, data)
+	return []byte(base64.StdEncoding.EncodeToString(signature) + "\n")
+}
+
+// VerifySignature checks a base64-encoded signature, as returned by Sign, of the encoded manifest against key
+func VerifySignature(data, signature []byte, key ed25519.PublicKey) error {
+	decoded, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(signature)))
+	if err != nil {
+		return fmt.Errorf("error decoding manifest signature: %v", err)
+	}
+	if !ed25519.Verify(key, data, decoded) {
+		return fmt.Errorf("manifest signature does not match the verification key")
+	}
+	return nil
+}
+
+// LoadPrivateKey reads a PEM-encoded PKCS #8 ed25519 private key, as written by
+// "openssl genpkey -algorithm ed25519"
+func LoadPrivateKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing private key %s: %v", path, err)
+	}
+	privateKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("private key %s is a %T, not an ed25519 key", path, key)
+	}
+	return privateKey, nil
+}
+
+// LoadPublicKey reads a PEM-encoded PKIX ed25519 public key, as written by "openssl pkey -pubout"
+func LoadPublicKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing public key %s: %v", path, err)
+	}
+	publicKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("public key %s is a %T, not an ed25519 key", path, key)
+	}
+	return publicKey, nil
+}
+
+// readPEM reads the first PEM block of a file
+func readPEM(path string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading key: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("key %s is not PEM-encoded", path)
+	}
+	return block, nil
+}
@@ -0,0 +1,60 @@
+package manifest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeKeys generates an ed25519 key pair and writes it to PEM files in dir, returning their paths.
+func writeKeys(t *testing.T, dir string) (string, string) {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	privateDER, err := x509.MarshalPKCS8PrivateKey(privateKey)
+	if err != nil {
+		t.Fatalf("failed to marshal private key: %v", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+
+	privatePath := filepath.Join(dir, "signing.pem")
+	publicPath := filepath.Join(dir, "verify.pem")
+	if err := os.WriteFile(privatePath, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDER}), 0600); err != nil {
+		t.Fatalf("failed to write private key: %v", err)
+	}
+	if err := os.WriteFile(publicPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0600); err != nil {
+		t.Fatalf("failed to write public key: %v", err)
+	}
+	return privatePath, publicPath
+}
+
+func TestLoadKeyErrors(t *testing.T) {
+	dir := t.TempDir()
+	privatePath, publicPath := writeKeys(t, dir)
+	notPEM := filepath.Join(dir, "key.txt")
+	if err := os.WriteFile(notPEM, []byte("not a key"), 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+
+	if _, err := LoadPrivateKey(publicPath); err == nil {
+		t.Error("LoadPrivateKey() of a public key: expected an error")
+	}
+	if _, err := LoadPublicKey(privatePath); err == nil {
+		t.Error("LoadPublicKey() of a private key: expected an error")
+	}
+	if _, err := LoadPrivateKey(notPEM); err == nil {
+		t.Error("LoadPrivateKey() of a non-PEM file: expected an error")
+	}
+	if _, err := LoadPublicKey(filepath.Join(dir, "missing.pem")); err == nil {
+		t.Error("LoadPublicKey() of a missing file: expected an error")
+	}
+}
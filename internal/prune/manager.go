@@ -0,0 +1,135 @@
+package prune
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/chaoscypher/kube-save-restore/internal/storage"
+)
+
+// NamePrefix is the prefix of the names of backups created by default, which are followed by the time of
+// the backup in TimeLayout
+const NamePrefix = "k8s-backup-"
+
+// TimeLayout is the layout of the time in the names of backups
+const TimeLayout = "20060102-150405"
+
+// Logger is the logging interface used by the prune Manager
+type Logger interface {
+	Debugf(format string, v ...interface{})
+	Info(v ...interface{})
+	Infof(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+}
+
+// Manager deletes the backups that a retention policy no longer keeps.
+type Manager struct {
+	storage storage.Storage
+	policy  Policy
+	dryRun  bool
+	logger  Logger
+	now     func() time.Time
+}
+
+// NewManager creates a new prune Manager. If dryRun is true, no backups are deleted.
+func NewManager(s storage.Storage, policy Policy, dryRun bool, logger Logger) *Manager {
+	return &Manager{
+		storage: s,
+		policy:  policy,
+		dryRun:  dryRun,
+		logger:  logger,
+		now:     time.Now,
+	}
+}
+
+// PerformPrune applies the retention policy to the backups directly below dir and deletes those it does
+// not keep. Only backups named after their creation time, such as "k8s-backup-20240101-120000" or
+// "k8s-backup-20240101-120000.tar.gz", are considered; all other files are left alone.
+func (m *Manager) PerformPrune(ctx context.Context, dir string) error {
+	if m.policy.IsZero() {
+		return fmt.Errorf("refusing to prune without a retention policy: it would delete every backup")
+	}
+	m.logger.Infof("Starting prune of backups in %s", m.storage.Location(dir))
+
+	backups, err := m.findBackups(ctx, dir)
+	if err != nil {
+		return err
+	}
+	if m.dryRun {
+		m.logger.Info("Dry run mode: No backups will be deleted")
+	}
+
+	var deleted, failed int
+	for _, decision := range m.policy.Apply(backups, m.now()) {
+		if decision.Keep() {
+			m.logger.Debugf("Keeping %s (%s)", decision.Name, strings.Join(decision.Reasons, ", "))
+			continue
+		}
+		name := path.Join(dir, decision.Name)
+		if m.dryRun {
+			m.logger.Infof("Dry run: would delete %s", m.storage.Location(name))
+			deleted++
+			continue
+		}
+		m.logger.Infof("Deleting %s", m.storage.Location(name))
+		if err := m.delete(ctx, name); err != nil {
+			m.logger.Errorf("Error deleting %s: %v", m.storage.Location(name), err)
+			failed++
+			continue
+		}
+		deleted++
+	}
+
+	kept := len(backups) - deleted - failed
+	if m.dryRun {
+		m.logger.Infof("Dry run completed. %d backups would be deleted and %d kept", deleted, kept)
+	} else {
+		m.logger.Infof("Prune completed. %d backups deleted and %d kept", deleted, kept)
+	}
+	if failed > 0 {
+		return fmt.Errorf("failed to delete %d backups", failed)
+	}
+	return nil
+}
+
+// findBackups returns the backups directly below dir
+func (m *Manager) findBackups(ctx context.Context, dir string) ([]Backup, error) {
+	names, err := m.storage.ReadDir(ctx, dir)
+	if err != nil {
+		return nil, fmt.Errorf("error listing backups: %v", err)
+	}
+	var backups []Backup
+	for _, name := range names {
+		backupTime, ok := parseName(name)
+		if !ok {
+			m.logger.Debugf("Ignoring %s: not a backup", name)
+			continue
+		}
+		backups = append(backups, Backup{Name: name, Time: backupTime})
+	}
+	return backups, nil
+}
+
+// delete removes a backup directory or archive
+func (m *Manager) delete(ctx context.Context, name string) error {
+	if storage.IsArchive(name) {
+		return m.storage.Delete(ctx, name)
+	}
+	return m.storage.DeleteAll(ctx, name)
+}
+
+// parseName returns the creation time of the backup named name, and whether name is the name of a backup
+func parseName(name string) (time.Time, bool) {
+	timestamp, ok := strings.CutPrefix(name, NamePrefix)
+	if !ok {
+		return time.Time{}, false
+	}
+	for _, suffix := range storage.ArchiveSuffixes {
+		timestamp = strings.TrimSuffix(timestamp, suffix)
+	}
+	backupTime, err := time.ParseInLocation(TimeLayout, timestamp, time.Local)
+	return backupTime, err == nil
+}
@@ -0,0 +1,137 @@
+package prune
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/chaoscypher/kube-save-restore/internal/storage"
+)
+
+// testLogger writes log messages to the test log
+type testLogger struct {
+	t *testing.T
+}
+
+func (l testLogger) Debugf(format string, v ...interface{}) { l.t.Logf(format, v...) }
+func (l testLogger) Info(v ...interface{})                  { l.t.Log(v...) }
+func (l testLogger) Infof(format string, v ...interface{})  { l.t.Logf(format, v...) }
+func (l testLogger) Errorf(format string, v ...interface{}) { l.t.Logf(format, v...) }
+
+// setupBackups creates backup directories, archives and unrelated files in a new directory
+func setupBackups(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	for _, name := range []string{"k8s-backup-20240108-060000", "k8s-backup-20240109-060000", "k8s-backup-20240110-060000"} {
+		if err := os.MkdirAll(filepath.Join(dir, name, "namespaces"), 0755); err != nil {
+			t.Fatalf("failed to create backup: %v", err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name, "namespaces", "default.json"), []byte("{}"), 0600); err != nil {
+			t.Fatalf("failed to create backup: %v", err)
+		}
+	}
+	for _, name := range []string{"k8s-backup-20240106-060000.tgz", "k8s-backup-20240107-060000.tar.gz", "k8s-backup-20240111-060000.tar.gz.partial", "notes.txt"} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("data"), 0600); err != nil {
+			t.Fatalf("failed to create file: %v", err)
+		}
+	}
+	return dir
+}
+
+// entries returns the sorted names in dir
+func entries(t *testing.T, dir string) []string {
+	t.Helper()
+	list, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to read directory: %v", err)
+	}
+	var names []string
+	for _, entry := range list {
+		names = append(names, entry.Name())
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestPerformPrune(t *testing.T) {
+	tests := []struct {
+		name     string
+		dryRun   bool
+		expected []string
+	}{
+		{
+			name:   "Dry run",
+			dryRun: true,
+			expected: []string{
+				"k8s-backup-20240106-060000.tgz",
+				"k8s-backup-20240107-060000.tar.gz",
+				"k8s-backup-20240108-060000",
+				"k8s-backup-20240109-060000",
+				"k8s-backup-20240110-060000",
+				"k8s-backup-20240111-060000.tar.gz.partial",
+				"notes.txt",
+			},
+		},
+		{
+			name: "Prune",
+			expected: []string{
+				"k8s-backup-20240109-060000",
+				"k8s-backup-20240110-060000",
+				"k8s-backup-20240111-060000.tar.gz.partial",
+				"notes.txt",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := setupBackups(t)
+			manager := NewManager(storage.NewLocal(dir), Policy{KeepLast: 2}, tt.dryRun, testLogger{t})
+
+			if err := manager.PerformPrune(context.Background(), ""); err != nil {
+				t.Fatalf("PerformPrune() error = %v", err)
+			}
+			names := entries(t, dir)
+			if len(names) != len(tt.expected) {
+				t.Fatalf("remaining entries = %v; want %v", names, tt.expected)
+			}
+			for i := range names {
+				if names[i] != tt.expected[i] {
+					t.Errorf("remaining entries = %v; want %v", names, tt.expected)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestPerformPruneKeepWithin(t *testing.T) {
+	dir := setupBackups(t)
+	manager := NewManager(storage.NewLocal(dir), Policy{KeepWithin: 36 * time.Hour}, false, testLogger{t})
+	manager.now = func() time.Time { return time.Date(2024, 1, 10, 12, 0, 0, 0, time.Local) }
+
+	if err := manager.PerformPrune(context.Background(), ""); err != nil {
+		t.Fatalf("PerformPrune() error = %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "k8s-backup-20240108-060000")); !os.IsNotExist(err) {
+		t.Error("expected k8s-backup-20240108-060000 to be deleted")
+	}
+	if _, err := os.Stat(filepath.Join(dir, "k8s-backup-20240109-060000")); err != nil {
+		t.Errorf("expected k8s-backup-20240109-060000 to be kept: %v", err)
+	}
+}
+
+func TestPerformPruneWithoutPolicy(t *testing.T) {
+	dir := setupBackups(t)
+	manager := NewManager(storage.NewLocal(dir), Policy{}, false, testLogger{t})
+
+	if err := manager.PerformPrune(context.Background(), ""); err == nil {
+		t.Fatal("PerformPrune() error = nil; want an error without a retention policy")
+	}
+	if names := entries(t, dir); len(names) != 7 {
+		t.Errorf("remaining entries = %v; want every backup kept", names)
+	}
+}
@@ -0,0 +1,102 @@
+package prune
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy decides which backups are kept. A backup is kept when any of the rules keeps it.
+type Policy struct {
+	// KeepLast keeps the most recent backups
+	KeepLast int
+	// KeepDaily keeps the most recent backup of each of the last days that have backups
+	KeepDaily int
+	// KeepWeekly keeps the most recent backup of each of the last ISO weeks that have backups
+	KeepWeekly int
+	// KeepWithin keeps all backups taken within this duration before now
+	KeepWithin time.Duration
+}
+
+// IsZero reports whether the policy has no rules, in which case it would keep no backups at all
+func (p Policy) IsZero() bool {
+	return p == Policy{}
+}
+
+// Backup is a backup found under the pruned directory
+type Backup struct {
+	// Name of the backup directory or archive
+	Name string
+	// Time the backup was taken
+	Time time.Time
+}
+
+// Decision records whether a backup is kept, and the rules that keep it
+type Decision struct {
+	Backup
+	Reasons []string
+}
+
+// Keep reports whether the backup is kept
+func (d Decision) Keep() bool {
+	return len(d.Reasons) > 0
+}
+
+// Apply evaluates the policy against backups at time now. It returns a decision for every backup,
+// ordered from the most recent to the oldest.
+func (p Policy) Apply(backups []Backup, now time.Time) []Decision {
+	decisions := make([]Decision, len(backups))
+	for i, backup := range backups {
+		decisions[i] = Decision{Backup: backup}
+	}
+	sort.SliceStable(decisions, func(i, j int) bool {
+		return decisions[i].Time.After(decisions[j].Time)
+	})
+
+	days := make(map[string]bool)
+	weeks := make(map[string]bool)
+	for i := range decisions {
+		decision := &decisions[i]
+		if i < p.KeepLast {
+			decision.Reasons = append(decision.Reasons, "last")
+		}
+		// Backups are ordered newest first, so the first backup seen for a day or week is its most recent
+		if day := decision.Time.Format("2006-01-02"); !days[day] && len(days) < p.KeepDaily {
+			days[day] = true
+			decision.Reasons = append(decision.Reasons, "daily")
+		}
+		year, week := decision.Time.ISOWeek()
+		if key := fmt.Sprintf("%d-W%02d", year, week); !weeks[key] && len(weeks) < p.KeepWeekly {
+			weeks[key] = true
+			decision.Reasons = append(decision.Reasons, "weekly")
+		}
+		if p.KeepWithin > 0 && now.Sub(decision.Time) <= p.KeepWithin {
+			decision.Reasons = append(decision.Reasons, "within")
+		}
+	}
+	return decisions
+}
+
+// ParseDuration parses a duration for KeepWithin. Besides the units of time.ParseDuration, it accepts
+// days and weeks as a whole number followed by "d" or "w", such as "30d" or "4w".
+func ParseDuration(value string) (time.Duration, error) {
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if number, ok := strings.CutSuffix(value, suffix); ok {
+			n, err := strconv.Atoi(number)
+			if err != nil || n < 0 {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			return time.Duration(n) * unit, nil
+		}
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, err
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("invalid duration %q: must not be negative", value)
+	}
+	return duration, nil
+}
@@ -0,0 +1,103 @@
+package prune
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// at returns a backup taken at the given time
+func at(value string) Backup {
+	backupTime, err := time.Parse("2006-01-02 15:04", value)
+	if err != nil {
+		panic(err)
+	}
+	return Backup{Name: value, Time: backupTime}
+}
+
+func TestPolicyApply(t *testing.T) {
+	// Wednesday 2024-01-10 and the days before it, with two backups on some days
+	backups := []Backup{
+		at("2024-01-01 12:00"), // Monday, week 1
+		at("2024-01-05 08:00"), // Friday, week 1
+		at("2024-01-05 20:00"),
+		at("2024-01-08 06:00"), // Monday, week 2
+		at("2024-01-09 06:00"),
+		at("2024-01-10 06:00"),
+		at("2024-01-10 18:00"),
+	}
+	now := at("2024-01-10 20:00").Time
+
+	tests := []struct {
+		name     string
+		policy   Policy
+		expected []string
+	}{
+		{
+			name:     "Keep last",
+			policy:   Policy{KeepLast: 2},
+			expected: []string{"2024-01-10 18:00", "2024-01-10 06:00"},
+		},
+		{
+			name:     "Keep daily",
+			policy:   Policy{KeepDaily: 3},
+			expected: []string{"2024-01-10 18:00", "2024-01-09 06:00", "2024-01-08 06:00"},
+		},
+		{
+			name:     "Keep weekly",
+			policy:   Policy{KeepWeekly: 2},
+			expected: []string{"2024-01-10 18:00", "2024-01-05 20:00"},
+		},
+		{
+			name:     "Keep within",
+			policy:   Policy{KeepWithin: 48 * time.Hour},
+			expected: []string{"2024-01-10 18:00", "2024-01-10 06:00", "2024-01-09 06:00"},
+		},
+		{
+			name:     "Combined rules",
+			policy:   Policy{KeepLast: 1, KeepWeekly: 3},
+			expected: []string{"2024-01-10 18:00", "2024-01-05 20:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var kept []string
+			for _, decision := range tt.policy.Apply(backups, now) {
+				if decision.Keep() {
+					kept = append(kept, decision.Name)
+				}
+			}
+			if !reflect.DeepEqual(kept, tt.expected) {
+				t.Errorf("Apply() kept %v; want %v", kept, tt.expected)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		value     string
+		expected  time.Duration
+		expectErr bool
+	}{
+		{value: "36h", expected: 36 * time.Hour},
+		{value: "30d", expected: 30 * 24 * time.Hour},
+		{value: "2w", expected: 14 * 24 * time.Hour},
+		{value: "1.5d", expectErr: true},
+		{value: "-1h", expectErr: true},
+		{value: "soon", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			duration, err := ParseDuration(tt.value)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("ParseDuration() error = %v; expectErr %v", err, tt.expectErr)
+			}
+			if duration != tt.expected {
+				t.Errorf("ParseDuration() = %v; want %v", duration, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,307 @@
+package redact
+
+import (
+	"encoding/base64"
+	"sort"
+	"strings"
+
+	"github.com/chaoscypher/kube-save-restore/internal/filter"
+)
+
+// Annotation marks a resource whose sensitive values have been replaced with Placeholder
+const Annotation = "kube-save-restore.io/redacted"
+
+// Placeholder replaces redacted values. Secret data holds it base64-encoded.
+const Placeholder = "REDACTED"
+
+// lastAppliedAnnotation holds the configuration last applied by kubectl, which repeats the redacted values
+const lastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// DefaultPatterns are the glob patterns of ConfigMap keys and environment variable names redacted by default
+var DefaultPatterns = []string{"*password*", "*passwd*", "*secret*", "*token*", "*credential*", "*apikey*", "*api_key*", "*private_key*"}
+
+// containerFields lists the fields of a pod spec holding containers
+var containerFields = map[string]bool{
+	"containers":          true,
+	"initContainers":      true,
+	"ephemeralContainers": true,
+}
+
+// Redactor replaces sensitive values in resource manifests with placeholders, so that backups can be
+// shared without disclosing credentials. Every value of a Secret is redacted, along with ConfigMap keys
+// and container environment variables whose names match the patterns.
+type Redactor struct {
+	patterns *filter.Filter
+}
+
+// New creates a Redactor for ConfigMap keys and environment variable names matching the glob patterns.
+// Names are matched case-insensitively; patterns must be lowercase.
+func New(patterns []string) (*Redactor, error) {
+	r := &Redactor{}
+	if len(patterns) > 0 {
+		f, err := filter.New(patterns, nil)
+		if err != nil {
+			return nil, err
+		}
+		r.patterns = f
+	}
+	return r, nil
+}
+
+// Redact replaces the sensitive values of a resource manifest in place. Resources that had any value
+// replaced are marked with Annotation. It reports whether anything was redacted.
+func (r *Redactor) Redact(resource map[string]interface{}) bool {
+	apiVersion, _ := resource["apiVersion"].(string)
+	kind, _ := resource["kind"].(string)
+
+	var redacted bool
+	switch {
+	case apiVersion == "v1" && kind == "Secret":
+		redacted = redactSecret(resource)
+	case apiVersion == "v1" && kind == "ConfigMap":
+		redacted = r.redactConfigMap(resource)
+	default:
+		redacted = r.redactContainers(resource)
+	}
+	if redacted {
+		mark(resource)
+	}
+	return redacted
+}
+
+// IsRedacted reports whether a resource manifest holds placeholders written by a Redactor
+func IsRedacted(resource map[string]interface{}) bool {
+	metadata, _ := resource["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	_, ok := annotations[Annotation]
+	return ok
+}
+
+// Unredact replaces the placeholders of a redacted resource manifest in place with the values of live, the
+// version of the resource in the cluster, so that restoring it keeps the sensitive values the cluster holds.
+// Placeholders without a live value are removed, leaving their fields unset. live is nil if the resource
+// does not exist. Annotation is removed. It returns the fields left unset.
+func Unredact(resource, live map[string]interface{}) []string {
+	apiVersion, _ := resource["apiVersion"].(string)
+	kind, _ := resource["kind"].(string)
+	placeholder := base64.StdEncoding.EncodeToString([]byte(Placeholder))
+
+	var unset []string
+	switch {
+	case apiVersion == "v1" && kind == "Secret":
+		unset = unredactData(resource, live, "data", placeholder)
+		// The API server only returns data, which holds the base64-encoded stringData
+		stringData, _ := resource["stringData"].(map[string]interface{})
+		liveData, _ := live["data"].(map[string]interface{})
+		for key, value := range stringData {
+			if value != Placeholder {
+				continue
+			}
+			delete(stringData, key)
+			if liveValue, ok := liveData[key]; ok {
+				data, _ := resource["data"].(map[string]interface{})
+				if data == nil {
+					data = map[string]interface{}{}
+					resource["data"] = data
+				}
+				data[key] = liveValue
+			} else {
+				unset = append(unset, "stringData."+key)
+			}
+		}
+	case apiVersion == "v1" && kind == "ConfigMap":
+		unset = append(unredactData(resource, live, "data", Placeholder), unredactData(resource, live, "binaryData", placeholder)...)
+	default:
+		unredactContainers(resource, live, &unset)
+	}
+	sort.Strings(unset)
+
+	metadata, _ := resource["metadata"].(map[string]interface{})
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	delete(annotations, Annotation)
+	if len(annotations) == 0 {
+		delete(metadata, "annotations")
+	}
+	return unset
+}
+
+// unredactData replaces the placeholder values of a data field of resource with the values of live
+func unredactData(resource, live map[string]interface{}, field, placeholder string) []string {
+	var unset []string
+	data, _ := resource[field].(map[string]interface{})
+	liveData, _ := live[field].(map[string]interface{})
+	for key, value := range data {
+		if value != placeholder {
+			continue
+		}
+		if liveValue, ok := liveData[key]; ok {
+			data[key] = liveValue
+		} else {
+			delete(data, key)
+			unset = append(unset, field+"."+key)
+		}
+	}
+	return unset
+}
+
+// unredactContainers replaces the placeholder values of environment variables in every container of value
+// with the variables of the container of the same name in live, which is the same part of the live resource
+func unredactContainers(value, live interface{}, unset *[]string) {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		liveMap, _ := live.(map[string]interface{})
+		for field, child := range value {
+			if containers, ok := child.([]interface{}); ok && containerFields[field] {
+				liveContainers, _ := liveMap[field].([]interface{})
+				for _, container := range containers {
+					container, _ := container.(map[string]interface{})
+					name, _ := container["name"].(string)
+					unredactEnv(container, findByName(liveContainers, name), unset)
+				}
+			}
+			unredactContainers(child, liveMap[field], unset)
+		}
+	case []interface{}:
+		liveList, _ := live.([]interface{})
+		for i, child := range value {
+			var liveChild interface{}
+			if i < len(liveList) {
+				liveChild = liveList[i]
+			}
+			unredactContainers(child, liveChild, unset)
+		}
+	}
+}
+
+// unredactEnv replaces the environment variables of a container holding the placeholder with the variables
+// of the same name of the live container, or removes their value if there are none
+func unredactEnv(container, live map[string]interface{}, unset *[]string) {
+	env, _ := container["env"].([]interface{})
+	liveEnv, _ := live["env"].([]interface{})
+	containerName, _ := container["name"].(string)
+	for i, variable := range env {
+		variable, _ := variable.(map[string]interface{})
+		if variable["value"] != Placeholder {
+			continue
+		}
+		name, _ := variable["name"].(string)
+		if liveVariable := findByName(liveEnv, name); liveVariable != nil {
+			env[i] = liveVariable
+		} else {
+			delete(variable, "value")
+			*unset = append(*unset, "env "+name+" of container "+containerName)
+		}
+	}
+}
+
+// findByName returns the element of list whose name is name, or nil if there is none
+func findByName(list []interface{}, name string) map[string]interface{} {
+	for _, element := range list {
+		if element, ok := element.(map[string]interface{}); ok && element["name"] == name {
+			return element
+		}
+	}
+	return nil
+}
+
+// redactSecret replaces every value of a Secret, keeping its keys
+func redactSecret(secret map[string]interface{}) bool {
+	placeholder := base64.StdEncoding.EncodeToString([]byte(Placeholder))
+	data, _ := secret["data"].(map[string]interface{})
+	for key := range data {
+		data[key] = placeholder
+	}
+	stringData, _ := secret["stringData"].(map[string]interface{})
+	for key := range stringData {
+		stringData[key] = Placeholder
+	}
+	return true
+}
+
+// redactConfigMap replaces the values of ConfigMap keys matching the patterns
+func (r *Redactor) redactConfigMap(configMap map[string]interface{}) bool {
+	var redacted bool
+	data, _ := configMap["data"].(map[string]interface{})
+	for key := range data {
+		if r.matches(key) {
+			data[key] = Placeholder
+			redacted = true
+		}
+	}
+	binaryData, _ := configMap["binaryData"].(map[string]interface{})
+	for key := range binaryData {
+		if r.matches(key) {
+			binaryData[key] = base64.StdEncoding.EncodeToString([]byte(Placeholder))
+			redacted = true
+		}
+	}
+	return redacted
+}
+
+// redactContainers replaces the values of environment variables matching the patterns in every container
+// of the resource. Containers are found wherever they appear, so pod templates of any workload,
+// including custom resources, are covered.
+func (r *Redactor) redactContainers(value interface{}) bool {
+	var redacted bool
+	switch value := value.(type) {
+	case map[string]interface{}:
+		for field, child := range value {
+			if containers, ok := child.([]interface{}); ok && containerFields[field] {
+				for _, container := range containers {
+					container, _ := container.(map[string]interface{})
+					if r.redactEnv(container) {
+						redacted = true
+					}
+				}
+			}
+			if r.redactContainers(child) {
+				redacted = true
+			}
+		}
+	case []interface{}:
+		for _, child := range value {
+			if r.redactContainers(child) {
+				redacted = true
+			}
+		}
+	}
+	return redacted
+}
+
+// redactEnv replaces the literal values of a container's environment variables matching the patterns.
+// Variables taken from other resources with valueFrom hold no value and are kept.
+func (r *Redactor) redactEnv(container map[string]interface{}) bool {
+	var redacted bool
+	env, _ := container["env"].([]interface{})
+	for _, variable := range env {
+		variable, _ := variable.(map[string]interface{})
+		name, _ := variable["name"].(string)
+		if _, ok := variable["value"]; ok && r.matches(name) {
+			variable["value"] = Placeholder
+			redacted = true
+		}
+	}
+	return redacted
+}
+
+// matches reports whether a key or variable name matches the patterns
+func (r *Redactor) matches(name string) bool {
+	return r.patterns != nil && r.patterns.Matches(strings.ToLower(name))
+}
+
+// mark annotates a redacted resource and drops the last applied configuration, which repeats the
+// original values
+func mark(resource map[string]interface{}) {
+	metadata, _ := resource["metadata"].(map[string]interface{})
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+		resource["metadata"] = metadata
+	}
+	annotations, _ := metadata["annotations"].(map[string]interface{})
+	if annotations == nil {
+		annotations = map[string]interface{}{}
+		metadata["annotations"] = annotations
+	}
+	delete(annotations, lastAppliedAnnotation)
+	annotations[Annotation] = "true"
+}
@@ -0,0 +1,186 @@
+package redact
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// decode parses a JSON manifest
+func decode(t *testing.T, manifest string) map[string]interface{} {
+	t.Helper()
+	var resource map[string]interface{}
+	if err := json.Unmarshal([]byte(manifest), &resource); err != nil {
+		t.Fatalf("failed to parse manifest: %v", err)
+	}
+	return resource
+}
+
+func TestRedact(t *testing.T) {
+	placeholder := base64.StdEncoding.EncodeToString([]byte(Placeholder))
+
+	tests := []struct {
+		name     string
+		resource string
+		expected string
+		redacted bool
+	}{
+		{
+			name: "Secret",
+			resource: `{"apiVersion": "v1", "kind": "Secret", "type": "kubernetes.io/tls",
+				"metadata": {"name": "tls", "annotations": {"kubectl.kubernetes.io/last-applied-configuration": "{}"}},
+				"data": {"tls.crt": "Y2VydA==", "tls.key": "a2V5"}}`,
+			expected: `{"apiVersion": "v1", "kind": "Secret", "type": "kubernetes.io/tls",
+				"metadata": {"name": "tls", "annotations": {"kube-save-restore.io/redacted": "true"}},
+				"data": {"tls.crt": "` + placeholder + `", "tls.key": "` + placeholder + `"}}`,
+			redacted: true,
+		},
+		{
+			name: "ConfigMap with matching keys",
+			resource: `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "app"},
+				"data": {"DB_PASSWORD": "hunter2", "log_level": "debug"}, "binaryData": {"auth-token": "dG9rZW4="}}`,
+			expected: `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "app", "annotations": {"kube-save-restore.io/redacted": "true"}},
+				"data": {"DB_PASSWORD": "REDACTED", "log_level": "debug"}, "binaryData": {"auth-token": "` + placeholder + `"}}`,
+			redacted: true,
+		},
+		{
+			name:     "ConfigMap without matching keys",
+			resource: `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "app"}, "data": {"log_level": "debug"}}`,
+			expected: `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "app"}, "data": {"log_level": "debug"}}`,
+		},
+		{
+			name: "CronJob environment variables",
+			resource: `{"apiVersion": "batch/v1", "kind": "CronJob", "metadata": {"name": "report"},
+				"spec": {"jobTemplate": {"spec": {"template": {"spec": {
+					"initContainers": [{"name": "init", "env": [{"name": "API_TOKEN", "value": "abc"}]}],
+					"containers": [{"name": "report", "env": [
+						{"name": "SMTP_PASSWORD", "value": "hunter2"},
+						{"name": "DB_PASSWORD", "valueFrom": {"secretKeyRef": {"name": "db", "key": "password"}}},
+						{"name": "REGION", "value": "eu-west-1"}
+					]}]
+				}}}}}}`,
+			expected: `{"apiVersion": "batch/v1", "kind": "CronJob", "metadata": {"name": "report", "annotations": {"kube-save-restore.io/redacted": "true"}},
+				"spec": {"jobTemplate": {"spec": {"template": {"spec": {
+					"initContainers": [{"name": "init", "env": [{"name": "API_TOKEN", "value": "REDACTED"}]}],
+					"containers": [{"name": "report", "env": [
+						{"name": "SMTP_PASSWORD", "value": "REDACTED"},
+						{"name": "DB_PASSWORD", "valueFrom": {"secretKeyRef": {"name": "db", "key": "password"}}},
+						{"name": "REGION", "value": "eu-west-1"}
+					]}]
+				}}}}}}`,
+			redacted: true,
+		},
+	}
+
+	redactor, err := New(DefaultPatterns)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := decode(t, tt.resource)
+			if redacted := redactor.Redact(resource); redacted != tt.redacted {
+				t.Errorf("Redact() = %v; want %v", redacted, tt.redacted)
+			}
+			if IsRedacted(resource) != tt.redacted {
+				t.Errorf("IsRedacted() = %v; want %v", IsRedacted(resource), tt.redacted)
+			}
+			if expected := decode(t, tt.expected); !reflect.DeepEqual(resource, expected) {
+				t.Errorf("Redact() resource = %v; want %v", resource, expected)
+			}
+		})
+	}
+}
+
+func TestRedactWithoutPatterns(t *testing.T) {
+	redactor, err := New(nil)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	configMap := decode(t, `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "app"}, "data": {"password": "hunter2"}}`)
+	if redactor.Redact(configMap) {
+		t.Error("Redact() = true; want ConfigMaps to be kept without patterns")
+	}
+	secret := decode(t, `{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "db"}, "data": {"password": "aHVudGVyMg=="}}`)
+	if !redactor.Redact(secret) {
+		t.Error("Redact() = false; want Secrets to be redacted without patterns")
+	}
+}
+
+func TestNewInvalidPattern(t *testing.T) {
+	if _, err := New([]string{"[password"}); err == nil {
+		t.Error("New() error = nil; want an error for a malformed pattern")
+	}
+}
+
+func TestUnredact(t *testing.T) {
+	placeholder := base64.StdEncoding.EncodeToString([]byte(Placeholder))
+
+	tests := []struct {
+		name          string
+		resource      string
+		live          string
+		expected      string
+		expectedUnset []string
+	}{
+		{
+			name: "Secret with live values",
+			resource: `{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "tls", "annotations": {"kube-save-restore.io/redacted": "true"}},
+				"data": {"tls.crt": "` + placeholder + `", "tls.key": "` + placeholder + `"}}`,
+			live:     `{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "tls"}, "data": {"tls.crt": "Y2VydA==", "tls.key": "a2V5"}}`,
+			expected: `{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "tls"}, "data": {"tls.crt": "Y2VydA==", "tls.key": "a2V5"}}`,
+		},
+		{
+			name: "Secret that does not exist",
+			resource: `{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "tls", "annotations": {"kube-save-restore.io/redacted": "true", "team": "web"}},
+				"data": {"tls.crt": "` + placeholder + `"}}`,
+			expected:      `{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "tls", "annotations": {"team": "web"}}, "data": {}}`,
+			expectedUnset: []string{"data.tls.crt"},
+		},
+		{
+			name: "ConfigMap",
+			resource: `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "app", "annotations": {"kube-save-restore.io/redacted": "true"}},
+				"data": {"DB_PASSWORD": "REDACTED", "API_TOKEN": "REDACTED", "log_level": "debug"}}`,
+			live:          `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "app"}, "data": {"DB_PASSWORD": "hunter2", "log_level": "info"}}`,
+			expected:      `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "app"}, "data": {"DB_PASSWORD": "hunter2", "log_level": "debug"}}`,
+			expectedUnset: []string{"data.API_TOKEN"},
+		},
+		{
+			name: "Deployment environment variables",
+			resource: `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "annotations": {"kube-save-restore.io/redacted": "true"}},
+				"spec": {"template": {"spec": {"containers": [{"name": "web", "env": [
+					{"name": "SMTP_PASSWORD", "value": "REDACTED"}, {"name": "API_TOKEN", "value": "REDACTED"}, {"name": "REGION", "value": "eu-west-1"}
+				]}]}}}}`,
+			live: `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web"},
+				"spec": {"template": {"spec": {"containers": [{"name": "web", "env": [
+					{"name": "SMTP_PASSWORD", "value": "hunter2"}, {"name": "REGION", "value": "us-east-1"}
+				]}]}}}}`,
+			expected: `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web"},
+				"spec": {"template": {"spec": {"containers": [{"name": "web", "env": [
+					{"name": "SMTP_PASSWORD", "value": "hunter2"}, {"name": "API_TOKEN"}, {"name": "REGION", "value": "eu-west-1"}
+				]}]}}}}`,
+			expectedUnset: []string{"env API_TOKEN of container web"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := decode(t, tt.resource)
+			var live map[string]interface{}
+			if tt.live != "" {
+				live = decode(t, tt.live)
+			}
+			unset := Unredact(resource, live)
+			if !reflect.DeepEqual(unset, tt.expectedUnset) {
+				t.Errorf("Unredact() = %v; want %v", unset, tt.expectedUnset)
+			}
+			if IsRedacted(resource) {
+				t.Error("IsRedacted() = true; want the annotation removed")
+			}
+			if expected := decode(t, tt.expected); !reflect.DeepEqual(resource, expected) {
+				t.Errorf("Unredact() resource = %v; want %v", resource, expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package restore
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+
+	"github.com/chaoscypher/kube-save-restore/internal/manifest"
+	"github.com/chaoscypher/kube-save-restore/internal/storage"
+	"github.com/klauspost/compress/zstd"
+)
+
+// readArchive reads the resource files of a compressed tar archive in s, along with its manifest and
+// signature if present, without extracting it to disk.
+func readArchive(ctx context.Context, s storage.Storage, archivePath string) ([]backupEntry, error) {
+	file, err := s.Open(ctx, archivePath)
+	if err != nil {
+		return nil, fmt.Errorf("error opening archive: %v", err)
+	}
+	defer file.Close()
+
+	var decompressed io.Reader
+	if strings.HasSuffix(archivePath, ".tar.zst") {
+		decoder, err := zstd.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading archive: %v", err)
+		}
+		defer decoder.Close()
+		decompressed = decoder
+	} else {
+		reader, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("error reading archive: %v", err)
+		}
+		defer reader.Close()
+		decompressed = reader
+	}
+
+	var entries []backupEntry
+	archive := tar.NewReader(decompressed)
+	for {
+		header, err := archive.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading archive: %v", err)
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if header.Name != manifest.SignatureFilename && !resourceFileExtensions[path.Ext(header.Name)] {
+			continue
+		}
+
+		data, err := io.ReadAll(archive)
+		if err != nil {
+			return nil, fmt.Errorf("error reading archive entry %s: %v", header.Name, err)
+		}
+		entries = append(entries, backupEntry{name: path.Clean(header.Name), data: data})
+	}
+
+	return entries, nil
+}
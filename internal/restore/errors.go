@@ -0,0 +1,92 @@
+package restore
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ResourceError is the error of a resource that failed to restore.
+type ResourceError struct {
+	File      string
+	Kind      string
+	Namespace string
+	Name      string
+	Err       error
+}
+
+// newResourceError returns the error of the resource read from file
+func newResourceError(file resourceFile, err error) *ResourceError {
+	e := &ResourceError{File: file.path, Kind: file.kind, Err: err}
+	if file.resource != nil {
+		e.Name, e.Namespace, _ = getResourceIdentifiers(file.resource)
+	}
+	return e
+}
+
+func (e *ResourceError) Error() string {
+	if e.Kind == "" {
+		return fmt.Sprintf("%s: %v", e.File, e.Err)
+	}
+	return fmt.Sprintf("%s (%s %s/%s): %v", e.File, e.Kind, e.Namespace, e.Name, e.Err)
+}
+
+func (e *ResourceError) Unwrap() error {
+	return e.Err
+}
+
+// RestoreError is returned when resources fail to restore. It lists every failed resource.
+type RestoreError struct {
+	// Failed holds the errors of the resources that failed to restore
+	Failed []*ResourceError
+	// Restored is the number of resources written to the cluster. Resources left untouched because they
+	// already existed are not counted.
+	Restored int
+	// Total is the number of resources selected for the restore, including the ones not attempted
+	// because the restore stopped early
+	Total int
+	// RolledBack is set when an atomic restore reverted every resource it restored
+	RolledBack bool
+	// RollbackFailed is set when an atomic restore failed to revert some of the resources it restored,
+	// leaving the cluster partly reverted
+	RollbackFailed bool
+}
+
+func (e *RestoreError) Error() string {
+	if len(e.Failed) == 0 {
+		return fmt.Sprintf("%d of %d resources restored", e.Restored, e.Total)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d of %d resources failed to restore", len(e.Failed), e.Total)
+	for _, failed := range e.Failed {
+		b.WriteString("\n  ")
+		b.WriteString(failed.Error())
+	}
+	return b.String()
+}
+
+func (e *RestoreError) Unwrap() []error {
+	errs := make([]error, len(e.Failed))
+	for i, failed := range e.Failed {
+		errs[i] = failed
+	}
+	return errs
+}
+
+// Partial reports whether some resources were restored despite the failures, and no rollback was attempted.
+func (e *RestoreError) Partial() bool {
+	return e.Restored > 0 && !e.RolledBack && !e.RollbackFailed
+}
+
+// resourceErrors returns the errors of a worker pool as resource errors
+func resourceErrors(errs []error) []*ResourceError {
+	var failed []*ResourceError
+	for _, err := range errs {
+		var resourceErr *ResourceError
+		if !errors.As(err, &resourceErr) {
+			resourceErr = &ResourceError{Err: err}
+		}
+		failed = append(failed, resourceErr)
+	}
+	return failed
+}
@@ -0,0 +1,140 @@
+package restore
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/chaoscypher/kube-save-restore/internal/logger"
+)
+
+// TestPerformRestoreErrors tests that resources failing to restore are reported in a RestoreError.
+func TestPerformRestoreErrors(t *testing.T) {
+	tests := []struct {
+		name            string
+		files           map[string]string
+		opts            []Option
+		expectedFailed  int
+		expectedPartial bool
+	}{
+		{
+			name: "Partial failure",
+			files: map[string]string{
+				"secrets/db.json":            `{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "db", "namespace": "shop"}}`,
+				"widgets.example.com/a.json": `{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "a", "namespace": "shop"}}`,
+			},
+			expectedFailed:  1,
+			expectedPartial: true,
+		},
+		{
+			name: "Total failure",
+			files: map[string]string{
+				"widgets.example.com/a.json": `{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "a", "namespace": "shop"}}`,
+				"gadgets.example.com/b.json": `{"apiVersion": "example.com/v1", "kind": "Gadget", "metadata": {"name": "b", "namespace": "shop"}}`,
+			},
+			expectedFailed: 2,
+		},
+		{
+			name: "Stops at max errors",
+			files: map[string]string{
+				"widgets.example.com/a.json": `{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "a", "namespace": "shop"}}`,
+				"gadgets.example.com/b.json": `{"apiVersion": "example.com/v1", "kind": "Gadget", "metadata": {"name": "b", "namespace": "shop"}}`,
+			},
+			opts:           []Option{WithMaxErrors(1), WithRestoreOrder([][]string{{"Widget"}, {"Gadget"}})},
+			expectedFailed: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restoreDir := t.TempDir()
+			for name, content := range tt.files {
+				path := filepath.Join("shop", filepath.FromSlash(name))
+				if err := os.MkdirAll(filepath.Join(restoreDir, filepath.Dir(path)), 0755); err != nil {
+					t.Fatalf("failed to create directory: %v", err)
+				}
+				writeResourceFile(t, restoreDir, path, content)
+			}
+
+			manager := NewManager(newTestClient(), logger.NewLogger(os.Stdout, logger.DEBUG), tt.opts...)
+			err := manager.PerformRestore(restoreDir, false)
+			var restoreErr *RestoreError
+			if !errors.As(err, &restoreErr) {
+				t.Fatalf("PerformRestore() error = %v; want a *RestoreError", err)
+			}
+			if len(restoreErr.Failed) != tt.expectedFailed {
+				t.Errorf("failed resources = %d; want %d", len(restoreErr.Failed), tt.expectedFailed)
+			}
+			if restoreErr.Partial() != tt.expectedPartial {
+				t.Errorf("Partial() = %v; want %v", restoreErr.Partial(), tt.expectedPartial)
+			}
+			if restoreErr.Total != len(tt.files) {
+				t.Errorf("Total = %d; want %d", restoreErr.Total, len(tt.files))
+			}
+			for _, failed := range restoreErr.Failed {
+				if failed.File == "" || failed.Kind == "" || failed.Namespace != "shop" || failed.Name == "" || failed.Err == nil {
+					t.Errorf("incomplete resource error: %+v", failed)
+				}
+			}
+		})
+	}
+}
+
+// TestPerformRestoreAbortedErrors tests that an aborted restore reports the failed resources in a RestoreError.
+func TestPerformRestoreAbortedErrors(t *testing.T) {
+	restoreDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(restoreDir, "team-a", "deployments"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	writeResourceFile(t, restoreDir, filepath.Join("team-a", "deployments", "web.json"), `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "namespace": "team-a"}}`)
+
+	manager := NewManager(newTestClient(existingDeployment("live")), logger.NewLogger(os.Stdout, logger.DEBUG), WithExistingResourcePolicy(ExistingResourceFail))
+	err := manager.PerformRestore(restoreDir, false)
+	if !errors.Is(err, ErrResourceExists) {
+		t.Errorf("PerformRestore() error = %v; want %v", err, ErrResourceExists)
+	}
+	var restoreErr *RestoreError
+	if !errors.As(err, &restoreErr) {
+		t.Fatalf("PerformRestore() error = %v; want a *RestoreError", err)
+	}
+	if len(restoreErr.Failed) != 1 || restoreErr.Failed[0].Name != "web" {
+		t.Errorf("failed resources = %v; want the deployment web", restoreErr.Failed)
+	}
+}
+
+// TestPerformRestoreErrorsCountApplied tests that resources left untouched are not counted as restored.
+func TestPerformRestoreErrorsCountApplied(t *testing.T) {
+	restoreDir := t.TempDir()
+	for _, dir := range []string{"deployments", "widgets.example.com"} {
+		if err := os.MkdirAll(filepath.Join(restoreDir, "team-a", dir), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+	}
+	writeResourceFile(t, restoreDir, filepath.Join("team-a", "deployments", "web.json"), `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "namespace": "team-a"}}`)
+	writeResourceFile(t, restoreDir, filepath.Join("team-a", "widgets.example.com", "w.json"), `{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "w", "namespace": "team-a"}}`)
+
+	manager := NewManager(newTestClient(existingDeployment("live")), logger.NewLogger(os.Stdout, logger.DEBUG), WithExistingResourcePolicy(ExistingResourceNone))
+	err := manager.PerformRestore(restoreDir, false)
+	var restoreErr *RestoreError
+	if !errors.As(err, &restoreErr) {
+		t.Fatalf("PerformRestore() error = %v; want a *RestoreError", err)
+	}
+	if restoreErr.Restored != 0 || restoreErr.Partial() {
+		t.Errorf("Restored = %d, Partial() = %v; want nothing restored", restoreErr.Restored, restoreErr.Partial())
+	}
+}
+
+// TestPerformRestoreNoErrors tests that a successful restore returns no error.
+func TestPerformRestoreNoErrors(t *testing.T) {
+	restoreDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(restoreDir, "shop", "secrets"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	writeResourceFile(t, restoreDir, filepath.Join("shop", "secrets", "db.json"), `{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "db", "namespace": "shop"}}`)
+
+	manager := NewManager(newTestClient(), logger.NewLogger(os.Stdout, logger.DEBUG))
+	if err := manager.PerformRestore(restoreDir, false); err != nil {
+		t.Errorf("PerformRestore() unexpected error = %v", err)
+	}
+}
@@ -1,167 +1,692 @@
 package restore
 
 import (
+	"bytes"
 	"context"
-	"encoding/json"
+	"crypto/ed25519"
+	"errors"
 	"fmt"
+	"io"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/chaoscypher/kube-save-restore/internal/encryption"
+	"github.com/chaoscypher/kube-save-restore/internal/filter"
 	"github.com/chaoscypher/kube-save-restore/internal/kubernetes"
 	"github.com/chaoscypher/kube-save-restore/internal/logger"
+	"github.com/chaoscypher/kube-save-restore/internal/manifest"
+	"github.com/chaoscypher/kube-save-restore/internal/redact"
+	"github.com/chaoscypher/kube-save-restore/internal/sanitize"
+	"github.com/chaoscypher/kube-save-restore/internal/storage"
 	"github.com/chaoscypher/kube-save-restore/internal/workerpool"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/util/yaml"
 )
 
 const maxConcurrency = 10
 
 // Manager handles the restore operations.
 type Manager struct {
-	k8sClient *kubernetes.Client
-	logger    logger.LoggerInterface
+	k8sClient  *kubernetes.Client
+	logger     logger.LoggerInterface
+	namespaces *filter.Filter
+	resources  *filter.Filter
+	selector   labels.Selector
+	verifyKey  ed25519.PublicKey
+	decrypter  *encryption.Decrypter
+	storage    storage.Storage
+	apply      applyOptions
+	remap      map[string]string
+	order      [][]string
+	wait       time.Duration
+
+	// clusterResources restores cluster-scoped resources other than Namespaces despite a namespace filter
+	clusterResources bool
+	// atomicRestore rolls back the changes of a restore that fails
+	atomicRestore bool
+	// maxErrors is the number of failed resources after which the restore stops, or 0 for no limit
+	maxErrors int64
+
+	// skippedExisting counts the resources left untouched because they already existed
+	skippedExisting atomic.Int64
+	// applied counts the resources written to the cluster, and failed the resources that failed to restore
+	applied atomic.Int64
+	failed  atomic.Int64
+
+	// restored holds the resources to wait for, snapshots the resources to roll back, and crds the
+	// CustomResourceDefinitions to wait for before restoring the next wave
+	mu        sync.Mutex
+	restored  []*restoredResource
+	snapshots []*snapshot
+	crds      []*unstructured.Unstructured
+}
+
+// Option configures optional behaviour of a Manager.
+type Option func(*Manager)
+
+// WithNamespaceFilter restricts the restore to resources in the namespaces matched by f.
+// Namespace objects are filtered by their own name. Other cluster-scoped resources are skipped if f has any
+// pattern, unless WithClusterResources is set.
+func WithNamespaceFilter(f *filter.Filter) Option {
+	return func(m *Manager) {
+		m.namespaces = f
+	}
+}
+
+// WithClusterResources restores the cluster-scoped resources of the backup, such as ClusterRoles and
+// CustomResourceDefinitions, even when a namespace filter is set. Without it, a namespace filter restricts
+// the restore to the selected namespaces and skips every cluster-scoped resource other than Namespaces.
+func WithClusterResources() Option {
+	return func(m *Manager) {
+		m.clusterResources = true
+	}
+}
+
+// WithResourceFilter restricts the restore to the resource types matched by f.
+// Resource types are matched by the directory name the backup stores them in, such as "secrets" or
+// "certificates.cert-manager.io", and by their lowercase kind.
+func WithResourceFilter(f *filter.Filter) Option {
+	return func(m *Manager) {
+		m.resources = f
+	}
+}
+
+// WithLabelSelector restricts the restore to resources whose labels match selector.
+// Namespace objects are always restored so that the selected resources have a namespace to go to.
+func WithLabelSelector(selector labels.Selector) Option {
+	return func(m *Manager) {
+		m.selector = selector
+	}
+}
+
+// WithVerifyKey makes the restore refuse backups that are not signed with the private key matching key,
+// or whose files do not match the signed manifest.
+func WithVerifyKey(key ed25519.PublicKey) Option {
+	return func(m *Manager) {
+		m.verifyKey = key
+	}
+}
+
+// WithDecryption decrypts Secrets that were encrypted during the backup with decrypter.
+// Without it, encrypted Secrets fail to restore.
+func WithDecryption(decrypter *encryption.Decrypter) Option {
+	return func(m *Manager) {
+		m.decrypter = decrypter
+	}
+}
+
+// WithStorage reads backups from s instead of the local filesystem. The restore directory names the
+// backup within s.
+func WithStorage(s storage.Storage) Option {
+	return func(m *Manager) {
+		m.storage = s
+	}
+}
+
+// WithExistingResourcePolicy sets how resources that already exist in the cluster are handled.
+// The default is ExistingResourceUpdate.
+func WithExistingResourcePolicy(policy ExistingResourcePolicy) Option {
+	return func(m *Manager) {
+		m.apply.policy = policy
+	}
+}
+
+// WithServerSideApply restores resources with server-side apply as FieldManager instead of updating or
+// creating them. If forceConflicts is true, fields managed by others are taken over instead of failing
+// with a conflict.
+func WithServerSideApply(forceConflicts bool) Option {
+	return func(m *Manager) {
+		m.apply.serverSide = true
+		m.apply.forceConflicts = forceConflicts
+	}
+}
+
+// WithSanitizer sets the server-populated fields removed from resources before they are restored.
+// By default, every field known to the sanitize package is removed.
+func WithSanitizer(s *sanitize.Sanitizer) Option {
+	return func(m *Manager) {
+		m.apply.sanitizer = s
+	}
+}
+
+// WithNamespaceMapping restores the resources of each namespace of the backup that is a key of mapping
+// into the namespace it maps to. Namespace filters match the namespaces of the backup.
+func WithNamespaceMapping(mapping map[string]string) Option {
+	return func(m *Manager) {
+		m.remap = mapping
+	}
+}
+
+// WithRestoreOrder sets the waves of kinds resources are restored in. The default is DefaultRestoreOrder.
+func WithRestoreOrder(order [][]string) Option {
+	return func(m *Manager) {
+		m.order = order
+	}
+}
+
+// WithWait makes the restore wait up to timeout for the restored Deployments, StatefulSets and DaemonSets
+// to complete their rollout, PersistentVolumeClaims to be bound and Jobs to complete, and report the ones
+// that do not.
+func WithWait(timeout time.Duration) Option {
+	return func(m *Manager) {
+		m.wait = timeout
+	}
+}
+
+// WithAtomic makes the restore all or nothing. The live version of each resource is saved before it is
+// restored, and if any resource fails to restore, or does not become ready when waiting, the resources
+// created by the restore are deleted and the ones it changed are put back to their previous version.
+func WithAtomic() Option {
+	return func(m *Manager) {
+		m.atomicRestore = true
+	}
+}
+
+// WithMaxErrors stops the restore once n resources have failed to restore, leaving the remaining ones
+// unrestored. By default, or if n is 0, every resource is attempted.
+func WithMaxErrors(n int) Option {
+	return func(m *Manager) {
+		m.maxErrors = int64(n)
+	}
 }
 
 // NewManager creates a new restore Manager.
-func NewManager(k8sClient *kubernetes.Client, logger logger.LoggerInterface) *Manager {
-	return &Manager{
+func NewManager(k8sClient *kubernetes.Client, logger logger.LoggerInterface, opts ...Option) *Manager {
+	m := &Manager{
 		k8sClient: k8sClient,
 		logger:    logger,
+		storage:   storage.NewLocal(""),
+		apply:     applyOptions{policy: ExistingResourceUpdate},
+		order:     DefaultRestoreOrder,
+	}
+	for _, opt := range opts {
+		opt(m)
 	}
+	return m
+}
+
+// resourceFile is a resource read from a backup file. If the file could not be read or is invalid,
+// err is set and the error is reported when the resource is restored.
+type resourceFile struct {
+	path     string
+	resource map[string]interface{}
+	kind     string
+	err      error
 }
 
 // PerformRestore performs the restore operation by reading resource files from the specified directory
 // and applying them to the Kubernetes cluster. If dryRun is true, no changes will be made.
+// If resources fail to restore, it returns a *RestoreError listing them.
 func (m *Manager) PerformRestore(restoreDir string, dryRun bool) error {
 	m.logger.Info("Starting restore operation")
+	m.skippedExisting.Store(0)
+	m.applied.Store(0)
+	m.failed.Store(0)
+	m.restored = nil
+	m.snapshots = nil
+	m.crds = nil
 
-	// Get the list of resource files from the restore directory
-	files, err := getResourceFiles(restoreDir)
+	// Cancelled to abort the restore when a resource exists and the policy is ExistingResourceFail, or when
+	// too many resources failed
+	ctx, abort := context.WithCancel(context.Background())
+	defer abort()
+
+	// Read the resource files from the restore directory or archive
+	loaded, err := m.loadBackup(restoreDir)
 	if err != nil {
-		return fmt.Errorf("error getting resource files: %v", err)
+		return err
 	}
 
-	// Separate namespace files from other resource files
-	namespaceFiles, otherFiles := m.separateNamespaceFiles(files)
+	// Keep the resources selected by the filters
+	resources := m.selectResources(loaded)
+
+	// Warn about role bindings that will not work after the restore
+	m.checkRoleBindingReferences(resources)
 
-	// Count the total number of resources to be restored
-	totalResources := len(namespaceFiles) + len(otherFiles)
+	// Group the resources into waves, so that they are restored after the resources they depend on
+	waves := groupWaves(resources, m.order)
 
 	if dryRun {
 		m.logger.Info("Dry run mode: No resources will be created or modified")
 	}
 
-	// First, restore namespaces to ensure they exist before other resources
-	if len(namespaceFiles) > 0 {
-		m.logger.Info("Restoring namespaces first...")
-		nsWp := workerpool.NewWorkerPool(maxConcurrency, len(namespaceFiles))
-		m.enqueueTasks(namespaceFiles, nsWp, dryRun)
+	var failed []*ResourceError
+	for i, wave := range waves {
+		m.logger.Infof("Restoring wave %d of %d: %s", i+1, len(waves), wave)
+		wp := workerpool.NewWorkerPool(maxConcurrency, len(wave.files))
+		m.enqueueTasks(wave.files, wp, dryRun, abort)
 
-		// Run the namespace worker pool and collect any errors
-		nsErrors := nsWp.Run(context.Background())
-		if len(nsErrors) > 0 {
-			for _, err := range nsErrors {
-				m.logger.Errorf("Error restoring namespace: %v", err)
-			}
+		// Run the worker pool and collect any errors before starting the next wave
+		errs := wp.Run(ctx)
+		for _, err := range errs {
+			m.logger.Errorf("Error during restore: %v", err)
+		}
+		failed = append(failed, resourceErrors(errs)...)
+
+		// Stop at an existing resource with the fail policy, or at any error of an atomic restore
+		if m.atomicRestore && len(failed) > 0 {
+			return m.rollback(m.restoreError(failed, len(resources)))
 		}
-		m.logger.Info("Namespace restoration completed")
+		if isAborted(errs) {
+			return fmt.Errorf("restore aborted: %w", m.restoreError(failed, len(resources)))
+		}
+		if ctx.Err() != nil {
+			m.logger.Errorf("Stopping the restore: %d resources failed to restore", len(failed))
+			break
+		}
+
+		// Custom resources can only be restored once their CustomResourceDefinition is served
+		m.waitForEstablished(ctx)
 	}
 
-	// Then restore other resources using the worker pool
-	if len(otherFiles) > 0 {
-		m.logger.Info("Restoring other resources...")
-		wp := workerpool.NewWorkerPool(maxConcurrency, len(otherFiles))
-		m.enqueueTasks(otherFiles, wp, dryRun)
+	// Log a completion message summarizing the restore operation
+	m.logCompletionMessage(len(resources), len(failed), dryRun, restoreDir)
+	var restoreErr error
+	if len(failed) > 0 {
+		restoreErr = m.restoreError(failed, len(resources))
+	}
 
-		// Run the worker pool and collect any errors
-		errors := wp.Run(context.Background())
-		if len(errors) > 0 {
-			for _, err := range errors {
-				m.logger.Errorf("Error during restore: %v", err)
+	// Check that the restored workloads are actually running, unless the restore was stopped
+	if m.wait > 0 && !dryRun && ctx.Err() == nil {
+		if readyErr := m.waitForReady(ctx); readyErr != nil {
+			if m.atomicRestore {
+				// Every resource was restored, so the restore error only records that they were rolled back
+				return m.rollback(errors.Join(m.restoreError(nil, len(resources)), readyErr))
 			}
+			restoreErr = errors.Join(restoreErr, readyErr)
 		}
 	}
-
-	// Log a completion message summarizing the restore operation
-	m.logCompletionMessage(totalResources, dryRun, restoreDir)
-	return nil
+	return restoreErr
 }
 
 // enqueueTasks adds restore tasks for each resource file to the worker pool.
-func (m *Manager) enqueueTasks(files []string, wp *workerpool.WorkerPool, dryRun bool) {
+// abort is called when a resource exists and the policy is ExistingResourceFail, on any error if the
+// restore is atomic, or once the maximum number of errors is reached, after which the remaining tasks
+// do nothing.
+func (m *Manager) enqueueTasks(files []resourceFile, wp *workerpool.WorkerPool, dryRun bool, abort func()) {
 	for _, file := range files {
 		resourceFile := file // capture range variable
 		task := func(ctx context.Context) error {
-			return m.RestoreResource(resourceFile, dryRun)
+			if ctx.Err() != nil {
+				return nil
+			}
+			err := m.restoreResourceFile(ctx, resourceFile, dryRun)
+			if err == nil {
+				return nil
+			}
+			failed := m.failed.Add(1)
+			if errors.Is(err, ErrResourceExists) || m.atomicRestore || (m.maxErrors > 0 && failed >= m.maxErrors) {
+				abort()
+			}
+			return newResourceError(resourceFile, err)
 		}
 		if err := wp.AddTask(task); err != nil {
-			m.logger.Errorf("Failed to add task for file %s: %v", resourceFile, err)
+			m.logger.Errorf("Failed to add task for file %s: %v", resourceFile.path, err)
 		}
 	}
 	wp.Close()
 }
 
 // logCompletionMessage logs a summary message upon completion of the restore operation.
-func (m *Manager) logCompletionMessage(totalResources int, dryRun bool, restoreDir string) {
-	if dryRun {
+func (m *Manager) logCompletionMessage(totalResources, failedResources int, dryRun bool, restoreDir string) {
+	switch {
+	case failedResources > 0:
+		m.logger.Errorf("Restore failed. %d of %d resources failed to restore from: %s", failedResources, totalResources, restoreDir)
+	case dryRun:
 		m.logger.Infof("Dry run completed. %d resources would be restored from: %s", totalResources, restoreDir)
-	} else {
+	default:
 		m.logger.Infof("Restore completed. %d resources restored from: %s", totalResources, restoreDir)
 	}
+	if skipped := m.skippedExisting.Load(); skipped > 0 {
+		m.logger.Warnf("%d resources already existed and were left untouched", skipped)
+	}
+}
+
+// isAborted reports whether the errors of a worker pool include an existing resource with the
+// ExistingResourceFail policy, which aborts the restore.
+func isAborted(errs []error) bool {
+	for _, err := range errs {
+		if errors.Is(err, ErrResourceExists) {
+			return true
+		}
+	}
+	return false
+}
+
+// restoreError returns the error listing the resources that failed to restore, out of total
+func (m *Manager) restoreError(failed []*ResourceError, total int) *RestoreError {
+	return &RestoreError{Failed: failed, Restored: int(m.applied.Load()), Total: total}
 }
 
-// RestoreResource restores a single resource from the specified file. If dryRun is true, no changes will be made.
+// RestoreResource restores the resources in the specified file. If dryRun is true, no changes will be made.
 func (m *Manager) RestoreResource(filename string, dryRun bool) error {
-	m.logger.Debugf("Restoring resource from file: %s", filename)
+	var errs []error
+	for _, file := range loadResourceFile(filename) {
+		if err := m.restoreResourceFile(context.Background(), file, dryRun); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
 
-	// Read the resource file
-	data, err := os.ReadFile(filename)
-	if err != nil {
-		return fmt.Errorf("error reading file %s: %v", filename, err)
+// restoreResourceFile restores a resource read from a backup file. If dryRun is true, no changes will be made.
+func (m *Manager) restoreResourceFile(ctx context.Context, file resourceFile, dryRun bool) error {
+	m.logger.Debugf("Restoring resource from file: %s", file.path)
+	if file.err != nil {
+		return file.err
 	}
 
-	// Unmarshal the JSON data into a raw resource map
-	var rawResource map[string]interface{}
-	if err := json.Unmarshal(data, &rawResource); err != nil {
-		return fmt.Errorf("error unmarshaling resource: %v", err)
+	// Decrypt encrypted Secrets, also in dry runs so that a wrong passphrase is reported
+	if encryption.IsEncrypted(file.resource) {
+		if m.decrypter == nil {
+			return fmt.Errorf("%s is encrypted: an encryption passphrase is required to restore it", file.path)
+		}
+		if err := m.decrypter.DecryptSecret(file.resource); err != nil {
+			return fmt.Errorf("error decrypting %s: %v", file.path, err)
+		}
 	}
 
-	// Adjust the resource structure and validate it
-	resource, kind, err := adjustResourceStructure(rawResource)
-	if err != nil {
-		return fmt.Errorf("error adjusting resource structure: %v", err)
+	// Remove the fields populated by the source cluster, which the target cluster rejects or rebuilds. The
+	// spec fields allocated on creation are only removed if the resource is created, by applyResource.
+	m.apply.sanitizer.Sanitize(file.resource)
+
+	// Move the resource to its destination namespace, after decryption since encrypted values are bound
+	// to the namespace of their Secret
+	remapNamespaces(file.resource, m.remap)
+	if file.kind == "ClusterRoleBinding" {
+		if remapped := remappedSubjects(file.resource, m.remap); len(remapped) > 0 {
+			metadata, _ := file.resource["metadata"].(map[string]interface{})
+			m.logger.Warnf("ClusterRoleBinding %v is left unchanged, so remapped ServiceAccounts are not granted its permissions: %s",
+				metadata["name"], strings.Join(remapped, ", "))
+		}
 	}
-	if err := validateResource(resource); err != nil {
-		return fmt.Errorf("invalid resource structure: %v", err)
+
+	if err := m.requireNamespace(file.resource); err != nil {
+		return err
+	}
+
+	// Never apply the placeholders of redacted backups over real values, after remapping so that they are
+	// taken from the destination
+	if redact.IsRedacted(file.resource) {
+		if err := m.unredact(ctx, file); err != nil {
+			return err
+		}
 	}
 
 	if dryRun {
-		m.logger.Infof("Dry run: would restore %s/%s", kind, filename)
+		m.logger.Infof("Dry run: would restore %s/%s", file.kind, file.path)
 		return nil
 	}
 
 	// Get the resource identifiers and apply the resource to the Kubernetes cluster
-	name, namespace, err := getResourceIdentifiers(resource)
+	name, namespace, err := getResourceIdentifiers(file.resource)
 	if err != nil {
 		return fmt.Errorf("error getting resource identifiers: %v", err)
 	}
-	m.logger.Infof("Restoring %s/%s in namespace %s", kind, name, namespace)
-	return applyResource(m.k8sClient, resource, kind, namespace)
+	m.logger.Infof("Restoring %s/%s in namespace %s", file.kind, name, namespace)
+
+	// Save the live version of the resource so that an atomic restore can put it back
+	var saved *snapshot
+	if m.atomicRestore {
+		if saved, err = m.takeSnapshot(ctx, file.resource); err != nil {
+			return err
+		}
+	}
+
+	result, err := applyResource(ctx, m.k8sClient, file.resource, m.apply)
+	if saved != nil && result != resourceExisted {
+		saved.recreated = result == resourceRecreated
+		m.recordSnapshot(saved)
+	}
+	if err != nil {
+		return err
+	}
+	if result != resourceExisted {
+		m.applied.Add(1)
+	}
+	switch {
+	case result == resourceExisted:
+		m.logger.Warnf("Skipping %s/%s in namespace %s: it already exists", file.kind, name, namespace)
+		m.skippedExisting.Add(1)
+	case result == resourceRecreated:
+		m.logger.Infof("Recreated %s/%s in namespace %s", file.kind, name, namespace)
+	case result == resourceNotRecreated:
+		m.logger.Warnf("Updated %s/%s in namespace %s instead of recreating it: deleting a %s deletes the resources or data that depend on it", file.kind, name, namespace, file.kind)
+	}
+	if m.wait > 0 && result != resourceExisted && readinessChecks[file.kind] != nil {
+		m.recordRestored(file.resource)
+	}
+	if file.kind == "CustomResourceDefinition" {
+		m.recordCRD(file.resource)
+	}
+	return nil
+}
+
+// backupEntry is a file read from a backup directory or archive. If the file could not be read, err is set.
+type backupEntry struct {
+	name string // path relative to the root of the backup, using forward slashes
+	data []byte
+	err  error
+}
+
+// loadBackup reads all resource files of a backup, which is either a directory or a compressed archive.
+// If a verification key is configured, the backup is checked against its signed manifest first and
+// nothing is loaded unless every file matches.
+func (m *Manager) loadBackup(restoreDir string) ([]resourceFile, error) {
+	entries, err := readBackup(context.Background(), m.storage, restoreDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if m.verifyKey != nil {
+		if err := verifyBackup(entries, m.verifyKey); err != nil {
+			return nil, err
+		}
+		m.logger.Info("Backup matches its signed manifest")
+	}
+
+	var resources []resourceFile
+	for _, entry := range entries {
+		if entry.name == manifest.Filename || entry.name == manifest.SignatureFilename {
+			continue
+		}
+		path := filepath.Join(restoreDir, filepath.FromSlash(entry.name))
+		if entry.err != nil {
+			resources = append(resources, resourceFile{path: path, err: entry.err})
+			continue
+		}
+		resources = append(resources, parseResourceFile(path, entry.data)...)
+	}
+	return resources, nil
+}
+
+// readBackup reads the files of the backup at restorePath in s, which is either a directory or a compressed archive
+func readBackup(ctx context.Context, s storage.Storage, restorePath string) ([]backupEntry, error) {
+	if storage.IsArchive(restorePath) {
+		return readArchive(ctx, s, restorePath)
+	}
+	return readDirectory(ctx, s, restorePath)
 }
 
-// separateNamespaceFiles separates namespace files from other resource files
-func (m *Manager) separateNamespaceFiles(files []string) ([]string, []string) {
-	var namespaceFiles []string
-	var otherFiles []string
+// readDirectory reads the resource files of a backup directory in s, along with its manifest and signature
+// if present.
+func readDirectory(ctx context.Context, s storage.Storage, restoreDir string) ([]backupEntry, error) {
+	// Get the list of resource files from the restore directory
+	files, err := getResourceFiles(ctx, s, restoreDir)
+	if err != nil {
+		return nil, fmt.Errorf("error getting resource files: %v", err)
+	}
+
+	entries := make([]backupEntry, 0, len(files)+2)
+	for _, name := range []string{manifest.Filename, manifest.SignatureFilename} {
+		data, err := storage.ReadFile(ctx, s, filepath.Join(restoreDir, name))
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				continue
+			}
+			return nil, fmt.Errorf("error reading %s: %v", name, err)
+		}
+		entries = append(entries, backupEntry{name: name, data: data})
+	}
 
 	for _, file := range files {
-		// Check if the file is in the namespaces directory
-		dir := filepath.Dir(file)
-		if filepath.Base(dir) == "namespaces" || strings.Contains(filepath.ToSlash(dir), "/namespaces/") {
-			namespaceFiles = append(namespaceFiles, file)
-		} else {
-			otherFiles = append(otherFiles, file)
+		rel, err := filepath.Rel(restoreDir, file)
+		if err != nil {
+			return nil, fmt.Errorf("error getting resource files: %v", err)
+		}
+		entry := backupEntry{name: filepath.ToSlash(rel)}
+		entry.data, entry.err = storage.ReadFile(ctx, s, file)
+		if entry.err != nil {
+			entry.err = fmt.Errorf("error reading file %s: %v", file, entry.err)
 		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// loadResourceFile reads a single resource file, returning an entry for every resource it contains.
+// Any error is recorded in the result.
+func loadResourceFile(filename string) []resourceFile {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return []resourceFile{{path: filename, err: fmt.Errorf("error reading file %s: %v", filename, err)}}
+	}
+	return parseResourceFile(filename, data)
+}
+
+// parseResourceFile parses the content of a resource file, returning an entry for every resource it contains.
+// Any error is recorded in the result.
+func parseResourceFile(filename string, data []byte) []resourceFile {
+	documents, err := decodeDocuments(data)
+	if err != nil {
+		return []resourceFile{{path: filename, err: err}}
+	}
+
+	resources := make([]resourceFile, 0, len(documents))
+	for _, document := range documents {
+		resource, kind, err := prepareResource(document)
+		resources = append(resources, resourceFile{path: filename, resource: resource, kind: kind, err: err})
+	}
+	return resources
+}
+
+// selectResources returns the resources matched by the namespace and resource filters and the label selector.
+// Resources that could not be read are kept so that their errors are reported.
+func (m *Manager) selectResources(files []resourceFile) []resourceFile {
+	var selected []resourceFile
+	var skippedClusterResources int
+	for _, file := range files {
+		if file.err == nil && file.kind != "Namespace" && !m.clusterResourceSelected(file) {
+			m.logger.Debugf("Skipping cluster-scoped %s with a namespace filter", file.path)
+			skippedClusterResources++
+			continue
+		}
+		if file.err == nil && !m.namespaceSelected(file) {
+			m.logger.Debugf("Skipping %s excluded by namespace filter", file.path)
+			continue
+		}
+		if file.err == nil && !m.resources.MatchesAny(resourceTypeNames(file)...) {
+			m.logger.Debugf("Skipping %s excluded by resource filter", file.path)
+			continue
+		}
+		if file.err == nil && !m.labelsSelected(file) {
+			m.logger.Debugf("Skipping %s not matched by label selector", file.path)
+			continue
+		}
+		selected = append(selected, file)
+	}
+	if skippedClusterResources > 0 {
+		m.logger.Warnf("Skipping %d cluster-scoped resources because a namespace filter is set; use --include-cluster-resources to restore them", skippedClusterResources)
+	}
+	return selected
+}
+
+// resourceTypeNames returns the names a resource's type is matched by in resource filters: the directory
+// the backup stored it in, that directory without its API group suffix, the plural resource name of
+// abbreviated directories such as "pvcs", and the lowercase kind.
+func resourceTypeNames(file resourceFile) []string {
+	dir := filepath.Base(filepath.Dir(file.path))
+	group := strings.SplitN(dir, ".", 2)[0]
+	names := []string{dir, group, strings.ToLower(file.kind)}
+	if alias, ok := filter.ResourceTypeAliases[dir]; ok {
+		names = append(names, alias)
+	}
+	return names
+}
+
+// clusterResourceSelected reports whether a resource is namespaced, or a cluster-scoped resource restored
+// because no namespace filter is set or cluster-scoped resources were requested with WithClusterResources.
+func (m *Manager) clusterResourceSelected(file resourceFile) bool {
+	return m.clusterResources || m.namespaces.IsZero() || !m.clusterScoped(file.resource)
+}
+
+// namespaceSelected reports whether the namespace of a resource is matched by the namespace filter.
+// Namespaces are matched by their name and other cluster-scoped resources are always selected.
+func (m *Manager) namespaceSelected(file resourceFile) bool {
+	name, namespace, _ := getResourceIdentifiers(file.resource)
+	switch {
+	case file.kind == "Namespace":
+		return m.namespaces.Matches(name)
+	case m.clusterScoped(file.resource):
+		return true
+	default:
+		return m.namespaces.Matches(namespace)
+	}
+}
+
+// labelsSelected reports whether the labels of a resource are matched by the label selector.
+// Namespaces are always selected.
+func (m *Manager) labelsSelected(file resourceFile) bool {
+	if m.selector == nil || file.kind == "Namespace" {
+		return true
+	}
+	metadata, _ := file.resource["metadata"].(map[string]interface{})
+	resourceLabels, _ := metadata["labels"].(map[string]interface{})
+	set := make(labels.Set, len(resourceLabels))
+	for key, value := range resourceLabels {
+		set[key], _ = value.(string)
+	}
+	return m.selector.Matches(set)
+}
+
+// decodeDocuments decodes the content of a JSON or YAML resource file into the documents it contains.
+// YAML files may contain multiple documents separated by "---"; empty documents are skipped.
+func decodeDocuments(data []byte) ([]map[string]interface{}, error) {
+	// Decode each document into a raw resource map
+	var documents []map[string]interface{}
+	decoder := yaml.NewYAMLOrJSONDecoder(bytes.NewReader(data), 4096)
+	for {
+		var rawResource map[string]interface{}
+		if err := decoder.Decode(&rawResource); err != nil {
+			if err == io.EOF {
+				break
+			}
+			return nil, fmt.Errorf("error unmarshaling resource: %v", err)
+		}
+		if len(rawResource) > 0 {
+			documents = append(documents, rawResource)
+		}
+	}
+
+	return documents, nil
+}
+
+// prepareResource adjusts the structure of a raw resource and validates it.
+// It returns the resource and its kind.
+func prepareResource(rawResource map[string]interface{}) (map[string]interface{}, string, error) {
+	resource, kind, err := adjustResourceStructure(rawResource)
+	if err != nil {
+		return nil, "", fmt.Errorf("error adjusting resource structure: %v", err)
+	}
+	if err := validateResource(resource); err != nil {
+		return nil, "", fmt.Errorf("invalid resource structure: %v", err)
 	}
 
-	return namespaceFiles, otherFiles
+	return resource, kind, nil
 }
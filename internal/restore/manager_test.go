@@ -1,7 +1,42 @@
 package restore
 
 import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/chaoscypher/kube-save-restore/internal/encryption"
+	"github.com/chaoscypher/kube-save-restore/internal/filter"
+	"github.com/chaoscypher/kube-save-restore/internal/kubernetes"
+	"github.com/chaoscypher/kube-save-restore/internal/logger"
+	"github.com/chaoscypher/kube-save-restore/internal/manifest"
+	"github.com/chaoscypher/kube-save-restore/internal/redact"
+	"github.com/chaoscypher/kube-save-restore/internal/sanitize"
+	"github.com/chaoscypher/kube-save-restore/internal/storage"
+	"github.com/klauspost/compress/zstd"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
 )
 
 // TestCountResources tests the countResources method of the Manager struct.
@@ -39,3 +74,1292 @@ func TestCountResources(t *testing.T) {
 		})
 	}
 }
+
+// newTestClient returns a Kubernetes client backed by a fake dynamic client and a static RESTMapper
+// that knows about Namespaces, ClusterRoles, Secrets, Deployments and cert-manager Certificates.
+func newTestClient(objects ...runtime.Object) *kubernetes.Client {
+	mapper := meta.NewDefaultRESTMapper(nil)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "rbac.authorization.k8s.io", Version: "v1", Kind: "ClusterRole"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Group: "apiextensions.k8s.io", Version: "v1", Kind: "CustomResourceDefinition"}, meta.RESTScopeRoot)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Version: "v1", Kind: "PersistentVolumeClaim"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "batch", Version: "v1", Kind: "Job"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}, meta.RESTScopeNamespace)
+	mapper.Add(schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "ClusterIssuer"}, meta.RESTScopeRoot)
+
+	return &kubernetes.Client{
+		Dynamic: dynamicfake.NewSimpleDynamicClient(runtime.NewScheme(), objects...),
+		Mapper:  mapper,
+	}
+}
+
+// loadResourceFiles reads all resource files.
+func loadResourceFiles(files []string) []resourceFile {
+	var resources []resourceFile
+	for _, file := range files {
+		resources = append(resources, loadResourceFile(file)...)
+	}
+	return resources
+}
+
+// writeResourceFile writes content to a file in dir and returns its path.
+func writeResourceFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write resource file: %v", err)
+	}
+	return path
+}
+
+// TestRestoreResource tests that resources of any kind are restored through the dynamic client.
+func TestRestoreResource(t *testing.T) {
+	tests := []struct {
+		name      string
+		content   string
+		gvr       schema.GroupVersionResource
+		namespace string
+		objName   string
+	}{
+		{
+			name:      "Wrapped built-in resource without apiVersion",
+			content:   `{"kind": "Deployment", "resource": {"metadata": {"name": "web", "namespace": "default"}}}`,
+			gvr:       schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			namespace: "default",
+			objName:   "web",
+		},
+		{
+			name:      "Wrapped custom resource",
+			content:   `{"kind": "Certificate", "resource": {"apiVersion": "cert-manager.io/v1", "kind": "Certificate", "metadata": {"name": "web-tls", "namespace": "default"}}}`,
+			gvr:       schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"},
+			namespace: "default",
+			objName:   "web-tls",
+		},
+		{
+			name:      "Plain manifest",
+			content:   `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "api", "namespace": "default"}}`,
+			gvr:       schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"},
+			namespace: "default",
+			objName:   "api",
+		},
+		{
+			name:    "Cluster-scoped resource",
+			content: `{"kind": "Namespace", "resource": {"metadata": {"name": "team-a"}}}`,
+			gvr:     schema.GroupVersionResource{Version: "v1", Resource: "namespaces"},
+			objName: "team-a",
+		},
+		{
+			name:    "Cluster-scoped resource with group",
+			content: `{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "ClusterRole", "metadata": {"name": "view"}}`,
+			gvr:     schema.GroupVersionResource{Group: "rbac.authorization.k8s.io", Version: "v1", Resource: "clusterroles"},
+			objName: "view",
+		},
+		{
+			name:    "Cluster-scoped custom resource",
+			content: `{"apiVersion": "cert-manager.io/v1", "kind": "ClusterIssuer", "metadata": {"name": "letsencrypt"}}`,
+			gvr:     schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "clusterissuers"},
+			objName: "letsencrypt",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient()
+			manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG))
+			file := writeResourceFile(t, t.TempDir(), "resource.json", tt.content)
+
+			if err := manager.RestoreResource(file, false); err != nil {
+				t.Fatalf("RestoreResource() error = %v", err)
+			}
+
+			var resourceClient dynamic.ResourceInterface = client.Dynamic.Resource(tt.gvr)
+			if tt.namespace != "" {
+				resourceClient = client.Dynamic.Resource(tt.gvr).Namespace(tt.namespace)
+			}
+			if _, err := resourceClient.Get(context.Background(), tt.objName, metav1.GetOptions{}); err != nil {
+				t.Errorf("expected %s to be created, got error: %v", tt.objName, err)
+			}
+		})
+	}
+}
+
+// TestRestoreResourceSanitize tests that fields populated by the source cluster are removed before a
+// resource is restored, unless they are preserved.
+func TestRestoreResourceSanitize(t *testing.T) {
+	tests := []struct {
+		name         string
+		preserve     []string
+		expectStatus bool
+	}{
+		{name: "Default"},
+		{name: "Preserve status", preserve: []string{"status"}, expectStatus: true},
+	}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sanitizer, err := sanitize.New(tt.preserve)
+			if err != nil {
+				t.Fatalf("sanitize.New() error = %v", err)
+			}
+			client := newTestClient()
+			manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG), WithSanitizer(sanitizer))
+			file := writeResourceFile(t, t.TempDir(), "web.json", `{"apiVersion": "apps/v1", "kind": "Deployment",
+				"metadata": {"name": "web", "namespace": "team-a", "uid": "1234", "annotations": {"deployment.kubernetes.io/revision": "3"}},
+				"status": {"readyReplicas": 2}}`)
+
+			if err := manager.RestoreResource(file, false); err != nil {
+				t.Fatalf("RestoreResource() error = %v", err)
+			}
+
+			live, err := client.Dynamic.Resource(deployments).Namespace("team-a").Get(context.Background(), "web", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("expected deployment web to be created, got error: %v", err)
+			}
+			if uid := live.GetUID(); uid != "" {
+				t.Errorf("uid = %q; want it removed", uid)
+			}
+			if annotations := live.GetAnnotations(); len(annotations) != 0 {
+				t.Errorf("annotations = %v; want them removed", annotations)
+			}
+			if _, hasStatus := live.Object["status"]; hasStatus != tt.expectStatus {
+				t.Errorf("status present = %v; want %v", hasStatus, tt.expectStatus)
+			}
+		})
+	}
+}
+
+// TestRestoreResourceSanitizeExisting tests that the spec fields allocated on creation, which the API server
+// does not allow to be removed, are only removed from resources that are created.
+func TestRestoreResourceSanitizeExisting(t *testing.T) {
+	claims := schema.GroupVersionResource{Version: "v1", Resource: "persistentvolumeclaims"}
+	jobs := schema.GroupVersionResource{Group: "batch", Version: "v1", Resource: "jobs"}
+	claim := `{"apiVersion": "v1", "kind": "PersistentVolumeClaim", "metadata": {"name": "data", "namespace": "team-a"}, "spec": {"volumeName": "pvc-1234"}}`
+	job := `{"apiVersion": "batch/v1", "kind": "Job", "metadata": {"name": "migrate", "namespace": "team-a"},
+		"spec": {"selector": {"matchLabels": {"batch.kubernetes.io/controller-uid": "1234"}},
+			"template": {"metadata": {"labels": {"batch.kubernetes.io/controller-uid": "1234"}}}}}`
+
+	tests := []struct {
+		name          string
+		content       string
+		gvr           schema.GroupVersionResource
+		objName       string
+		exists        bool
+		field         []string
+		expectRemoved bool
+	}{
+		{name: "Existing bound PersistentVolumeClaim", content: claim, gvr: claims, objName: "data", exists: true, field: []string{"spec", "volumeName"}},
+		{name: "New PersistentVolumeClaim", content: claim, gvr: claims, objName: "data", field: []string{"spec", "volumeName"}, expectRemoved: true},
+		{name: "Existing Job", content: job, gvr: jobs, objName: "migrate", exists: true, field: []string{"spec", "selector"}},
+		{name: "New Job", content: job, gvr: jobs, objName: "migrate", field: []string{"spec", "selector"}, expectRemoved: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var objects []runtime.Object
+			if tt.exists {
+				existing := &unstructured.Unstructured{}
+				if err := json.Unmarshal([]byte(tt.content), &existing.Object); err != nil {
+					t.Fatalf("failed to parse resource: %v", err)
+				}
+				objects = append(objects, existing)
+			}
+			client := newTestClient(objects...)
+
+			// Reject updates removing the field, as the API server does
+			client.Dynamic.(*dynamicfake.FakeDynamicClient).PrependReactor("update", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				obj := action.(k8stesting.UpdateAction).GetObject().(*unstructured.Unstructured)
+				if _, found, _ := unstructured.NestedFieldNoCopy(obj.Object, tt.field...); !found {
+					return true, nil, errors.New("spec: Forbidden: field is immutable")
+				}
+				return false, nil, nil
+			})
+
+			manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG))
+			if err := manager.RestoreResource(writeResourceFile(t, t.TempDir(), "resource.json", tt.content), false); err != nil {
+				t.Fatalf("RestoreResource() error = %v", err)
+			}
+
+			live, err := client.Dynamic.Resource(tt.gvr).Namespace("team-a").Get(context.Background(), tt.objName, metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("expected %s to be restored, got error: %v", tt.objName, err)
+			}
+			if _, found, _ := unstructured.NestedFieldNoCopy(live.Object, tt.field...); found == tt.expectRemoved {
+				t.Errorf("%s present = %v; want %v", strings.Join(tt.field, "."), found, !tt.expectRemoved)
+			}
+		})
+	}
+}
+
+// rejectUnconditionalUpdates makes the fake client reject updates without a resourceVersion, as the API
+// server does for custom resources and CustomResourceDefinitions.
+func rejectUnconditionalUpdates(client *kubernetes.Client) {
+	client.Dynamic.(*dynamicfake.FakeDynamicClient).PrependReactor("update", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		obj := action.(k8stesting.UpdateAction).GetObject().(*unstructured.Unstructured)
+		if obj.GetResourceVersion() == "" {
+			return true, nil, apierrors.NewInvalid(obj.GroupVersionKind().GroupKind(), obj.GetName(), field.ErrorList{
+				field.Invalid(field.NewPath("metadata", "resourceVersion"), "", "must be specified for an update"),
+			})
+		}
+		return false, nil, nil
+	})
+}
+
+// TestRestoreResourceUpdateCustomResource tests that existing custom resources are updated with the
+// resourceVersion of their live version, which the API server requires for them.
+func TestRestoreResourceUpdateCustomResource(t *testing.T) {
+	certificates := schema.GroupVersionResource{Group: "cert-manager.io", Version: "v1", Resource: "certificates"}
+	existing := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "cert-manager.io/v1",
+		"kind":       "Certificate",
+		"metadata":   map[string]interface{}{"name": "web-tls", "namespace": "team-a", "resourceVersion": "7", "labels": map[string]interface{}{"version": "live"}},
+	}}
+	client := newTestClient(existing)
+	rejectUnconditionalUpdates(client)
+
+	manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG))
+	file := writeResourceFile(t, t.TempDir(), "web-tls.json", `{"apiVersion": "cert-manager.io/v1", "kind": "Certificate",
+		"metadata": {"name": "web-tls", "namespace": "team-a", "resourceVersion": "3", "labels": {"version": "backup"}}}`)
+	if err := manager.RestoreResource(file, false); err != nil {
+		t.Fatalf("RestoreResource() error = %v", err)
+	}
+
+	live, err := client.Dynamic.Resource(certificates).Namespace("team-a").Get(context.Background(), "web-tls", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected certificate web-tls to exist, got error: %v", err)
+	}
+	if version := live.GetLabels()["version"]; version != "backup" {
+		t.Errorf("version label = %q; want %q", version, "backup")
+	}
+}
+
+// TestRestoreResourceUnknownKind tests that kinds the cluster does not serve are reported as errors.
+func TestRestoreResourceUnknownKind(t *testing.T) {
+	manager := NewManager(newTestClient(), logger.NewLogger(os.Stdout, logger.DEBUG))
+	file := writeResourceFile(t, t.TempDir(), "widget.json",
+		`{"kind": "Widget", "resource": {"apiVersion": "example.com/v1", "metadata": {"name": "w", "namespace": "default"}}}`)
+
+	if err := manager.RestoreResource(file, false); err == nil {
+		t.Fatal("expected an error for an unknown kind")
+	}
+}
+
+// TestRestoreResourceMissingNamespace tests that namespaced resources without a namespace are not restored.
+func TestRestoreResourceMissingNamespace(t *testing.T) {
+	manager := NewManager(newTestClient(), logger.NewLogger(os.Stdout, logger.DEBUG))
+	file := writeResourceFile(t, t.TempDir(), "certificate.json",
+		`{"apiVersion": "cert-manager.io/v1", "kind": "Certificate", "metadata": {"name": "web-tls"}}`)
+
+	if err := manager.RestoreResource(file, false); err == nil {
+		t.Fatal("expected an error for a namespaced resource without a namespace")
+	}
+}
+
+// TestRestoreResourceEncryptedSecret tests that encrypted Secrets are decrypted on restore and are not
+// restored without the right passphrase.
+func TestRestoreResourceEncryptedSecret(t *testing.T) {
+	encrypter, err := encryption.NewEncrypter("passphrase")
+	if err != nil {
+		t.Fatalf("NewEncrypter() error = %v", err)
+	}
+	password := base64.StdEncoding.EncodeToString([]byte("hunter2"))
+	secret := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"metadata":   map[string]interface{}{"name": "db", "namespace": "default"},
+		"data":       map[string]interface{}{"password": password},
+	}
+	if err := encrypter.EncryptSecret(secret); err != nil {
+		t.Fatalf("EncryptSecret() error = %v", err)
+	}
+	content, err := json.Marshal(secret)
+	if err != nil {
+		t.Fatalf("failed to marshal secret: %v", err)
+	}
+	file := writeResourceFile(t, t.TempDir(), "db.json", string(content))
+	secrets := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+
+	tests := []struct {
+		name      string
+		opts      []Option
+		expectErr bool
+	}{
+		{name: "No passphrase", expectErr: true},
+		{name: "Wrong passphrase", opts: []Option{WithDecryption(encryption.NewDecrypter("wrong"))}, expectErr: true},
+		{name: "Correct passphrase", opts: []Option{WithDecryption(encryption.NewDecrypter("passphrase"))}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient()
+			manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG), tt.opts...)
+
+			err := manager.RestoreResource(file, false)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("RestoreResource() error = %v; expectErr %v", err, tt.expectErr)
+			}
+
+			restored, err := client.Dynamic.Resource(secrets).Namespace("default").Get(context.Background(), "db", metav1.GetOptions{})
+			if tt.expectErr {
+				if err == nil {
+					t.Error("expected the encrypted secret not to be restored")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected secret db to be created, got error: %v", err)
+			}
+			if value, _, _ := unstructured.NestedString(restored.Object, "data", "password"); value != password {
+				t.Errorf("restored password = %q; want %q", value, password)
+			}
+			if _, ok := restored.GetAnnotations()[encryption.Annotation]; ok {
+				t.Error("expected the encryption annotation to be removed")
+			}
+		})
+	}
+}
+
+// TestRestoreResourceRedacted tests that the placeholders of redacted resources are replaced with the live
+// values, or left unset, instead of being applied.
+func TestRestoreResourceRedacted(t *testing.T) {
+	configMaps := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	live := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "app", "namespace": "default"},
+		"data":       map[string]interface{}{"DB_PASSWORD": "hunter2"},
+	}}
+	tests := []struct {
+		name     string
+		objects  []runtime.Object
+		expected map[string]interface{}
+	}{
+		{name: "Existing resource", objects: []runtime.Object{live}, expected: map[string]interface{}{"DB_PASSWORD": "hunter2", "log_level": "debug"}},
+		{name: "New resource", expected: map[string]interface{}{"log_level": "debug"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(tt.objects...)
+			manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG))
+			file := writeResourceFile(t, t.TempDir(), "app.json", `{"apiVersion": "v1", "kind": "ConfigMap",
+				"metadata": {"name": "app", "namespace": "default", "annotations": {"kube-save-restore.io/redacted": "true"}},
+				"data": {"DB_PASSWORD": "REDACTED", "log_level": "debug"}}`)
+
+			if err := manager.RestoreResource(file, false); err != nil {
+				t.Fatalf("RestoreResource() error = %v", err)
+			}
+			restored, err := client.Dynamic.Resource(configMaps).Namespace("default").Get(context.Background(), "app", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("expected the redacted config map to be restored, got error: %v", err)
+			}
+			if data := restored.Object["data"]; !reflect.DeepEqual(data, tt.expected) {
+				t.Errorf("data = %v; want %v", data, tt.expected)
+			}
+			if _, ok := restored.GetAnnotations()[redact.Annotation]; ok {
+				t.Error("expected the redaction annotation to be removed")
+			}
+		})
+	}
+}
+
+// existingDeployment returns a Deployment named web in namespace team-a, labelled with version.
+func existingDeployment(version string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "web", "namespace": "team-a", "labels": map[string]interface{}{"version": version}},
+	}}
+}
+
+// TestRestoreResourceExistingResourcePolicy tests how each policy handles a resource that already exists.
+func TestRestoreResourceExistingResourcePolicy(t *testing.T) {
+	tests := []struct {
+		policy          ExistingResourcePolicy
+		expectedVersion string
+		expectErr       error
+	}{
+		{policy: ExistingResourceNone, expectedVersion: "live"},
+		{policy: ExistingResourceUpdate, expectedVersion: "backup"},
+		{policy: ExistingResourceFail, expectedVersion: "live", expectErr: ErrResourceExists},
+		{policy: ExistingResourceRecreate, expectedVersion: "backup"},
+	}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	for _, tt := range tests {
+		t.Run(string(tt.policy), func(t *testing.T) {
+			client := newTestClient(existingDeployment("live"))
+			manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG), WithExistingResourcePolicy(tt.policy))
+			content, err := json.Marshal(existingDeployment("backup").Object)
+			if err != nil {
+				t.Fatalf("failed to marshal deployment: %v", err)
+			}
+			file := writeResourceFile(t, t.TempDir(), "web.json", string(content))
+
+			err = manager.RestoreResource(file, false)
+			if !errors.Is(err, tt.expectErr) {
+				t.Fatalf("RestoreResource() error = %v; want %v", err, tt.expectErr)
+			}
+
+			live, err := client.Dynamic.Resource(deployments).Namespace("team-a").Get(context.Background(), "web", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("expected deployment web to exist, got error: %v", err)
+			}
+			if version := live.GetLabels()["version"]; version != tt.expectedVersion {
+				t.Errorf("version label = %q; want %q", version, tt.expectedVersion)
+			}
+		})
+	}
+}
+
+// TestRestoreResourceRecreateKeepsNamespace tests that the ExistingResourceRecreate policy updates an
+// existing Namespace instead of deleting it along with everything in it.
+func TestRestoreResourceRecreateKeepsNamespace(t *testing.T) {
+	namespace := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata":   map[string]interface{}{"name": "team-a", "labels": map[string]interface{}{"version": "live"}},
+	}}
+	client := newTestClient(namespace, existingDeployment("live"))
+	var deleted []string
+	client.Dynamic.(*dynamicfake.FakeDynamicClient).PrependReactor("delete", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		deleted = append(deleted, action.(k8stesting.DeleteAction).GetName())
+		return false, nil, nil
+	})
+	manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG), WithExistingResourcePolicy(ExistingResourceRecreate))
+	file := writeResourceFile(t, t.TempDir(), "team-a.json", `{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "team-a", "labels": {"version": "backup"}}}`)
+
+	if err := manager.RestoreResource(file, false); err != nil {
+		t.Fatalf("RestoreResource() error = %v", err)
+	}
+
+	if len(deleted) != 0 {
+		t.Errorf("expected no deletions, got %v", deleted)
+	}
+	namespaces := schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	live, err := client.Dynamic.Resource(namespaces).Get(context.Background(), "team-a", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected namespace team-a to exist, got error: %v", err)
+	}
+	if version := live.GetLabels()["version"]; version != "backup" {
+		t.Errorf("version label = %q; want %q", version, "backup")
+	}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	if _, err := client.Dynamic.Resource(deployments).Namespace("team-a").Get(context.Background(), "web", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected deployment web to be kept, got error: %v", err)
+	}
+}
+
+// TestRestoreResourceRecreateUpdatesCRD tests that the ExistingResourceRecreate policy updates an existing
+// CustomResourceDefinition with the resourceVersion of its live version instead of deleting it.
+func TestRestoreResourceRecreateUpdatesCRD(t *testing.T) {
+	crds := schema.GroupVersionResource{Group: "apiextensions.k8s.io", Version: "v1", Resource: "customresourcedefinitions"}
+	existing := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": "certificates.cert-manager.io", "resourceVersion": "12", "labels": map[string]interface{}{"version": "live"}},
+	}}
+	client := newTestClient(existing)
+	rejectUnconditionalUpdates(client)
+
+	manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG), WithExistingResourcePolicy(ExistingResourceRecreate))
+	file := writeResourceFile(t, t.TempDir(), "certificates.json", `{"apiVersion": "apiextensions.k8s.io/v1", "kind": "CustomResourceDefinition",
+		"metadata": {"name": "certificates.cert-manager.io", "labels": {"version": "backup"}}}`)
+	if err := manager.RestoreResource(file, false); err != nil {
+		t.Fatalf("RestoreResource() error = %v", err)
+	}
+
+	live, err := client.Dynamic.Resource(crds).Get(context.Background(), "certificates.cert-manager.io", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("expected the CustomResourceDefinition to exist, got error: %v", err)
+	}
+	if version := live.GetLabels()["version"]; version != "backup" {
+		t.Errorf("version label = %q; want %q", version, "backup")
+	}
+}
+
+// TestRestoreResourceExistingResourcePolicyCreates tests that every policy creates missing resources.
+func TestRestoreResourceExistingResourcePolicyCreates(t *testing.T) {
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	for _, policy := range []ExistingResourcePolicy{ExistingResourceNone, ExistingResourceUpdate, ExistingResourceFail, ExistingResourceRecreate} {
+		t.Run(string(policy), func(t *testing.T) {
+			client := newTestClient()
+			manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG), WithExistingResourcePolicy(policy))
+			file := writeResourceFile(t, t.TempDir(), "web.json", `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "namespace": "team-a"}}`)
+
+			if err := manager.RestoreResource(file, false); err != nil {
+				t.Fatalf("RestoreResource() error = %v", err)
+			}
+			if _, err := client.Dynamic.Resource(deployments).Namespace("team-a").Get(context.Background(), "web", metav1.GetOptions{}); err != nil {
+				t.Errorf("expected deployment web to be created, got error: %v", err)
+			}
+		})
+	}
+}
+
+// patchRecorder records the patches sent through a dynamic client, including their options, which the fake
+// dynamic client does not pass on to its reactors.
+type patchRecorder struct {
+	dynamic.Interface
+	patches *[]recordedPatch
+}
+
+// recordedPatch is a patch recorded by patchRecorder
+type recordedPatch struct {
+	patchType types.PatchType
+	options   metav1.PatchOptions
+}
+
+func (r patchRecorder) Resource(gvr schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return recordingResource{NamespaceableResourceInterface: r.Interface.Resource(gvr), patches: r.patches}
+}
+
+type recordingResource struct {
+	dynamic.NamespaceableResourceInterface
+	patches *[]recordedPatch
+}
+
+func (r recordingResource) Namespace(namespace string) dynamic.ResourceInterface {
+	return recordingNamespacedResource{ResourceInterface: r.NamespaceableResourceInterface.Namespace(namespace), patches: r.patches}
+}
+
+func (r recordingResource) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, options metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	*r.patches = append(*r.patches, recordedPatch{patchType: pt, options: options})
+	return r.NamespaceableResourceInterface.Patch(ctx, name, pt, data, options, subresources...)
+}
+
+type recordingNamespacedResource struct {
+	dynamic.ResourceInterface
+	patches *[]recordedPatch
+}
+
+func (r recordingNamespacedResource) Patch(ctx context.Context, name string, pt types.PatchType, data []byte, options metav1.PatchOptions, subresources ...string) (*unstructured.Unstructured, error) {
+	*r.patches = append(*r.patches, recordedPatch{patchType: pt, options: options})
+	return r.ResourceInterface.Patch(ctx, name, pt, data, options, subresources...)
+}
+
+// TestRestoreResourceServerSideApply tests that resources are restored with server-side apply as the
+// kube-save-restore field manager.
+func TestRestoreResourceServerSideApply(t *testing.T) {
+	tests := []struct {
+		name            string
+		opts            []Option
+		expectedVersion string
+		expectPatch     bool
+		expectForce     bool
+	}{
+		{
+			name:            "Apply",
+			opts:            []Option{WithServerSideApply(false)},
+			expectedVersion: "backup",
+			expectPatch:     true,
+		},
+		{
+			name:            "Apply with force conflicts",
+			opts:            []Option{WithServerSideApply(true)},
+			expectedVersion: "backup",
+			expectPatch:     true,
+			expectForce:     true,
+		},
+		{
+			name:            "Existing resource left untouched",
+			opts:            []Option{WithServerSideApply(false), WithExistingResourcePolicy(ExistingResourceNone)},
+			expectedVersion: "live",
+		},
+	}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(existingDeployment("live"))
+			fake := client.Dynamic.(*dynamicfake.FakeDynamicClient)
+			// The fake client cannot merge apply patches, so store the applied object as is
+			fake.PrependReactor("patch", "deployments", func(action k8stesting.Action) (bool, runtime.Object, error) {
+				patch := action.(k8stesting.PatchAction)
+				obj := &unstructured.Unstructured{}
+				if err := obj.UnmarshalJSON(patch.GetPatch()); err != nil {
+					return true, nil, err
+				}
+				if err := fake.Tracker().Update(deployments, obj, patch.GetNamespace()); err != nil {
+					return true, nil, err
+				}
+				return true, obj, nil
+			})
+			var patches []recordedPatch
+			client.Dynamic = patchRecorder{Interface: fake, patches: &patches}
+			manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG), tt.opts...)
+			content, err := json.Marshal(existingDeployment("backup").Object)
+			if err != nil {
+				t.Fatalf("failed to marshal deployment: %v", err)
+			}
+			file := writeResourceFile(t, t.TempDir(), "web.json", string(content))
+
+			if err := manager.RestoreResource(file, false); err != nil {
+				t.Fatalf("RestoreResource() error = %v", err)
+			}
+
+			if !tt.expectPatch {
+				if len(patches) != 0 {
+					t.Errorf("expected no patches, got %d", len(patches))
+				}
+			} else {
+				if len(patches) != 1 {
+					t.Fatalf("expected 1 patch, got %d", len(patches))
+				}
+				patch := patches[0]
+				if patch.patchType != types.ApplyPatchType {
+					t.Errorf("patch type = %s; want %s", patch.patchType, types.ApplyPatchType)
+				}
+				if patch.options.FieldManager != FieldManager {
+					t.Errorf("field manager = %q; want %q", patch.options.FieldManager, FieldManager)
+				}
+				if patch.options.Force == nil || *patch.options.Force != tt.expectForce {
+					t.Errorf("force = %v; want %v", patch.options.Force, tt.expectForce)
+				}
+			}
+
+			live, err := client.Dynamic.Resource(deployments).Namespace("team-a").Get(context.Background(), "web", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("expected deployment web to exist, got error: %v", err)
+			}
+			if version := live.GetLabels()["version"]; version != tt.expectedVersion {
+				t.Errorf("version label = %q; want %q", version, tt.expectedVersion)
+			}
+		})
+	}
+}
+
+// TestPerformRestoreExistingResourceFail tests that the restore is aborted when a resource exists and the
+// policy is ExistingResourceFail.
+func TestPerformRestoreExistingResourceFail(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "namespaces"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.MkdirAll(filepath.Join(dir, "team-a", "deployments"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	writeResourceFile(t, filepath.Join(dir, "namespaces"), "team-a.json", `{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "team-a"}}`)
+	writeResourceFile(t, filepath.Join(dir, "team-a", "deployments"), "web.json", `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "namespace": "team-a"}}`)
+
+	namespace := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Namespace",
+		"metadata":   map[string]interface{}{"name": "team-a"},
+	}}
+	client := newTestClient(namespace)
+	manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG), WithExistingResourcePolicy(ExistingResourceFail))
+
+	if err := manager.PerformRestore(dir, false); !errors.Is(err, ErrResourceExists) {
+		t.Fatalf("PerformRestore() error = %v; want %v", err, ErrResourceExists)
+	}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	if _, err := client.Dynamic.Resource(deployments).Namespace("team-a").Get(context.Background(), "web", metav1.GetOptions{}); err == nil {
+		t.Error("expected the restore to be aborted before deployment web is created")
+	}
+}
+
+// TestRestoreResourceMultiDocumentYAML tests that every document of a multi-document YAML file is restored.
+func TestRestoreResourceMultiDocumentYAML(t *testing.T) {
+	client := newTestClient()
+	manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG))
+	file := writeResourceFile(t, t.TempDir(), "resources.yml", `---
+apiVersion: v1
+kind: Namespace
+metadata:
+  name: team-a
+---
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: team-a
+`)
+
+	files := loadResourceFile(file)
+	if len(files) != 2 {
+		t.Fatalf("expected 2 resources, got %d", len(files))
+	}
+	if files[0].kind != "Namespace" || files[1].kind != "Deployment" {
+		t.Errorf("expected kinds Namespace and Deployment, got %s and %s", files[0].kind, files[1].kind)
+	}
+
+	if err := manager.RestoreResource(file, false); err != nil {
+		t.Fatalf("RestoreResource() error = %v", err)
+	}
+	namespaces := schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	if _, err := client.Dynamic.Resource(namespaces).Get(context.Background(), "team-a", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected namespace team-a to be created, got error: %v", err)
+	}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	if _, err := client.Dynamic.Resource(deployments).Namespace("team-a").Get(context.Background(), "web", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected deployment web to be created, got error: %v", err)
+	}
+}
+
+// TestGetResourceFiles tests that JSON and YAML files are collected and other files are ignored.
+func TestGetResourceFiles(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.json", "b.yaml", "c.yml", "README.md", "manifest.json"} {
+		writeResourceFile(t, dir, name, "{}")
+	}
+
+	files, err := getResourceFiles(context.Background(), storage.NewLocal(""), dir)
+	if err != nil {
+		t.Fatalf("getResourceFiles() error = %v", err)
+	}
+
+	var names []string
+	for _, file := range files {
+		names = append(names, filepath.Base(file))
+	}
+	if strings.Join(names, ",") != "a.json,b.yaml,c.yml" {
+		t.Errorf("getResourceFiles() = %v; want [a.json b.yaml c.yml]", names)
+	}
+}
+
+// TestPerformRestoreStorage tests that backups are read from the configured storage, named relative to its root.
+func TestPerformRestoreStorage(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "k8s-backup-20240101-000000", "team-a", "deployments")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	writeResourceFile(t, dir, "web.json", `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "namespace": "team-a"}}`)
+
+	client := newTestClient()
+	manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG), WithStorage(storage.NewLocal(root)))
+	if err := manager.PerformRestore("k8s-backup-20240101-000000", false); err != nil {
+		t.Fatalf("PerformRestore() error = %v", err)
+	}
+
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	if _, err := client.Dynamic.Resource(deployments).Namespace("team-a").Get(context.Background(), "web", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected deployment web to be created, got error: %v", err)
+	}
+}
+
+// TestCheckRoleBindingReferences tests that role bindings referencing objects missing from the backup are reported.
+func TestCheckRoleBindingReferences(t *testing.T) {
+	dir := t.TempDir()
+	files := []string{
+		writeResourceFile(t, dir, "sa.json", `{"apiVersion": "v1", "kind": "ServiceAccount", "metadata": {"name": "app", "namespace": "default"}}`),
+		writeResourceFile(t, dir, "role.json", `{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "Role", "metadata": {"name": "reader", "namespace": "default"}}`),
+		writeResourceFile(t, dir, "complete.json", `{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "RoleBinding",
+			"metadata": {"name": "complete", "namespace": "default"},
+			"roleRef": {"apiGroup": "rbac.authorization.k8s.io", "kind": "Role", "name": "reader"},
+			"subjects": [{"kind": "ServiceAccount", "name": "app"}]}`),
+		writeResourceFile(t, dir, "dangling.json", `{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "RoleBinding",
+			"metadata": {"name": "dangling", "namespace": "default"},
+			"roleRef": {"apiGroup": "rbac.authorization.k8s.io", "kind": "Role", "name": "writer"},
+			"subjects": [{"kind": "ServiceAccount", "name": "worker", "namespace": "jobs"}, {"kind": "User", "name": "alice"}]}`),
+	}
+
+	var buf bytes.Buffer
+	manager := NewManager(nil, logger.NewLogger(&buf, logger.DEBUG))
+	manager.checkRoleBindingReferences(loadResourceFiles(files))
+
+	output := buf.String()
+	if strings.Contains(output, "RoleBinding default/complete") {
+		t.Errorf("unexpected warning for complete role binding: %s", output)
+	}
+	if !strings.Contains(output, "RoleBinding default/dangling references Role default/writer") {
+		t.Errorf("expected a warning for the missing role, got: %s", output)
+	}
+	if !strings.Contains(output, "RoleBinding default/dangling references ServiceAccount jobs/worker") {
+		t.Errorf("expected a warning for the missing service account, got: %s", output)
+	}
+	if strings.Contains(output, "alice") {
+		t.Errorf("unexpected warning for a user subject: %s", output)
+	}
+}
+
+// TestSelectResourcesNamespaceFilter tests that resources are filtered by their namespace before being restored.
+func TestSelectResourcesNamespaceFilter(t *testing.T) {
+	dir := t.TempDir()
+	files := loadResourceFiles([]string{
+		writeResourceFile(t, dir, "ns-tenant.json", `{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "tenant-a"}}`),
+		writeResourceFile(t, dir, "ns-system.json", `{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "kube-system"}}`),
+		writeResourceFile(t, dir, "cm-tenant.json", `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "a", "namespace": "tenant-a"}}`),
+		writeResourceFile(t, dir, "cm-system.json", `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "b", "namespace": "kube-system"}}`),
+		writeResourceFile(t, dir, "clusterrole.json", `{"apiVersion": "rbac.authorization.k8s.io/v1", "kind": "ClusterRole", "metadata": {"name": "view"}}`),
+		writeResourceFile(t, dir, "clusterissuer.json", `{"apiVersion": "cert-manager.io/v1", "kind": "ClusterIssuer", "metadata": {"name": "letsencrypt"}}`),
+		writeResourceFile(t, dir, "broken.json", `{not json`),
+	})
+
+	namespaceFilter, err := filter.New(nil, []string{"kube-*"})
+	if err != nil {
+		t.Fatalf("filter.New() error = %v", err)
+	}
+	tests := []struct {
+		name     string
+		opts     []Option
+		expected []string
+	}{
+		{
+			name:     "Namespace filter",
+			opts:     []Option{WithNamespaceFilter(namespaceFilter)},
+			expected: []string{"ns-tenant.json", "cm-tenant.json", "broken.json"},
+		},
+		{
+			name:     "Namespace filter with cluster resources",
+			opts:     []Option{WithNamespaceFilter(namespaceFilter), WithClusterResources()},
+			expected: []string{"ns-tenant.json", "cm-tenant.json", "clusterrole.json", "clusterissuer.json", "broken.json"},
+		},
+		{
+			name:     "Empty namespace filter",
+			opts:     []Option{WithNamespaceFilter(&filter.Filter{})},
+			expected: []string{"ns-tenant.json", "ns-system.json", "cm-tenant.json", "cm-system.json", "clusterrole.json", "clusterissuer.json", "broken.json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := NewManager(newTestClient(), logger.NewLogger(os.Stdout, logger.DEBUG), tt.opts...)
+
+			var selected []string
+			for _, file := range manager.selectResources(files) {
+				selected = append(selected, filepath.Base(file.path))
+			}
+			if strings.Join(selected, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("selectResources() = %v; want %v", selected, tt.expected)
+			}
+		})
+	}
+}
+
+// TestSelectResourcesNamespaceFilterClusterScoped tests that cluster-scoped resources, including custom
+// resources, are selected whatever the namespaces included when cluster resources are requested.
+func TestSelectResourcesNamespaceFilterClusterScoped(t *testing.T) {
+	dir := t.TempDir()
+	files := loadResourceFiles([]string{
+		writeResourceFile(t, dir, "clusterissuer.json", `{"apiVersion": "cert-manager.io/v1", "kind": "ClusterIssuer", "metadata": {"name": "letsencrypt"}}`),
+		writeResourceFile(t, dir, "certificate-tenant.json", `{"apiVersion": "cert-manager.io/v1", "kind": "Certificate", "metadata": {"name": "a", "namespace": "tenant-a"}}`),
+		writeResourceFile(t, dir, "certificate-system.json", `{"apiVersion": "cert-manager.io/v1", "kind": "Certificate", "metadata": {"name": "b", "namespace": "kube-system"}}`),
+	})
+
+	namespaceFilter, err := filter.New([]string{"tenant-*"}, nil)
+	if err != nil {
+		t.Fatalf("filter.New() error = %v", err)
+	}
+	manager := NewManager(newTestClient(), logger.NewLogger(os.Stdout, logger.DEBUG), WithNamespaceFilter(namespaceFilter), WithClusterResources())
+
+	var selected []string
+	for _, file := range manager.selectResources(files) {
+		selected = append(selected, filepath.Base(file.path))
+	}
+
+	expected := []string{"clusterissuer.json", "certificate-tenant.json"}
+	if strings.Join(selected, ",") != strings.Join(expected, ",") {
+		t.Errorf("selectResources() = %v; want %v", selected, expected)
+	}
+}
+
+// TestSelectResourcesResourceFilter tests that resources are filtered by their type before being restored.
+func TestSelectResourcesResourceFilter(t *testing.T) {
+	dir := t.TempDir()
+	for _, sub := range []string{"secrets", "jobs", "configmaps", "certificates.cert-manager.io", "pvcs"} {
+		if err := os.MkdirAll(filepath.Join(dir, sub), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+	}
+	files := loadResourceFiles([]string{
+		writeResourceFile(t, dir, "secrets/token.json", `{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "token", "namespace": "default"}}`),
+		writeResourceFile(t, dir, "jobs/migrate.json", `{"apiVersion": "batch/v1", "kind": "Job", "metadata": {"name": "migrate", "namespace": "default"}}`),
+		writeResourceFile(t, dir, "configmaps/settings.json", `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "settings", "namespace": "default"}}`),
+		writeResourceFile(t, dir, "certificates.cert-manager.io/tls.json", `{"apiVersion": "cert-manager.io/v1", "kind": "Certificate", "metadata": {"name": "tls", "namespace": "default"}}`),
+		writeResourceFile(t, dir, "pvcs/data.json", `{"apiVersion": "v1", "kind": "PersistentVolumeClaim", "metadata": {"name": "data", "namespace": "default"}}`),
+	})
+
+	tests := []struct {
+		name     string
+		include  []string
+		exclude  []string
+		expected []string
+	}{
+		{name: "Exclude by directory name", exclude: []string{"secrets", "jobs"}, expected: []string{"settings.json", "tls.json", "data.json"}},
+		{name: "Include by kind", include: []string{"configmap"}, expected: []string{"settings.json"}},
+		{name: "Include custom resource without group", include: []string{"certificates"}, expected: []string{"tls.json"}},
+		{name: "Exclude by plural resource name of abbreviated directory", exclude: []string{"persistentvolumeclaims"}, expected: []string{"token.json", "migrate.json", "settings.json", "tls.json"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resourceFilter, err := filter.New(tt.include, tt.exclude)
+			if err != nil {
+				t.Fatalf("filter.New() error = %v", err)
+			}
+			manager := NewManager(newTestClient(), logger.NewLogger(os.Stdout, logger.DEBUG), WithResourceFilter(resourceFilter))
+
+			var selected []string
+			for _, file := range manager.selectResources(files) {
+				selected = append(selected, filepath.Base(file.path))
+			}
+			if strings.Join(selected, ",") != strings.Join(tt.expected, ",") {
+				t.Errorf("selectResources() = %v; want %v", selected, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSelectResourcesLabelSelector(t *testing.T) {
+	dir := t.TempDir()
+	files := loadResourceFiles([]string{
+		writeResourceFile(t, dir, "shop.json", `{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "shop"}}`),
+		writeResourceFile(t, dir, "web.json", `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "namespace": "shop", "labels": {"app.kubernetes.io/part-of": "shop"}}}`),
+		writeResourceFile(t, dir, "blog.json", `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "blog", "namespace": "shop", "labels": {"app.kubernetes.io/part-of": "blog"}}}`),
+		writeResourceFile(t, dir, "settings.json", `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "settings", "namespace": "shop"}}`),
+	})
+
+	selector, err := labels.Parse("app.kubernetes.io/part-of=shop")
+	if err != nil {
+		t.Fatalf("labels.Parse() error = %v", err)
+	}
+	manager := NewManager(newTestClient(), logger.NewLogger(os.Stdout, logger.DEBUG), WithLabelSelector(selector))
+
+	var selected []string
+	for _, file := range manager.selectResources(files) {
+		selected = append(selected, filepath.Base(file.path))
+	}
+	expected := []string{"shop.json", "web.json"}
+	if strings.Join(selected, ",") != strings.Join(expected, ",") {
+		t.Errorf("selectResources() = %v; want %v", selected, expected)
+	}
+}
+
+// writeArchive writes the given files into a compressed tar archive at path.
+func writeArchive(t *testing.T, path string, files map[string]string) {
+	t.Helper()
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create archive: %v", err)
+	}
+	defer file.Close()
+
+	var compressor io.WriteCloser
+	if strings.HasSuffix(path, ".tar.zst") {
+		if compressor, err = zstd.NewWriter(file); err != nil {
+			t.Fatalf("failed to create zstd writer: %v", err)
+		}
+	} else {
+		compressor = gzip.NewWriter(file)
+	}
+	archive := tar.NewWriter(compressor)
+	for name, content := range files {
+		if err := archive.WriteHeader(&tar.Header{Name: name, Mode: 0600, Size: int64(len(content))}); err != nil {
+			t.Fatalf("failed to write archive header: %v", err)
+		}
+		if _, err := archive.Write([]byte(content)); err != nil {
+			t.Fatalf("failed to write archive entry: %v", err)
+		}
+	}
+	if err := archive.Close(); err != nil {
+		t.Fatalf("failed to close archive: %v", err)
+	}
+	if err := compressor.Close(); err != nil {
+		t.Fatalf("failed to close compressor: %v", err)
+	}
+}
+
+// TestVerifyBackup tests that backup directories and archives are verified against their manifest.
+func TestVerifyBackup(t *testing.T) {
+	content := `{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "team-a"}}`
+	m := &manifest.Manifest{Files: []manifest.File{manifest.NewFile("namespaces/team-a.json", "Namespace", "", "team-a", []byte(content))}}
+	manifestData, err := m.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+
+	tests := []struct {
+		name             string
+		backup           string
+		files            map[string]string
+		expectedProblems int
+	}{
+		{
+			name:   "Directory",
+			backup: "backup",
+			files:  map[string]string{manifest.Filename: string(manifestData), "namespaces/team-a.json": content},
+		},
+		{
+			name:   "Gzip archive",
+			backup: "backup.tar.gz",
+			files:  map[string]string{manifest.Filename: string(manifestData), "namespaces/team-a.json": content},
+		},
+		{
+			name:   "Zstandard archive",
+			backup: "backup.tar.zst",
+			files:  map[string]string{manifest.Filename: string(manifestData), "namespaces/team-a.json": content},
+		},
+		{
+			name:             "Modified archive",
+			backup:           "backup.tar.gz",
+			files:            map[string]string{manifest.Filename: string(manifestData), "namespaces/team-a.json": content[:10]},
+			expectedProblems: 1,
+		},
+		{
+			name:             "Archive with an injected file",
+			backup:           "backup.tar.zst",
+			files:            map[string]string{manifest.Filename: string(manifestData), "namespaces/team-a.json": content, "namespaces/team-b.json": content},
+			expectedProblems: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backupPath := filepath.Join(t.TempDir(), tt.backup)
+			if storage.IsArchive(backupPath) {
+				writeArchive(t, backupPath, tt.files)
+			} else {
+				for name, data := range tt.files {
+					path := filepath.Join(backupPath, filepath.FromSlash(name))
+					if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+						t.Fatalf("failed to create directory: %v", err)
+					}
+					writeResourceFile(t, filepath.Dir(path), filepath.Base(path), data)
+				}
+			}
+
+			verified, problems, err := VerifyBackup(context.Background(), storage.NewLocal(""), backupPath, nil)
+			if err != nil {
+				t.Fatalf("VerifyBackup() error = %v", err)
+			}
+			if len(verified.Files) != 1 {
+				t.Errorf("manifest lists %d files; want 1", len(verified.Files))
+			}
+			if len(problems) != tt.expectedProblems {
+				t.Errorf("VerifyBackup() problems = %v; want %d", problems, tt.expectedProblems)
+			}
+		})
+	}
+}
+
+// TestPerformRestoreArchive tests that backups are restored directly from compressed archives.
+func TestPerformRestoreArchive(t *testing.T) {
+	for _, name := range []string{"backup.tar.gz", "backup.tar.zst"} {
+		t.Run(name, func(t *testing.T) {
+			archivePath := filepath.Join(t.TempDir(), name)
+			writeArchive(t, archivePath, map[string]string{
+				"namespaces/team-a.json":      `{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "team-a"}}`,
+				"team-a/deployments/web.yaml": "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: web\n  namespace: team-a\n",
+				"README.md":                   "not a resource",
+			})
+
+			client := newTestClient()
+			manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG))
+			files, err := manager.loadBackup(archivePath)
+			if err != nil {
+				t.Fatalf("loadBackup() error = %v", err)
+			}
+			if len(files) != 2 {
+				t.Fatalf("expected 2 resources, got %d", len(files))
+			}
+
+			waves := groupWaves(files, DefaultRestoreOrder)
+			if len(waves) != 2 || waves[0].files[0].kind != "Namespace" || waves[1].files[0].kind != "Deployment" {
+				t.Fatalf("expected the namespace to be restored before the deployment, got waves %v", waves)
+			}
+
+			if err := manager.PerformRestore(archivePath, false); err != nil {
+				t.Fatalf("PerformRestore() error = %v", err)
+			}
+			deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+			if _, err := client.Dynamic.Resource(deployments).Namespace("team-a").Get(context.Background(), "web", metav1.GetOptions{}); err != nil {
+				t.Errorf("expected deployment web to be created, got error: %v", err)
+			}
+		})
+	}
+}
+
+// writeSignedBackup writes files to dir along with a manifest signed with key.
+func writeSignedBackup(t *testing.T, dir string, files map[string]string, key ed25519.PrivateKey) {
+	t.Helper()
+	m := &manifest.Manifest{}
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+		writeResourceFile(t, filepath.Dir(path), filepath.Base(path), content)
+		m.Files = append(m.Files, manifest.NewFile(name, "", "", "", []byte(content)))
+	}
+	data, err := m.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	writeResourceFile(t, dir, manifest.Filename, string(data))
+	writeResourceFile(t, dir, manifest.SignatureFilename, string(manifest.Sign(data, key)))
+}
+
+// TestVerifyBackupSignature tests that verifying with a key checks the manifest signature of directories and archives.
+func TestVerifyBackupSignature(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	otherPublicKey, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	content := `{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "team-a"}}`
+	m := &manifest.Manifest{Files: []manifest.File{manifest.NewFile("namespaces/team-a.json", "Namespace", "", "team-a", []byte(content))}}
+	manifestData, err := m.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	signature := string(manifest.Sign(manifestData, privateKey))
+
+	tests := []struct {
+		name      string
+		backup    string
+		files     map[string]string
+		key       ed25519.PublicKey
+		expectErr bool
+	}{
+		{
+			name:   "Signed directory",
+			backup: "backup",
+			files:  map[string]string{manifest.Filename: string(manifestData), manifest.SignatureFilename: signature, "namespaces/team-a.json": content},
+			key:    publicKey,
+		},
+		{
+			name:   "Signed archive",
+			backup: "backup.tar.gz",
+			files:  map[string]string{manifest.Filename: string(manifestData), manifest.SignatureFilename: signature, "namespaces/team-a.json": content},
+			key:    publicKey,
+		},
+		{
+			name:      "Wrong key",
+			backup:    "backup.tar.zst",
+			files:     map[string]string{manifest.Filename: string(manifestData), manifest.SignatureFilename: signature, "namespaces/team-a.json": content},
+			key:       otherPublicKey,
+			expectErr: true,
+		},
+		{
+			name:      "Tampered manifest",
+			backup:    "backup",
+			files:     map[string]string{manifest.Filename: `{"files": []}`, manifest.SignatureFilename: signature, "namespaces/team-a.json": content},
+			key:       publicKey,
+			expectErr: true,
+		},
+		{
+			name:      "Unsigned archive",
+			backup:    "backup.tar.gz",
+			files:     map[string]string{manifest.Filename: string(manifestData), "namespaces/team-a.json": content},
+			key:       publicKey,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			backupPath := filepath.Join(t.TempDir(), tt.backup)
+			if storage.IsArchive(backupPath) {
+				writeArchive(t, backupPath, tt.files)
+			} else {
+				for name, data := range tt.files {
+					path := filepath.Join(backupPath, filepath.FromSlash(name))
+					if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+						t.Fatalf("failed to create directory: %v", err)
+					}
+					writeResourceFile(t, filepath.Dir(path), filepath.Base(path), data)
+				}
+			}
+
+			verified, problems, err := VerifyBackup(context.Background(), storage.NewLocal(""), backupPath, tt.key)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("VerifyBackup() error = %v; expectErr %v", err, tt.expectErr)
+			}
+			if err == nil && (len(verified.Files) != 1 || len(problems) != 0) {
+				t.Errorf("VerifyBackup() = %d files, problems %v; want 1 file and no problems", len(verified.Files), problems)
+			}
+		})
+	}
+}
+
+// TestPerformRestoreVerifyKey tests that restores with a verification key refuse unsigned or tampered backups.
+func TestPerformRestoreVerifyKey(t *testing.T) {
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	_, otherPrivateKey, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	files := map[string]string{
+		"namespaces/team-a.json":      `{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "team-a"}}`,
+		"team-a/deployments/web.json": `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "namespace": "team-a"}}`,
+	}
+
+	tests := []struct {
+		name      string
+		setup     func(dir string)
+		expectErr bool
+	}{
+		{
+			name:  "Signed backup",
+			setup: func(dir string) { writeSignedBackup(t, dir, files, privateKey) },
+		},
+		{
+			name: "Unsigned backup",
+			setup: func(dir string) {
+				writeSignedBackup(t, dir, files, privateKey)
+				if err := os.Remove(filepath.Join(dir, manifest.SignatureFilename)); err != nil {
+					t.Fatalf("failed to remove signature: %v", err)
+				}
+			},
+			expectErr: true,
+		},
+		{
+			name:      "Signed with another key",
+			setup:     func(dir string) { writeSignedBackup(t, dir, files, otherPrivateKey) },
+			expectErr: true,
+		},
+		{
+			name: "Modified file",
+			setup: func(dir string) {
+				writeSignedBackup(t, dir, files, privateKey)
+				writeResourceFile(t, filepath.Join(dir, "team-a", "deployments"), "web.json",
+					`{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "namespace": "team-a", "labels": {"evil": "true"}}}`)
+			},
+			expectErr: true,
+		},
+		{
+			name: "Injected file",
+			setup: func(dir string) {
+				writeSignedBackup(t, dir, files, privateKey)
+				writeResourceFile(t, filepath.Join(dir, "team-a", "deployments"), "miner.json",
+					`{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "miner", "namespace": "team-a"}}`)
+			},
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			tt.setup(dir)
+
+			client := newTestClient()
+			manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG), WithVerifyKey(publicKey))
+			err := manager.PerformRestore(dir, false)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("PerformRestore() error = %v; expectErr %v", err, tt.expectErr)
+			}
+
+			deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+			_, err = client.Dynamic.Resource(deployments).Namespace("team-a").Get(context.Background(), "web", metav1.GetOptions{})
+			if tt.expectErr && err == nil {
+				t.Error("expected nothing to be restored from a rejected backup")
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("expected deployment web to be created, got error: %v", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package restore
+
+import (
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// checkRoleBindingReferences warns about role bindings in the backup that reference a ServiceAccount
+// or Role that is missing from the backup. Such bindings are still restored, but grant nothing
+// until the referenced objects are created by other means.
+func (m *Manager) checkRoleBindingReferences(files []resourceFile) {
+	serviceAccounts := make(map[string]bool)
+	roles := make(map[string]bool)
+	var roleBindings []rbacv1.RoleBinding
+
+	for _, file := range files {
+		// Unreadable files are reported when they are restored
+		if file.err != nil {
+			continue
+		}
+		resource := file.resource
+		name, namespace, _ := getResourceIdentifiers(resource)
+
+		switch file.kind {
+		case "ServiceAccount":
+			serviceAccounts[namespace+"/"+name] = true
+		case "Role":
+			roles[namespace+"/"+name] = true
+		case "RoleBinding":
+			var roleBinding rbacv1.RoleBinding
+			if err := runtime.DefaultUnstructuredConverter.FromUnstructured(resource, &roleBinding); err != nil {
+				m.logger.Warnf("Could not check references of role binding %s/%s: %v", namespace, name, err)
+				continue
+			}
+			roleBindings = append(roleBindings, roleBinding)
+		}
+	}
+
+	for _, roleBinding := range roleBindings {
+		if roleBinding.RoleRef.Kind == "Role" && !roles[roleBinding.Namespace+"/"+roleBinding.RoleRef.Name] {
+			m.logger.Warnf("RoleBinding %s/%s references Role %s/%s which is missing from the backup",
+				roleBinding.Namespace, roleBinding.Name, roleBinding.Namespace, roleBinding.RoleRef.Name)
+		}
+
+		for _, subject := range roleBinding.Subjects {
+			if subject.Kind != rbacv1.ServiceAccountKind {
+				continue
+			}
+			// Subjects without a namespace refer to the namespace of the binding
+			namespace := subject.Namespace
+			if namespace == "" {
+				namespace = roleBinding.Namespace
+			}
+			if !serviceAccounts[namespace+"/"+subject.Name] {
+				m.logger.Warnf("RoleBinding %s/%s references ServiceAccount %s/%s which is missing from the backup",
+					roleBinding.Namespace, roleBinding.Name, namespace, subject.Name)
+			}
+		}
+	}
+}
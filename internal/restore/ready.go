@@ -0,0 +1,248 @@
+package restore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// ErrNotReady is returned when restored resources do not become ready before the wait timeout.
+var ErrNotReady = errors.New("restored resources are not ready")
+
+// readyPollInterval is how often the readiness of restored resources is checked
+const readyPollInterval = 2 * time.Second
+
+// readiness is the state of a restored resource
+type readiness struct {
+	// ready is set once the resource is healthy
+	ready bool
+	// failed is set when the resource will not become ready without intervention, such as a failed Job
+	failed bool
+	// message describes why the resource is not ready
+	message string
+}
+
+// readinessChecks holds the readiness checks of the kinds restore waits for, by kind
+var readinessChecks = map[string]func(obj *unstructured.Unstructured) readiness{
+	"Deployment":            deploymentReadiness,
+	"StatefulSet":           statefulSetReadiness,
+	"DaemonSet":             daemonSetReadiness,
+	"PersistentVolumeClaim": claimReadiness,
+	"Job":                   jobReadiness,
+}
+
+// restoredResource is a resource restored by the current restore
+type restoredResource struct {
+	obj   *unstructured.Unstructured
+	state readiness
+}
+
+// String identifies a restored resource for logging
+func (r *restoredResource) String() string {
+	return fmt.Sprintf("%s %s/%s", r.obj.GetKind(), r.obj.GetNamespace(), r.obj.GetName())
+}
+
+// waitForReady waits until the restored resources are ready, or until the wait timeout expires, and logs
+// a readiness report. It returns an error wrapping ErrNotReady if any resource is not ready.
+func (m *Manager) waitForReady(ctx context.Context) error {
+	watched := m.restoredResources()
+	if len(watched) == 0 {
+		return nil
+	}
+
+	m.logger.Infof("Waiting up to %s for %d restored resources to become ready", m.wait, len(watched))
+	err := wait.PollUntilContextTimeout(ctx, readyPollInterval, m.wait, true, func(ctx context.Context) (bool, error) {
+		done := true
+		for _, resource := range watched {
+			if resource.state.ready || resource.state.failed {
+				continue
+			}
+			resource.state = m.checkReadiness(ctx, resource.obj)
+			if !resource.state.ready && !resource.state.failed {
+				done = false
+			}
+		}
+		return done, nil
+	})
+	if err != nil && !wait.Interrupted(err) {
+		return err
+	}
+
+	// Report the resources that did not become ready
+	var notReady int
+	for _, resource := range watched {
+		if resource.state.ready {
+			m.logger.Debugf("%s is ready", resource)
+			continue
+		}
+		notReady++
+		m.logger.Errorf("%s is not ready: %s", resource, resource.state.message)
+	}
+	m.logger.Infof("Readiness report: %d of %d restored resources are ready", len(watched)-notReady, len(watched))
+	if notReady > 0 {
+		return fmt.Errorf("%w: %d of %d did not become ready within %s", ErrNotReady, notReady, len(watched), m.wait)
+	}
+	return nil
+}
+
+// checkReadiness gets the live version of a restored resource and checks whether it is ready
+func (m *Manager) checkReadiness(ctx context.Context, obj *unstructured.Unstructured) readiness {
+	resourceClient, _, err := m.k8sClient.ResourceFor(obj)
+	if err != nil {
+		return readiness{failed: true, message: err.Error()}
+	}
+	live, err := resourceClient.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return readiness{message: fmt.Sprintf("error getting resource: %v", err)}
+	}
+	return readinessChecks[obj.GetKind()](live)
+}
+
+// recordRestored remembers a restored resource of a kind with a readiness check, so that the restore can
+// wait for it to become ready
+func (m *Manager) recordRestored(resource map[string]interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.restored = append(m.restored, &restoredResource{obj: resourceReference(resource)})
+}
+
+// restoredResources returns the resources restored by the current restore
+func (m *Manager) restoredResources() []*restoredResource {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.restored
+}
+
+// nestedInt returns an integer field of obj and whether it is set. Resources read from backup files hold
+// their numbers as float64, and resources decoded by client-go as int64, so both are accepted.
+func nestedInt(obj *unstructured.Unstructured, fields ...string) (int64, bool) {
+	value, _, _ := unstructured.NestedFieldNoCopy(obj.Object, fields...)
+	switch value := value.(type) {
+	case int64:
+		return value, true
+	case float64:
+		return int64(value), true
+	}
+	return 0, false
+}
+
+// generationObserved reports whether the controller of obj has seen its latest spec
+func generationObserved(obj *unstructured.Unstructured) bool {
+	observed, _ := nestedInt(obj, "status", "observedGeneration")
+	generation, _ := nestedInt(obj, "metadata", "generation")
+	return observed >= generation
+}
+
+// desiredReplicas returns the number of replicas of a Deployment or StatefulSet, which defaults to 1
+func desiredReplicas(obj *unstructured.Unstructured) int64 {
+	replicas, found := nestedInt(obj, "spec", "replicas")
+	if !found {
+		return 1
+	}
+	return replicas
+}
+
+// statusInt returns an integer field of the status of obj, or 0 if it is not set
+func statusInt(obj *unstructured.Unstructured, field string) int64 {
+	value, _ := nestedInt(obj, "status", field)
+	return value
+}
+
+// condition returns the status and reason of a condition of obj, or empty strings if it is not set
+func condition(obj *unstructured.Unstructured, conditionType string) (string, string) {
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	for _, c := range conditions {
+		c, _ := c.(map[string]interface{})
+		if c["type"] == conditionType {
+			status, _ := c["status"].(string)
+			reason, _ := c["reason"].(string)
+			return status, reason
+		}
+	}
+	return "", ""
+}
+
+// deploymentReadiness checks whether the rollout of a Deployment is complete, like kubectl rollout status
+func deploymentReadiness(obj *unstructured.Unstructured) readiness {
+	if !generationObserved(obj) {
+		return readiness{message: "waiting for the deployment to be observed"}
+	}
+	if _, reason := condition(obj, "Progressing"); reason == "ProgressDeadlineExceeded" {
+		return readiness{failed: true, message: "progress deadline exceeded"}
+	}
+	replicas := desiredReplicas(obj)
+	updated := statusInt(obj, "updatedReplicas")
+	available := statusInt(obj, "availableReplicas")
+	switch {
+	case updated < replicas:
+		return readiness{message: fmt.Sprintf("%d of %d replicas updated", updated, replicas)}
+	case statusInt(obj, "replicas") > updated:
+		return readiness{message: fmt.Sprintf("%d old replicas pending termination", statusInt(obj, "replicas")-updated)}
+	case available < updated:
+		return readiness{message: fmt.Sprintf("%d of %d updated replicas available", available, updated)}
+	}
+	return readiness{ready: true}
+}
+
+// statefulSetReadiness checks whether all replicas of a StatefulSet are ready and updated
+func statefulSetReadiness(obj *unstructured.Unstructured) readiness {
+	if !generationObserved(obj) {
+		return readiness{message: "waiting for the statefulset to be observed"}
+	}
+	replicas := desiredReplicas(obj)
+	if ready := statusInt(obj, "readyReplicas"); ready < replicas {
+		return readiness{message: fmt.Sprintf("%d of %d replicas ready", ready, replicas)}
+	}
+	strategy, _, _ := unstructured.NestedString(obj.Object, "spec", "updateStrategy", "type")
+	if strategy != "OnDelete" {
+		if updated := statusInt(obj, "updatedReplicas"); updated < replicas {
+			return readiness{message: fmt.Sprintf("%d of %d replicas updated", updated, replicas)}
+		}
+	}
+	return readiness{ready: true}
+}
+
+// daemonSetReadiness checks whether the pods of a DaemonSet are updated and available on every node
+func daemonSetReadiness(obj *unstructured.Unstructured) readiness {
+	if !generationObserved(obj) {
+		return readiness{message: "waiting for the daemonset to be observed"}
+	}
+	desired := statusInt(obj, "desiredNumberScheduled")
+	if updated := statusInt(obj, "updatedNumberScheduled"); updated < desired {
+		return readiness{message: fmt.Sprintf("%d of %d pods updated", updated, desired)}
+	}
+	if available := statusInt(obj, "numberAvailable"); available < desired {
+		return readiness{message: fmt.Sprintf("%d of %d pods available", available, desired)}
+	}
+	return readiness{ready: true}
+}
+
+// claimReadiness checks whether a PersistentVolumeClaim is bound to a volume
+func claimReadiness(obj *unstructured.Unstructured) readiness {
+	phase, _, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	switch phase {
+	case "Bound":
+		return readiness{ready: true}
+	case "Lost":
+		return readiness{failed: true, message: "the bound volume was lost"}
+	case "":
+		return readiness{message: "waiting for the claim to be bound"}
+	}
+	return readiness{message: "phase " + phase}
+}
+
+// jobReadiness checks whether a Job has completed
+func jobReadiness(obj *unstructured.Unstructured) readiness {
+	if status, _ := condition(obj, "Complete"); status == "True" {
+		return readiness{ready: true}
+	}
+	if status, reason := condition(obj, "Failed"); status == "True" {
+		return readiness{failed: true, message: "job failed: " + reason}
+	}
+	return readiness{message: fmt.Sprintf("%d pods succeeded", statusInt(obj, "succeeded"))}
+}
@@ -0,0 +1,153 @@
+package restore
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chaoscypher/kube-save-restore/internal/logger"
+	"github.com/chaoscypher/kube-save-restore/internal/sanitize"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestReadinessChecks(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource string
+		ready    bool
+		failed   bool
+	}{
+		{
+			name:     "Deployment rolled out",
+			resource: `{"kind": "Deployment", "metadata": {"generation": 2}, "spec": {"replicas": 3}, "status": {"observedGeneration": 2, "replicas": 3, "updatedReplicas": 3, "availableReplicas": 3}}`,
+			ready:    true,
+		},
+		{
+			name:     "Deployment not observed",
+			resource: `{"kind": "Deployment", "metadata": {"generation": 2}, "spec": {"replicas": 3}, "status": {"observedGeneration": 1, "replicas": 3, "updatedReplicas": 3, "availableReplicas": 3}}`,
+		},
+		{
+			name:     "Deployment with unavailable replicas",
+			resource: `{"kind": "Deployment", "spec": {"replicas": 3}, "status": {"replicas": 3, "updatedReplicas": 3, "availableReplicas": 1}}`,
+		},
+		{
+			name:     "Deployment past its progress deadline",
+			resource: `{"kind": "Deployment", "status": {"conditions": [{"type": "Progressing", "status": "False", "reason": "ProgressDeadlineExceeded"}]}}`,
+			failed:   true,
+		},
+		{
+			name:     "StatefulSet ready",
+			resource: `{"kind": "StatefulSet", "spec": {"replicas": 2}, "status": {"readyReplicas": 2, "updatedReplicas": 2}}`,
+			ready:    true,
+		},
+		{
+			name:     "StatefulSet with OnDelete strategy",
+			resource: `{"kind": "StatefulSet", "spec": {"replicas": 2, "updateStrategy": {"type": "OnDelete"}}, "status": {"readyReplicas": 2}}`,
+			ready:    true,
+		},
+		{
+			name:     "StatefulSet not ready",
+			resource: `{"kind": "StatefulSet", "spec": {"replicas": 2}, "status": {"readyReplicas": 1, "updatedReplicas": 2}}`,
+		},
+		{
+			name:     "DaemonSet available",
+			resource: `{"kind": "DaemonSet", "status": {"desiredNumberScheduled": 4, "updatedNumberScheduled": 4, "numberAvailable": 4}}`,
+			ready:    true,
+		},
+		{
+			name:     "DaemonSet rolling out",
+			resource: `{"kind": "DaemonSet", "status": {"desiredNumberScheduled": 4, "updatedNumberScheduled": 2, "numberAvailable": 4}}`,
+		},
+		{
+			name:     "PersistentVolumeClaim bound",
+			resource: `{"kind": "PersistentVolumeClaim", "status": {"phase": "Bound"}}`,
+			ready:    true,
+		},
+		{
+			name:     "PersistentVolumeClaim pending",
+			resource: `{"kind": "PersistentVolumeClaim", "status": {"phase": "Pending"}}`,
+		},
+		{
+			name:     "PersistentVolumeClaim lost",
+			resource: `{"kind": "PersistentVolumeClaim", "status": {"phase": "Lost"}}`,
+			failed:   true,
+		},
+		{
+			name:     "Job complete",
+			resource: `{"kind": "Job", "status": {"succeeded": 1, "conditions": [{"type": "Complete", "status": "True"}]}}`,
+			ready:    true,
+		},
+		{
+			name:     "Job running",
+			resource: `{"kind": "Job", "status": {"active": 1}}`,
+		},
+		{
+			name:     "Job failed",
+			resource: `{"kind": "Job", "status": {"conditions": [{"type": "Failed", "status": "True", "reason": "BackoffLimitExceeded"}]}}`,
+			failed:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{}
+			if err := json.Unmarshal([]byte(tt.resource), &obj.Object); err != nil {
+				t.Fatalf("failed to parse resource: %v", err)
+			}
+			state := readinessChecks[obj.GetKind()](obj)
+			if state.ready != tt.ready || state.failed != tt.failed {
+				t.Errorf("readiness = %+v; want ready %v, failed %v", state, tt.ready, tt.failed)
+			}
+			if !state.ready && state.message == "" {
+				t.Error("expected a message explaining why the resource is not ready")
+			}
+		})
+	}
+}
+
+// TestPerformRestoreWait tests that restore waits for restored workloads and reports the ones that are not ready.
+func TestPerformRestoreWait(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    string
+		expectErr bool
+	}{
+		{
+			name:   "Ready",
+			status: `{"replicas": 2, "updatedReplicas": 2, "availableReplicas": 2}`,
+		},
+		{
+			name:      "Not ready",
+			status:    `{"replicas": 2, "updatedReplicas": 2, "availableReplicas": 0}`,
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restoreDir := t.TempDir()
+			if err := os.MkdirAll(filepath.Join(restoreDir, "default", "deployments"), 0755); err != nil {
+				t.Fatalf("failed to create directory: %v", err)
+			}
+			writeResourceFile(t, restoreDir, filepath.Join("default", "deployments", "web.json"),
+				`{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "namespace": "default"}, "spec": {"replicas": 2}, "status": `+tt.status+`}`)
+
+			// Keep the status of the backup, as the fake client has no controllers to fill it in
+			sanitizer, err := sanitize.New([]string{"status"})
+			if err != nil {
+				t.Fatalf("failed to create sanitizer: %v", err)
+			}
+			manager := NewManager(newTestClient(), logger.NewLogger(os.Stdout, logger.DEBUG), WithSanitizer(sanitizer), WithWait(10*time.Millisecond))
+			err = manager.PerformRestore(restoreDir, false)
+			if tt.expectErr && !errors.Is(err, ErrNotReady) {
+				t.Errorf("PerformRestore() error = %v; want %v", err, ErrNotReady)
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("PerformRestore() unexpected error = %v", err)
+			}
+		})
+	}
+}
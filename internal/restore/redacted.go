@@ -0,0 +1,38 @@
+package restore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/chaoscypher/kube-save-restore/internal/redact"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// unredact replaces the placeholders of a resource redacted during the backup with the values of its live
+// version, so that the placeholders are never applied over real values. Values the cluster does not hold
+// are left unset, with a warning.
+func (m *Manager) unredact(ctx context.Context, file resourceFile) error {
+	ref := resourceReference(file.resource)
+	resourceClient, _, err := m.k8sClient.ResourceFor(ref)
+	if err != nil {
+		return err
+	}
+	var live map[string]interface{}
+	obj, err := resourceClient.Get(ctx, ref.GetName(), metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		return fmt.Errorf("error getting the live version of redacted %s %s: %v", ref.GetKind(), ref.GetName(), err)
+	default:
+		live = obj.Object
+	}
+
+	if unset := redact.Unredact(file.resource, live); len(unset) > 0 {
+		name, namespace, _ := getResourceIdentifiers(file.resource)
+		m.logger.Warnf("Restoring %s/%s in namespace %s without values redacted during the backup, which the cluster does not hold: %s",
+			file.kind, name, namespace, strings.Join(unset, ", "))
+	}
+	return nil
+}
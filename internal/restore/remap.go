@@ -0,0 +1,179 @@
+package restore
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// namespaceNameLabel is the label the API server sets on every Namespace to its name, which namespace
+// selectors commonly match on
+const namespaceNameLabel = "kubernetes.io/metadata.name"
+
+// serviceDNSName matches the namespace in the DNS names of Services, such as "db.prod.svc" and
+// "db.prod.svc.cluster.local"
+var serviceDNSName = regexp.MustCompile(`\b([a-z0-9]([-a-z0-9]*[a-z0-9])?)\.([a-z0-9]([-a-z0-9]*[a-z0-9])?)\.svc\b`)
+
+// remapNamespaces moves a resource manifest to the namespaces given by mapping, in place. Besides the
+// namespace of the resource itself, Namespaces are renamed, and the namespaces referenced by the subjects
+// of RoleBindings (see remapSubjects), the claims PersistentVolumes are reserved for, the namespace selectors of
+// NetworkPolicies and the Service DNS names in Ingress annotations and ExternalName Services are rewritten.
+// ClusterRoleBindings are left unchanged, so that remapping never grants cluster-wide permissions.
+func remapNamespaces(resource map[string]interface{}, mapping map[string]string) {
+	if len(mapping) == 0 {
+		return
+	}
+	kind, _ := resource["kind"].(string)
+	metadata, _ := resource["metadata"].(map[string]interface{})
+
+	if kind == "Namespace" {
+		name, _ := metadata["name"].(string)
+		if destination, ok := mapping[name]; ok {
+			metadata["name"] = destination
+			labels, _ := metadata["labels"].(map[string]interface{})
+			if _, ok := labels[namespaceNameLabel]; ok {
+				labels[namespaceNameLabel] = destination
+			}
+		}
+		return
+	}
+
+	namespace, _ := metadata["namespace"].(string)
+	moved := mapping[namespace] != ""
+	if moved {
+		metadata["namespace"] = mapping[namespace]
+	}
+
+	switch kind {
+	case "RoleBinding":
+		remapSubjects(resource, mapping, moved)
+	case "PersistentVolume":
+		// The volume is only bound to a claim in the namespace its claimRef names
+		spec, _ := resource["spec"].(map[string]interface{})
+		claimRef, _ := spec["claimRef"].(map[string]interface{})
+		if claimNamespace, _ := claimRef["namespace"].(string); mapping[claimNamespace] != "" {
+			claimRef["namespace"] = mapping[claimNamespace]
+		}
+	case "NetworkPolicy":
+		remapNetworkPolicy(resource, mapping)
+	case "Ingress":
+		annotations, _ := metadata["annotations"].(map[string]interface{})
+		for key, value := range annotations {
+			if value, ok := value.(string); ok {
+				annotations[key] = remapServiceDNSNames(value, mapping)
+			}
+		}
+	case "Service":
+		spec, _ := resource["spec"].(map[string]interface{})
+		if externalName, ok := spec["externalName"].(string); ok {
+			spec["externalName"] = remapServiceDNSNames(externalName, mapping)
+		}
+	}
+}
+
+// remapSubjects rewrites the namespaces of the ServiceAccount subjects of a RoleBinding. If the binding
+// was moved to a mapped namespace, its subjects are rewritten in place. Otherwise the binding is shared with
+// the source namespaces, so the remapped subjects are added alongside the original ones, which keep their
+// permissions.
+func remapSubjects(binding map[string]interface{}, mapping map[string]string, moved bool) {
+	subjects, _ := binding["subjects"].([]interface{})
+	existing := make(map[string]bool, len(subjects))
+	for _, subject := range subjects {
+		subject, _ := subject.(map[string]interface{})
+		existing[subjectKey(subject)] = true
+	}
+
+	for _, subject := range subjects {
+		subject, _ := subject.(map[string]interface{})
+		namespace, _ := subject["namespace"].(string)
+		destination := mapping[namespace]
+		if destination == "" {
+			continue
+		}
+		if moved {
+			subject["namespace"] = destination
+			continue
+		}
+		added := make(map[string]interface{}, len(subject))
+		for key, value := range subject {
+			added[key] = value
+		}
+		added["namespace"] = destination
+		if !existing[subjectKey(added)] {
+			existing[subjectKey(added)] = true
+			subjects = append(subjects, added)
+		}
+	}
+	if !moved && len(subjects) > 0 {
+		binding["subjects"] = subjects
+	}
+}
+
+// remappedSubjects returns the subjects of a role binding in the namespaces remapped by mapping
+func remappedSubjects(binding map[string]interface{}, mapping map[string]string) []string {
+	var remapped []string
+	subjects, _ := binding["subjects"].([]interface{})
+	for _, subject := range subjects {
+		subject, _ := subject.(map[string]interface{})
+		if namespace, _ := subject["namespace"].(string); mapping[namespace] != "" {
+			remapped = append(remapped, fmt.Sprintf("%v %v/%v", subject["kind"], namespace, subject["name"]))
+		}
+	}
+	return remapped
+}
+
+// subjectKey identifies a subject of a role binding
+func subjectKey(subject map[string]interface{}) string {
+	return fmt.Sprintf("%v/%v/%v", subject["kind"], subject["namespace"], subject["name"])
+}
+
+// remapNetworkPolicy rewrites the namespace names matched by the namespace selectors of a NetworkPolicy's
+// ingress and egress rules through the kubernetes.io/metadata.name label
+func remapNetworkPolicy(policy map[string]interface{}, mapping map[string]string) {
+	spec, _ := policy["spec"].(map[string]interface{})
+	for _, direction := range []struct{ rules, peers string }{{"ingress", "from"}, {"egress", "to"}} {
+		rules, _ := spec[direction.rules].([]interface{})
+		for _, rule := range rules {
+			rule, _ := rule.(map[string]interface{})
+			peers, _ := rule[direction.peers].([]interface{})
+			for _, peer := range peers {
+				peer, _ := peer.(map[string]interface{})
+				selector, _ := peer["namespaceSelector"].(map[string]interface{})
+				remapNamespaceSelector(selector, mapping)
+			}
+		}
+	}
+}
+
+// remapNamespaceSelector rewrites the namespace names matched by a label selector through the
+// kubernetes.io/metadata.name label
+func remapNamespaceSelector(selector map[string]interface{}, mapping map[string]string) {
+	matchLabels, _ := selector["matchLabels"].(map[string]interface{})
+	if name, _ := matchLabels[namespaceNameLabel].(string); mapping[name] != "" {
+		matchLabels[namespaceNameLabel] = mapping[name]
+	}
+	expressions, _ := selector["matchExpressions"].([]interface{})
+	for _, expression := range expressions {
+		expression, _ := expression.(map[string]interface{})
+		if key, _ := expression["key"].(string); key != namespaceNameLabel {
+			continue
+		}
+		values, _ := expression["values"].([]interface{})
+		for i, value := range values {
+			if name, _ := value.(string); mapping[name] != "" {
+				values[i] = mapping[name]
+			}
+		}
+	}
+}
+
+// remapServiceDNSNames rewrites the namespaces in the Service DNS names found in value
+func remapServiceDNSNames(value string, mapping map[string]string) string {
+	return serviceDNSName.ReplaceAllStringFunc(value, func(name string) string {
+		parts := serviceDNSName.FindStringSubmatch(name)
+		destination, ok := mapping[parts[3]]
+		if !ok {
+			return name
+		}
+		return parts[1] + "." + destination + ".svc"
+	})
+}
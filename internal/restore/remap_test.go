@@ -0,0 +1,133 @@
+package restore
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/chaoscypher/kube-save-restore/internal/logger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestRemapNamespaces(t *testing.T) {
+	mapping := map[string]string{"prod": "prod-copy", "shop": "shop-copy"}
+
+	tests := []struct {
+		name     string
+		resource string
+		expected string
+	}{
+		{
+			name:     "Namespace",
+			resource: `{"kind": "Namespace", "metadata": {"name": "prod", "labels": {"kubernetes.io/metadata.name": "prod", "team": "core"}}}`,
+			expected: `{"kind": "Namespace", "metadata": {"name": "prod-copy", "labels": {"kubernetes.io/metadata.name": "prod-copy", "team": "core"}}}`,
+		},
+		{
+			name:     "Unmapped namespace",
+			resource: `{"kind": "ConfigMap", "metadata": {"name": "app", "namespace": "staging"}}`,
+			expected: `{"kind": "ConfigMap", "metadata": {"name": "app", "namespace": "staging"}}`,
+		},
+		{
+			name: "RoleBinding",
+			resource: `{"kind": "RoleBinding", "metadata": {"name": "deployer", "namespace": "prod"},
+				"subjects": [{"kind": "ServiceAccount", "name": "ci", "namespace": "shop"}, {"kind": "ServiceAccount", "name": "ci", "namespace": "tools"}, {"kind": "User", "name": "alice"}]}`,
+			expected: `{"kind": "RoleBinding", "metadata": {"name": "deployer", "namespace": "prod-copy"},
+				"subjects": [{"kind": "ServiceAccount", "name": "ci", "namespace": "shop-copy"}, {"kind": "ServiceAccount", "name": "ci", "namespace": "tools"}, {"kind": "User", "name": "alice"}]}`,
+		},
+		{
+			name: "ClusterRoleBinding",
+			resource: `{"kind": "ClusterRoleBinding", "metadata": {"name": "deployer"},
+				"subjects": [{"kind": "ServiceAccount", "name": "ci", "namespace": "prod"}, {"kind": "ServiceAccount", "name": "ci", "namespace": "tools"}]}`,
+			expected: `{"kind": "ClusterRoleBinding", "metadata": {"name": "deployer"},
+				"subjects": [{"kind": "ServiceAccount", "name": "ci", "namespace": "prod"}, {"kind": "ServiceAccount", "name": "ci", "namespace": "tools"}]}`,
+		},
+		{
+			name: "RoleBinding in an unmapped namespace",
+			resource: `{"kind": "RoleBinding", "metadata": {"name": "reader", "namespace": "tools"},
+				"subjects": [{"kind": "ServiceAccount", "name": "ci", "namespace": "shop"}]}`,
+			expected: `{"kind": "RoleBinding", "metadata": {"name": "reader", "namespace": "tools"},
+				"subjects": [{"kind": "ServiceAccount", "name": "ci", "namespace": "shop"}, {"kind": "ServiceAccount", "name": "ci", "namespace": "shop-copy"}]}`,
+		},
+		{
+			name:     "PersistentVolume",
+			resource: `{"kind": "PersistentVolume", "metadata": {"name": "pv-data"}, "spec": {"claimRef": {"kind": "PersistentVolumeClaim", "namespace": "prod", "name": "data"}}}`,
+			expected: `{"kind": "PersistentVolume", "metadata": {"name": "pv-data"}, "spec": {"claimRef": {"kind": "PersistentVolumeClaim", "namespace": "prod-copy", "name": "data"}}}`,
+		},
+		{
+			name:     "PersistentVolume reserved for an unmapped namespace",
+			resource: `{"kind": "PersistentVolume", "metadata": {"name": "pv-logs"}, "spec": {"claimRef": {"kind": "PersistentVolumeClaim", "namespace": "tools", "name": "logs"}}}`,
+			expected: `{"kind": "PersistentVolume", "metadata": {"name": "pv-logs"}, "spec": {"claimRef": {"kind": "PersistentVolumeClaim", "namespace": "tools", "name": "logs"}}}`,
+		},
+		{
+			name: "NetworkPolicy",
+			resource: `{"kind": "NetworkPolicy", "metadata": {"name": "allow-shop", "namespace": "prod"}, "spec": {
+				"ingress": [{"from": [{"namespaceSelector": {"matchLabels": {"kubernetes.io/metadata.name": "shop"}}}, {"podSelector": {}}]}],
+				"egress": [{"to": [{"namespaceSelector": {"matchExpressions": [{"key": "kubernetes.io/metadata.name", "operator": "In", "values": ["prod", "monitoring"]}]}}]}]}}`,
+			expected: `{"kind": "NetworkPolicy", "metadata": {"name": "allow-shop", "namespace": "prod-copy"}, "spec": {
+				"ingress": [{"from": [{"namespaceSelector": {"matchLabels": {"kubernetes.io/metadata.name": "shop-copy"}}}, {"podSelector": {}}]}],
+				"egress": [{"to": [{"namespaceSelector": {"matchExpressions": [{"key": "kubernetes.io/metadata.name", "operator": "In", "values": ["prod-copy", "monitoring"]}]}}]}]}}`,
+		},
+		{
+			name: "Ingress",
+			resource: `{"kind": "Ingress", "metadata": {"name": "web", "namespace": "prod",
+				"annotations": {"nginx.ingress.kubernetes.io/upstream-vhost": "api.shop.svc.cluster.local", "nginx.ingress.kubernetes.io/auth-url": "http://auth.tools.svc:8080/verify"}}}`,
+			expected: `{"kind": "Ingress", "metadata": {"name": "web", "namespace": "prod-copy",
+				"annotations": {"nginx.ingress.kubernetes.io/upstream-vhost": "api.shop-copy.svc.cluster.local", "nginx.ingress.kubernetes.io/auth-url": "http://auth.tools.svc:8080/verify"}}}`,
+		},
+		{
+			name:     "ExternalName Service",
+			resource: `{"kind": "Service", "metadata": {"name": "api", "namespace": "prod"}, "spec": {"type": "ExternalName", "externalName": "api.shop.svc.cluster.local"}}`,
+			expected: `{"kind": "Service", "metadata": {"name": "api", "namespace": "prod-copy"}, "spec": {"type": "ExternalName", "externalName": "api.shop-copy.svc.cluster.local"}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resource, expected map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.resource), &resource); err != nil {
+				t.Fatalf("failed to parse resource: %v", err)
+			}
+			if err := json.Unmarshal([]byte(tt.expected), &expected); err != nil {
+				t.Fatalf("failed to parse expected resource: %v", err)
+			}
+			remapNamespaces(resource, mapping)
+			if !reflect.DeepEqual(resource, expected) {
+				t.Errorf("remapNamespaces() = %v; want %v", resource, expected)
+			}
+		})
+	}
+}
+
+// TestPerformRestoreNamespaceMapping tests that a namespace of the backup is restored under another name.
+func TestPerformRestoreNamespaceMapping(t *testing.T) {
+	restoreDir := t.TempDir()
+	for _, dir := range []string{"namespaces", filepath.Join("prod", "deployments")} {
+		if err := os.MkdirAll(filepath.Join(restoreDir, dir), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+	}
+	writeResourceFile(t, restoreDir, filepath.Join("namespaces", "prod.json"), `{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "prod"}}`)
+	writeResourceFile(t, restoreDir, filepath.Join("prod", "deployments", "web.json"), `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "namespace": "prod"}}`)
+
+	client := newTestClient()
+	manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG), WithNamespaceMapping(map[string]string{"prod": "prod-copy-123"}))
+	if err := manager.PerformRestore(restoreDir, false); err != nil {
+		t.Fatalf("PerformRestore() error = %v", err)
+	}
+
+	namespaces := schema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+	if _, err := client.Dynamic.Resource(namespaces).Get(context.Background(), "prod-copy-123", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected namespace prod-copy-123 to be created, got error: %v", err)
+	}
+	if _, err := client.Dynamic.Resource(namespaces).Get(context.Background(), "prod", metav1.GetOptions{}); err == nil {
+		t.Error("expected namespace prod not to be created")
+	}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	if _, err := client.Dynamic.Resource(deployments).Namespace("prod-copy-123").Get(context.Background(), "web", metav1.GetOptions{}); err != nil {
+		t.Errorf("expected deployment web to be created in prod-copy-123, got error: %v", err)
+	}
+}
@@ -1,286 +1,256 @@
 package restore
 
 import (
-	"encoding/json"
-	"fmt"
-
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/chaoscypher/kube-save-restore/internal/kubernetes"
-	appsv1 "k8s.io/api/apps/v1"
-	autoscalingv2 "k8s.io/api/autoscaling/v2"
-	batchv1 "k8s.io/api/batch/v1"
-	corev1 "k8s.io/api/core/v1"
-	networkingv1 "k8s.io/api/networking/v1"
-	rbacv1 "k8s.io/api/rbac/v1"
-	"k8s.io/apimachinery/pkg/api/errors"
+	"github.com/chaoscypher/kube-save-restore/internal/sanitize"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/util/retry"
 )
 
-// applyResource applies the resource to the Kubernetes cluster based on its kind
-func applyResource(client *kubernetes.Client, resource map[string]interface{}, kind, namespace string) error {
-	// Marshal the resource into JSON format
-	adjustedData, err := json.Marshal(resource)
-	if err != nil {
-		return fmt.Errorf("error marshaling adjusted resource: %v", err)
-	}
+// ExistingResourcePolicy decides what happens to a resource of the backup that already exists in the cluster.
+type ExistingResourcePolicy string
 
-	// Switch based on the kind of resource and call the appropriate function
-	switch kind {
-	case "Namespace":
-		return applyNamespace(client, adjustedData)
-	case "Deployment":
-		return applyDeployment(client, adjustedData, namespace)
-	case "Service":
-		return applyService(client, adjustedData, namespace)
-	case "ConfigMap":
-		return applyConfigMap(client, adjustedData, namespace)
-	case "Secret":
-		return applySecret(client, adjustedData, namespace)
-	case "ServiceAccount":
-		return applyServiceAccount(client, adjustedData, namespace)
-	case "StatefulSet":
-		return applyStatefulSet(client, adjustedData, namespace)
-	case "DaemonSet":
-		return applyDaemonSet(client, adjustedData, namespace)
-	case "HorizontalPodAutoscaler":
-		return applyHorizontalPodAutoscalers(client, adjustedData, namespace)
-	case "CronJob":
-		return applyCronJob(client, adjustedData, namespace)
-	case "Job":
-		return applyJob(client, adjustedData, namespace)
-	case "PersistentVolumeClaim":
-		return applyPersistentVolumeClaim(client, adjustedData, namespace)
-	case "Ingress":
-		return applyIngress(client, adjustedData, namespace)
-	case "Role":
-		return applyRole(client, adjustedData, namespace)
-	case "NetworkPolicy":
-		return applyNetworkPolicy(client, adjustedData, namespace)
-	default:
-		return fmt.Errorf("unsupported resource kind: %s", kind)
-	}
-}
+const (
+	// ExistingResourceNone leaves existing resources untouched and reports them
+	ExistingResourceNone ExistingResourcePolicy = "none"
+	// ExistingResourceUpdate replaces existing resources with the backup. This is the default.
+	ExistingResourceUpdate ExistingResourcePolicy = "update"
+	// ExistingResourceFail aborts the restore when a resource already exists
+	ExistingResourceFail ExistingResourcePolicy = "fail"
+	// ExistingResourceRecreate deletes existing resources and creates them again from the backup, which
+	// allows restoring changes to immutable fields
+	ExistingResourceRecreate ExistingResourcePolicy = "recreate"
+)
 
-// applyNamespace applies a Namespace resource to the Kubernetes cluster
-func applyNamespace(client *kubernetes.Client, data []byte) error {
-	var namespace corev1.Namespace
-	// Unmarshal the JSON data into a Namespace object
-	if err := json.Unmarshal(data, &namespace); err != nil {
-		return fmt.Errorf("error unmarshaling namespace: %v", err)
-	}
-	// Try to update the Namespace, if it does not exist, create it
-	_, err := client.Clientset.CoreV1().Namespaces().Update(context.TODO(), &namespace, metav1.UpdateOptions{})
-	if err != nil && errors.IsNotFound(err) {
-		_, err = client.Clientset.CoreV1().Namespaces().Create(context.TODO(), &namespace, metav1.CreateOptions{})
-	}
-	return err
+// neverRecreatedKinds lists the kinds that the ExistingResourceRecreate policy updates instead of deleting,
+// because deleting them deletes more than the resource: a Namespace takes all the resources in it along, a
+// CustomResourceDefinition all its custom resources, and a bound PersistentVolume or PersistentVolumeClaim
+// may take the data of its volume.
+var neverRecreatedKinds = map[string]bool{
+	"Namespace":                true,
+	"CustomResourceDefinition": true,
+	"PersistentVolume":         true,
+	"PersistentVolumeClaim":    true,
 }
 
-// applyDeployment applies a Deployment resource to the Kubernetes cluster
-func applyDeployment(client *kubernetes.Client, data []byte, namespace string) error {
-	var deployment appsv1.Deployment
-	// Unmarshal the JSON data into a Deployment object
-	if err := json.Unmarshal(data, &deployment); err != nil {
-		return fmt.Errorf("error unmarshaling deployment: %v", err)
-	}
-	// Try to update the Deployment, if it does not exist, create it
-	_, err := client.Clientset.AppsV1().Deployments(namespace).Update(context.TODO(), &deployment, metav1.UpdateOptions{})
-	if err != nil && errors.IsNotFound(err) {
-		_, err = client.Clientset.AppsV1().Deployments(namespace).Create(context.TODO(), &deployment, metav1.CreateOptions{})
-	}
-	return err
-}
+// FieldManager is the field manager that owns the fields of resources restored with server-side apply
+const FieldManager = "kube-save-restore"
 
-// applyService applies a Service resource to the Kubernetes cluster
-func applyService(client *kubernetes.Client, data []byte, namespace string) error {
-	var service corev1.Service
-	// Unmarshal the JSON data into a Service object
-	if err := json.Unmarshal(data, &service); err != nil {
-		return fmt.Errorf("error unmarshaling service: %v", err)
-	}
-	// Try to update the Service, if it does not exist, create it
-	_, err := client.Clientset.CoreV1().Services(namespace).Update(context.TODO(), &service, metav1.UpdateOptions{})
-	if err != nil && errors.IsNotFound(err) {
-		_, err = client.Clientset.CoreV1().Services(namespace).Create(context.TODO(), &service, metav1.CreateOptions{})
-	}
-	return err
-}
+// ErrResourceExists is returned when a resource already exists and the ExistingResourceFail policy is used.
+var ErrResourceExists = errors.New("resource already exists")
 
-// applyConfigMap applies a ConfigMap resource to the Kubernetes cluster
-func applyConfigMap(client *kubernetes.Client, data []byte, namespace string) error {
-	var configMap corev1.ConfigMap
-	// Unmarshal the JSON data into a ConfigMap object
-	if err := json.Unmarshal(data, &configMap); err != nil {
-		return fmt.Errorf("error unmarshaling configmap: %v", err)
-	}
-	// Try to update the ConfigMap, if it does not exist, create it
-	_, err := client.Clientset.CoreV1().ConfigMaps(namespace).Update(context.TODO(), &configMap, metav1.UpdateOptions{})
-	if err != nil && errors.IsNotFound(err) {
-		_, err = client.Clientset.CoreV1().ConfigMaps(namespace).Create(context.TODO(), &configMap, metav1.CreateOptions{})
-	}
-	return err
-}
+// recreateTimeout is how long to wait for a deleted resource to disappear before creating it again
+const recreateTimeout = 2 * time.Minute
 
-// applySecret applies a Secret resource to the Kubernetes cluster
-func applySecret(client *kubernetes.Client, data []byte, namespace string) error {
-	var secret corev1.Secret
-	// Unmarshal the JSON data into a Secret object
-	if err := json.Unmarshal(data, &secret); err != nil {
-		return fmt.Errorf("error unmarshaling secret: %v", err)
-	}
-	// Try to update the Secret, if it does not exist, create it
-	_, err := client.Clientset.CoreV1().Secrets(namespace).Update(context.TODO(), &secret, metav1.UpdateOptions{})
-	if err != nil && errors.IsNotFound(err) {
-		_, err = client.Clientset.CoreV1().Secrets(namespace).Create(context.TODO(), &secret, metav1.CreateOptions{})
-	}
-	return err
-}
+// applyResult describes what applyResource did with a resource
+type applyResult int
 
-// applyServiceAccount applies a ServiceAccount resource to the Kubernetes cluster
-func applyServiceAccount(client *kubernetes.Client, data []byte, namespace string) error {
-	var serviceAccount corev1.ServiceAccount
-	// Unmarshal the JSON data into a ServiceAccount object
-	if err := json.Unmarshal(data, &serviceAccount); err != nil {
-		return fmt.Errorf("error unmarshaling service account: %v", err)
-	}
-	// Try to update the ServiceAccount, if it does not exist, create it
-	_, err := client.Clientset.CoreV1().ServiceAccounts(namespace).Update(context.TODO(), &serviceAccount, metav1.UpdateOptions{})
-	if err != nil && errors.IsNotFound(err) {
-		_, err = client.Clientset.CoreV1().ServiceAccounts(namespace).Create(context.TODO(), &serviceAccount, metav1.CreateOptions{})
-	}
-	return err
+const (
+	resourceCreated applyResult = iota
+	resourceUpdated
+	resourceRecreated
+	resourceExisted
+	resourceApplied
+	// resourceNotRecreated is an existing resource of one of the neverRecreatedKinds that was updated
+	// instead of recreated
+	resourceNotRecreated
+)
+
+// applyOptions configures how applyResource writes resources to the cluster
+type applyOptions struct {
+	// policy decides what happens to resources that already exist
+	policy ExistingResourcePolicy
+	// serverSide writes resources with server-side apply instead of Create and Update
+	serverSide bool
+	// forceConflicts takes ownership of fields managed by others when applying server-side
+	forceConflicts bool
+	// sanitizer removes the server-populated fields of the backup; a nil sanitizer removes all of them
+	sanitizer *sanitize.Sanitizer
 }
 
-// applyStatefulSet applies a StatefulSet resource to the Kubernetes cluster
-func applyStatefulSet(client *kubernetes.Client, data []byte, namespace string) error {
-	var statefulSet appsv1.StatefulSet
-	// Unmarshal the JSON data into a StatefulSet object
-	if err := json.Unmarshal(data, &statefulSet); err != nil {
-		return fmt.Errorf("error unmarshaling stateful set: %v", err)
-	}
-	// Try to update the StatefulSet, if it does not exist, create it
-	_, err := client.Clientset.AppsV1().StatefulSets(namespace).Update(context.TODO(), &statefulSet, metav1.UpdateOptions{})
-	if err != nil && errors.IsNotFound(err) {
-		_, err = client.Clientset.AppsV1().StatefulSets(namespace).Create(context.TODO(), &statefulSet, metav1.CreateOptions{})
-	}
-	return err
+// forCreate returns a copy of obj without the spec fields the API server allocates when creating it, such
+// as the IP of a Service. Those fields are kept when updating an existing resource, which the API server
+// rejects if they are removed.
+func (o applyOptions) forCreate(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	created := obj.DeepCopy()
+	o.sanitizer.SanitizeForCreate(created.Object)
+	return created
 }
 
-// applyDaemonSet applies a DaemonSet resource to the Kubernetes cluster
-func applyDaemonSet(client *kubernetes.Client, data []byte, namespace string) error {
-	var daemonSet appsv1.DaemonSet
-	// Unmarshal the JSON data into a DaemonSet object
-	if err := json.Unmarshal(data, &daemonSet); err != nil {
-		return fmt.Errorf("error unmarshaling daemon set: %v", err)
+// applyResource applies the resource to the Kubernetes cluster using the dynamic client.
+// The resource's apiVersion and kind are resolved to an API resource through the client's RESTMapper,
+// so any kind served by the cluster, including custom resources, can be restored.
+// Resources that already exist are handled according to the policy of opts.
+func applyResource(ctx context.Context, client *kubernetes.Client, resource map[string]interface{}, opts applyOptions) (applyResult, error) {
+	obj := &unstructured.Unstructured{Object: resource}
+
+	resourceClient, _, err := client.ResourceFor(obj)
+	if err != nil {
+		return 0, err
 	}
-	// Try to update the DaemonSet, if it does not exist, create it
-	_, err := client.Clientset.AppsV1().DaemonSets(namespace).Update(context.TODO(), &daemonSet, metav1.UpdateOptions{})
-	if err != nil && errors.IsNotFound(err) {
-		_, err = client.Clientset.AppsV1().DaemonSets(namespace).Create(context.TODO(), &daemonSet, metav1.CreateOptions{})
+
+	result, err := applyWithPolicy(ctx, resourceClient, obj, opts)
+	if err != nil {
+		if errors.Is(err, ErrResourceExists) {
+			return result, fmt.Errorf("%w: %s %s", err, obj.GetKind(), obj.GetName())
+		}
+		return result, fmt.Errorf("error applying %s %s: %v", obj.GetKind(), obj.GetName(), err)
 	}
-	return err
+	return result, nil
 }
 
-// applyHorizontalPodAutoscalers applies a HorizontalPodAutoscaler resource to the Kubernetes cluster
-func applyHorizontalPodAutoscalers(client *kubernetes.Client, data []byte, namespace string) error {
-	var hpa autoscalingv2.HorizontalPodAutoscaler
-	// Unmarshal the JSON data into a HorizontalPodAutoscaler object
-	if err := json.Unmarshal(data, &hpa); err != nil {
-		return fmt.Errorf("error unmarshaling hpa: %v", err)
+// applyWithPolicy creates obj, handling an existing resource according to the policy of opts
+func applyWithPolicy(ctx context.Context, resourceClient dynamic.ResourceInterface, obj *unstructured.Unstructured, opts applyOptions) (applyResult, error) {
+	if opts.policy == ExistingResourceUpdate || opts.policy == "" {
+		if opts.serverSide {
+			// Check whether the resource exists, to apply it without the fields allocated on creation if not
+			_, err := resourceClient.Get(ctx, obj.GetName(), metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				return resourceApplied, serverSideApply(ctx, resourceClient, opts.forCreate(obj), opts.forceConflicts)
+			}
+			if err != nil {
+				return 0, err
+			}
+			return resourceApplied, serverSideApply(ctx, resourceClient, obj, opts.forceConflicts)
+		}
+		// Try to update the resource, if it does not exist, create it
+		err := updateLive(ctx, resourceClient, obj)
+		if err == nil {
+			return resourceUpdated, nil
+		}
+		if !apierrors.IsNotFound(err) {
+			return 0, err
+		}
+		_, err = resourceClient.Create(ctx, opts.forCreate(obj), metav1.CreateOptions{})
+		return resourceCreated, err
 	}
-	// Try to update the HorizontalPodAutoscaler, if it does not exist, create it
-	_, err := client.Clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).Update(context.TODO(), &hpa, metav1.UpdateOptions{})
-	if err != nil && errors.IsNotFound(err) {
-		_, err = client.Clientset.AutoscalingV2().HorizontalPodAutoscalers(namespace).Create(context.TODO(), &hpa, metav1.CreateOptions{})
 
+	exists, err := createResource(ctx, resourceClient, obj, opts)
+	if err != nil {
+		return 0, err
+	}
+	if !exists {
+		return resourceCreated, nil
+	}
+	switch opts.policy {
+	case ExistingResourceNone:
+		return resourceExisted, nil
+	case ExistingResourceFail:
+		return resourceExisted, ErrResourceExists
+	case ExistingResourceRecreate:
+		if neverRecreatedKinds[obj.GetKind()] {
+			if err := updateResource(ctx, resourceClient, obj, opts); err != nil {
+				return 0, err
+			}
+			return resourceNotRecreated, nil
+		}
+		if err := deleteAndWait(ctx, resourceClient, obj.GetName()); err != nil {
+			return 0, err
+		}
+		if err := writeResource(ctx, resourceClient, opts.forCreate(obj), opts); err != nil {
+			return 0, err
+		}
+		return resourceRecreated, nil
+	default:
+		return 0, fmt.Errorf("unknown existing resource policy %q", opts.policy)
 	}
-	return err
 }
 
-// applyCronJob applies a CronJob resource to the Kubernetes cluster
-func applyCronJob(client *kubernetes.Client, data []byte, namespace string) error {
-	var cronJob batchv1.CronJob
-	// Unmarshal the JSON data into a CronJob object
-	if err := json.Unmarshal(data, &cronJob); err != nil {
-		return fmt.Errorf("error unmarshaling cron job: %v", err)
+// createResource creates obj unless it already exists, and reports whether it does
+func createResource(ctx context.Context, resourceClient dynamic.ResourceInterface, obj *unstructured.Unstructured, opts applyOptions) (bool, error) {
+	if !opts.serverSide {
+		_, err := resourceClient.Create(ctx, opts.forCreate(obj), metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			return true, nil
+		}
+		return false, err
 	}
-	// Try to update the CronJob, if it does not exist, create it
-	_, err := client.Clientset.BatchV1().CronJobs(namespace).Update(context.TODO(), &cronJob, metav1.UpdateOptions{})
-	if err != nil && errors.IsNotFound(err) {
-		_, err = client.Clientset.BatchV1().CronJobs(namespace).Create(context.TODO(), &cronJob, metav1.CreateOptions{})
-	}
-	return err
-}
 
-// applyPersistentVolumeClaim applies a PersistentVolumeClaim resource to the Kubernetes cluster
-func applyPersistentVolumeClaim(client *kubernetes.Client, data []byte, namespace string) error {
-	var pvc corev1.PersistentVolumeClaim
-	// Unmarshal the JSON data into a PersistentVolumeClaim object
-	if err := json.Unmarshal(data, &pvc); err != nil {
-		return fmt.Errorf("error unmarshaling pvc: %v", err)
+	// Server-side apply also changes existing resources, so check whether the resource exists first
+	_, err := resourceClient.Get(ctx, obj.GetName(), metav1.GetOptions{})
+	if err == nil {
+		return true, nil
 	}
-	// Try to update the PersistentVolumeClaim, if it does not exist, create it
-	_, err := client.Clientset.CoreV1().PersistentVolumeClaims(namespace).Update(context.TODO(), &pvc, metav1.UpdateOptions{})
-	if err != nil && errors.IsNotFound(err) {
-		_, err = client.Clientset.CoreV1().PersistentVolumeClaims(namespace).Create(context.TODO(), &pvc, metav1.CreateOptions{})
+	if !apierrors.IsNotFound(err) {
+		return false, err
 	}
-	return err
+	return false, serverSideApply(ctx, resourceClient, opts.forCreate(obj), opts.forceConflicts)
 }
 
-func applyJob(client *kubernetes.Client, data []byte, namespace string) error {
-	var job batchv1.Job
-	if err := json.Unmarshal(data, &job); err != nil {
-		return fmt.Errorf("error unmarshaling job: %v", err)
+// updateResource replaces the existing resource with obj, with server-side apply if enabled in opts
+func updateResource(ctx context.Context, resourceClient dynamic.ResourceInterface, obj *unstructured.Unstructured, opts applyOptions) error {
+	if opts.serverSide {
+		return serverSideApply(ctx, resourceClient, obj, opts.forceConflicts)
 	}
-	_, err := client.Clientset.BatchV1().Jobs(namespace).Update(context.TODO(), &job, metav1.UpdateOptions{})
-	if err != nil && errors.IsNotFound(err) {
-		_, err = client.Clientset.BatchV1().Jobs(namespace).Create(context.TODO(), &job, metav1.CreateOptions{})
-	}
-	return err
+	return updateLive(ctx, resourceClient, obj)
 }
 
-// applyIngress applies an Ingress resource to the Kubernetes cluster
-func applyIngress(client *kubernetes.Client, data []byte, namespace string) error {
-	var ingress networkingv1.Ingress
-	// Unmarshal the JSON data into an Ingress object
-	if err := json.Unmarshal(data, &ingress); err != nil {
-		return fmt.Errorf("error unmarshaling ingress: %v", err)
-	}
-	// Try to update the Ingress, if it does not exist, create it
-	_, err := client.Clientset.NetworkingV1().Ingresses(namespace).Update(context.TODO(), &ingress, metav1.UpdateOptions{})
-	if err != nil && errors.IsNotFound(err) {
-		_, err = client.Clientset.NetworkingV1().Ingresses(namespace).Create(context.TODO(), &ingress, metav1.CreateOptions{})
+// updateLive replaces the live version of the resource with obj. The update carries the resourceVersion of
+// the live version, which the API server requires to update custom resources and CustomResourceDefinitions,
+// and is retried if the resource changes in between. The error is NotFound if the resource does not exist.
+func updateLive(ctx context.Context, resourceClient dynamic.ResourceInterface, obj *unstructured.Unstructured) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		live, err := resourceClient.Get(ctx, obj.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		updated := obj.DeepCopy()
+		updated.SetResourceVersion(live.GetResourceVersion())
+		_, err = resourceClient.Update(ctx, updated, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// writeResource creates obj, with server-side apply if enabled in opts
+func writeResource(ctx context.Context, resourceClient dynamic.ResourceInterface, obj *unstructured.Unstructured, opts applyOptions) error {
+	if opts.serverSide {
+		return serverSideApply(ctx, resourceClient, obj, opts.forceConflicts)
 	}
+	_, err := resourceClient.Create(ctx, obj, metav1.CreateOptions{})
 	return err
 }
 
-// applyRole applies a Role resource to the Kubernetes cluster
-func applyRole(client *kubernetes.Client, data []byte, namespace string) error {
-	var role rbacv1.Role
-	// Unmarshal the JSON data into a Role object
-	if err := json.Unmarshal(data, &role); err != nil {
-		return fmt.Errorf("error unmarshaling role: %v", err)
-	}
-	// Try to update the Role, if it does not exist, create it
-	_, err := client.Clientset.RbacV1().Roles(namespace).Update(context.TODO(), &role, metav1.UpdateOptions{})
-	if err != nil && errors.IsNotFound(err) {
-		_, err = client.Clientset.RbacV1().Roles(namespace).Create(context.TODO(), &role, metav1.CreateOptions{})
+// serverSideApply applies obj with server-side apply as FieldManager. Fields owned by other managers, such
+// as controllers or GitOps tools, are only taken over if force is true; otherwise they cause a conflict error.
+func serverSideApply(ctx context.Context, resourceClient dynamic.ResourceInterface, obj *unstructured.Unstructured, force bool) error {
+	data, err := obj.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("error encoding resource: %v", err)
 	}
+	_, err = resourceClient.Patch(ctx, obj.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        &force,
+	})
 	return err
 }
 
-// applyNetworkPolicy applies a NetworkPolicy resource to the Kubernetes cluster
-func applyNetworkPolicy(client *kubernetes.Client, data []byte, namespace string) error {
-	var networkPolicy networkingv1.NetworkPolicy
-	// Unmarshal the JSON data into a NetworkPolicy object
-	if err := json.Unmarshal(data, &networkPolicy); err != nil {
-		return fmt.Errorf("error unmarshaling network policy: %v", err)
-	}
-	// Try to update the NetworkPolicy, if it does not exist, create it
-	_, err := client.Clientset.NetworkingV1().NetworkPolicies(namespace).Update(context.TODO(), &networkPolicy, metav1.UpdateOptions{})
-	if err != nil && errors.IsNotFound(err) {
-		_, err = client.Clientset.NetworkingV1().NetworkPolicies(namespace).Create(context.TODO(), &networkPolicy, metav1.CreateOptions{})
+// deleteAndWait deletes the named resource along with its dependents, such as the pods of a Job, and waits
+// until it is gone
+func deleteAndWait(ctx context.Context, resourceClient dynamic.ResourceInterface, name string) error {
+	propagation := metav1.DeletePropagationBackground
+	err := resourceClient.Delete(ctx, name, metav1.DeleteOptions{PropagationPolicy: &propagation})
+	if err != nil && !apierrors.IsNotFound(err) {
+		return fmt.Errorf("error deleting existing resource: %v", err)
+	}
+	err = wait.PollUntilContextTimeout(ctx, time.Second, recreateTimeout, true, func(ctx context.Context) (bool, error) {
+		_, err := resourceClient.Get(ctx, name, metav1.GetOptions{})
+		if apierrors.IsNotFound(err) {
+			return true, nil
+		}
+		return false, err
+	})
+	if err != nil {
+		return fmt.Errorf("error waiting for existing resource to be deleted: %v", err)
 	}
-	return err
+	return nil
 }
@@ -0,0 +1,137 @@
+package restore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// snapshot is the state of a resource before the restore changed it
+type snapshot struct {
+	// target identifies the restored resource
+	target *unstructured.Unstructured
+	// previous is the live version of the resource before the restore, or nil if it did not exist
+	previous *unstructured.Unstructured
+	// recreated is set when the restore deleted the resource and created it again
+	recreated bool
+}
+
+// String identifies the resource of a snapshot for logging
+func (s *snapshot) String() string {
+	return fmt.Sprintf("%s %s/%s", s.target.GetKind(), s.target.GetNamespace(), s.target.GetName())
+}
+
+// takeSnapshot gets the live version of the resource the restore is about to write
+func (m *Manager) takeSnapshot(ctx context.Context, resource map[string]interface{}) (*snapshot, error) {
+	s := &snapshot{target: resourceReference(resource)}
+	resourceClient, _, err := m.k8sClient.ResourceFor(s.target)
+	if err != nil {
+		return nil, err
+	}
+	live, err := resourceClient.Get(ctx, s.target.GetName(), metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		return s, nil
+	case err != nil:
+		return nil, fmt.Errorf("error getting the live version of %s: %v", s, err)
+	}
+	s.previous = live
+	return s, nil
+}
+
+// recordSnapshot remembers a snapshot of a resource changed by the restore, so that it can be rolled back
+func (m *Manager) recordSnapshot(s *snapshot) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.snapshots = append(m.snapshots, s)
+}
+
+// rollback reverts the resources changed by the restore after it failed with cause: resources it created
+// are deleted and resources it changed are put back to their previous version. Resources are reverted in
+// the reverse order of the restore, so that, for example, workloads are removed before their namespace.
+// It returns cause, along with the resources that could not be reverted. If cause is a *RestoreError, it is
+// marked as rolled back if every resource was reverted, and as failed to roll back otherwise.
+func (m *Manager) rollback(cause error) error {
+	m.mu.Lock()
+	snapshots := m.snapshots
+	m.mu.Unlock()
+
+	// The restore context may have been cancelled to abort the restore
+	ctx := context.Background()
+	m.logger.Warnf("Restore failed, rolling back %d resources", len(snapshots))
+	var failed int
+	for i := len(snapshots) - 1; i >= 0; i-- {
+		if err := m.revert(ctx, snapshots[i]); err != nil {
+			m.logger.Errorf("Error rolling back %s: %v", snapshots[i], err)
+			failed++
+		}
+	}
+	var restoreErr *RestoreError
+	isRestoreErr := errors.As(cause, &restoreErr)
+	if failed > 0 {
+		if isRestoreErr {
+			restoreErr.RollbackFailed = true
+		}
+		return fmt.Errorf("restore failed and %d of %d resources could not be rolled back: %w", failed, len(snapshots), cause)
+	}
+	m.logger.Infof("Rolled back %d resources", len(snapshots))
+	if isRestoreErr {
+		restoreErr.RolledBack = true
+	}
+	return fmt.Errorf("restore rolled back: %w", cause)
+}
+
+// revert puts a resource back to the state recorded by s
+func (m *Manager) revert(ctx context.Context, s *snapshot) error {
+	resourceClient, _, err := m.k8sClient.ResourceFor(s.target)
+	if err != nil {
+		return err
+	}
+
+	// Delete the resources that did not exist before the restore
+	if s.previous == nil {
+		propagation := metav1.DeletePropagationBackground
+		err := resourceClient.Delete(ctx, s.target.GetName(), metav1.DeleteOptions{PropagationPolicy: &propagation})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("error deleting resource: %v", err)
+		}
+		m.logger.Infof("Rolled back %s: deleted", s)
+		return nil
+	}
+
+	live, err := resourceClient.Get(ctx, s.target.GetName(), metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+		live = nil
+	case err != nil:
+		return fmt.Errorf("error getting resource: %v", err)
+	}
+
+	// A recreated resource may differ in immutable fields, so it is recreated again rather than updated
+	if live != nil && s.recreated {
+		if err := deleteAndWait(ctx, resourceClient, s.target.GetName()); err != nil {
+			return err
+		}
+		live = nil
+	}
+
+	previous := s.previous.DeepCopy()
+	previous.SetUID("")
+	if live == nil {
+		previous.SetResourceVersion("")
+		if _, err := resourceClient.Create(ctx, previous, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("error creating previous version: %v", err)
+		}
+	} else {
+		previous.SetResourceVersion(live.GetResourceVersion())
+		if _, err := resourceClient.Update(ctx, previous, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("error updating to previous version: %v", err)
+		}
+	}
+	m.logger.Infof("Rolled back %s: restored previous version", s)
+	return nil
+}
@@ -0,0 +1,118 @@
+package restore
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/chaoscypher/kube-save-restore/internal/logger"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// TestPerformRestoreAtomic tests that an atomic restore that fails deletes the resources it created and puts
+// back the previous version of the ones it changed.
+func TestPerformRestoreAtomic(t *testing.T) {
+	secrets := schema.GroupVersionResource{Version: "v1", Resource: "secrets"}
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+
+	for _, policy := range []ExistingResourcePolicy{ExistingResourceUpdate, ExistingResourceRecreate} {
+		t.Run(string(policy), func(t *testing.T) {
+			restoreDir := t.TempDir()
+			for _, dir := range []string{"secrets", "deployments", "widgets.example.com"} {
+				if err := os.MkdirAll(filepath.Join(restoreDir, "team-a", dir), 0755); err != nil {
+					t.Fatalf("failed to create directory: %v", err)
+				}
+			}
+			writeResourceFile(t, restoreDir, filepath.Join("team-a", "secrets", "db.json"), `{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "db", "namespace": "team-a"}}`)
+			writeResourceFile(t, restoreDir, filepath.Join("team-a", "deployments", "web.json"),
+				`{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "namespace": "team-a", "labels": {"version": "backup"}}}`)
+			// Restored last and fails, since the cluster does not serve the kind
+			writeResourceFile(t, restoreDir, filepath.Join("team-a", "widgets.example.com", "w.json"), `{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "w", "namespace": "team-a"}}`)
+
+			client := newTestClient(existingDeployment("live"))
+			manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG), WithExistingResourcePolicy(policy), WithAtomic())
+			err := manager.PerformRestore(restoreDir, false)
+			var restoreErr *RestoreError
+			if !errors.As(err, &restoreErr) {
+				t.Fatalf("PerformRestore() error = %v; want a *RestoreError", err)
+			}
+			if len(restoreErr.Failed) != 1 || restoreErr.Failed[0].Kind != "Widget" || !restoreErr.RolledBack {
+				t.Errorf("RestoreError = %+v; want the Widget failed and the restore rolled back", restoreErr)
+			}
+
+			if _, err := client.Dynamic.Resource(secrets).Namespace("team-a").Get(context.Background(), "db", metav1.GetOptions{}); err == nil {
+				t.Error("expected the created secret db to be deleted")
+			}
+			deployment, err := client.Dynamic.Resource(deployments).Namespace("team-a").Get(context.Background(), "web", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("expected the deployment web to be kept, got error: %v", err)
+			}
+			if version := deployment.GetLabels()["version"]; version != "live" {
+				t.Errorf("deployment version = %q; want %q", version, "live")
+			}
+		})
+	}
+}
+
+// TestPerformRestoreAtomicNotReady tests that an atomic restore is rolled back when restored resources do
+// not become ready.
+func TestPerformRestoreAtomicNotReady(t *testing.T) {
+	restoreDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(restoreDir, "team-a", "deployments"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	writeResourceFile(t, restoreDir, filepath.Join("team-a", "deployments", "web.json"), `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "namespace": "team-a"}}`)
+
+	client := newTestClient()
+	manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG), WithWait(10*time.Millisecond), WithAtomic())
+	err := manager.PerformRestore(restoreDir, false)
+	if !errors.Is(err, ErrNotReady) {
+		t.Fatalf("PerformRestore() error = %v; want %v", err, ErrNotReady)
+	}
+	var restoreErr *RestoreError
+	if !errors.As(err, &restoreErr) {
+		t.Fatalf("PerformRestore() error = %v; want a *RestoreError", err)
+	}
+	if !restoreErr.RolledBack || restoreErr.Partial() {
+		t.Errorf("RolledBack = %v, Partial() = %v; want a rolled back restore", restoreErr.RolledBack, restoreErr.Partial())
+	}
+
+	deployments := schema.GroupVersionResource{Group: "apps", Version: "v1", Resource: "deployments"}
+	if _, err := client.Dynamic.Resource(deployments).Namespace("team-a").Get(context.Background(), "web", metav1.GetOptions{}); err == nil {
+		t.Error("expected the created deployment web to be deleted")
+	}
+}
+
+// TestPerformRestoreAtomicRollbackFailed tests that an atomic restore that cannot revert every resource is
+// reported as failed to roll back rather than as a partial restore.
+func TestPerformRestoreAtomicRollbackFailed(t *testing.T) {
+	restoreDir := t.TempDir()
+	for _, dir := range []string{"secrets", "widgets.example.com"} {
+		if err := os.MkdirAll(filepath.Join(restoreDir, "team-a", dir), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+	}
+	writeResourceFile(t, restoreDir, filepath.Join("team-a", "secrets", "db.json"), `{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "db", "namespace": "team-a"}}`)
+	writeResourceFile(t, restoreDir, filepath.Join("team-a", "widgets.example.com", "w.json"), `{"apiVersion": "example.com/v1", "kind": "Widget", "metadata": {"name": "w", "namespace": "team-a"}}`)
+
+	client := newTestClient()
+	client.Dynamic.(*dynamicfake.FakeDynamicClient).PrependReactor("delete", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, errors.New("connection refused")
+	})
+	manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG), WithAtomic())
+	err := manager.PerformRestore(restoreDir, false)
+	var restoreErr *RestoreError
+	if !errors.As(err, &restoreErr) {
+		t.Fatalf("PerformRestore() error = %v; want a *RestoreError", err)
+	}
+	if !restoreErr.RollbackFailed || restoreErr.RolledBack || restoreErr.Partial() {
+		t.Errorf("RollbackFailed = %v, RolledBack = %v, Partial() = %v; want a failed rollback", restoreErr.RollbackFailed, restoreErr.RolledBack, restoreErr.Partial())
+	}
+}
@@ -1,50 +1,94 @@
 package restore
 
 import (
+	"context"
 	"fmt"
-	"os"
 	"path/filepath"
+
+	"github.com/chaoscypher/kube-save-restore/internal/manifest"
+	"github.com/chaoscypher/kube-save-restore/internal/storage"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
-// getResourceFiles walks through the restoreDir and collects all .json files.
-// It returns a slice of file paths and an error if any occurs during the walk.
-func getResourceFiles(restoreDir string) ([]string, error) {
+// resourceFileExtensions lists the extensions of the files read from a backup
+var resourceFileExtensions = map[string]bool{
+	".json": true,
+	".yaml": true,
+	".yml":  true,
+}
+
+// getResourceFiles lists the restoreDir in s and collects all .json, .yaml and .yml files
+// other than the backup manifest.
+// It returns a slice of file names and an error if the directory cannot be listed.
+func getResourceFiles(ctx context.Context, s storage.Storage, restoreDir string) ([]string, error) {
+	names, err := s.List(ctx, restoreDir)
+	if err != nil {
+		return nil, err
+	}
 	var files []string
-	err := filepath.Walk(restoreDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			return err
+	for _, name := range names {
+		// The backup manifest describes the backup and is not a resource
+		if filepath.Clean(name) == filepath.Join(restoreDir, manifest.Filename) {
+			continue
 		}
-		if !info.IsDir() && filepath.Ext(path) == ".json" {
-			files = append(files, path)
+		if resourceFileExtensions[filepath.Ext(name)] {
+			files = append(files, name)
 		}
-		return nil
-	})
-	return files, err
+	}
+	return files, nil
+}
+
+// legacyAPIVersions maps the kinds written by older versions of the backup to their API group version.
+// Those backups wrapped each resource in a {kind, resource} envelope without recording its apiVersion,
+// so it is inferred from the kind.
+var legacyAPIVersions = map[string]string{
+	"Namespace":               "v1",
+	"Service":                 "v1",
+	"ConfigMap":               "v1",
+	"Secret":                  "v1",
+	"ServiceAccount":          "v1",
+	"PersistentVolumeClaim":   "v1",
+	"Deployment":              "apps/v1",
+	"StatefulSet":             "apps/v1",
+	"DaemonSet":               "apps/v1",
+	"HorizontalPodAutoscaler": "autoscaling/v2",
+	"CronJob":                 "batch/v1",
+	"Job":                     "batch/v1",
+	"Ingress":                 "networking.k8s.io/v1",
+	"NetworkPolicy":           "networking.k8s.io/v1",
+	"Role":                    "rbac.authorization.k8s.io/v1",
 }
 
 // adjustResourceStructure adjusts the structure of the rawResource map.
-// It ensures the resource has the correct "kind" and "apiVersion" fields.
+// It accepts both plain manifests and the legacy {kind, resource} envelope, and ensures the resource
+// has the correct "kind" and "apiVersion" fields, inferring the apiVersion from the kind when it is missing.
 // It returns the adjusted resource, its kind, and an error if type assertions fail.
 func adjustResourceStructure(rawResource map[string]interface{}) (map[string]interface{}, string, error) {
-	var resource map[string]interface{}
-	var kind string
+	resource := rawResource
 
-	if rawKind, ok := rawResource["kind"].(string); ok {
-		resourceMap, ok := rawResource["resource"].(map[string]interface{})
+	// Unwrap resources saved in the legacy envelope format
+	if wrapped, isWrapped := rawResource["resource"]; isWrapped {
+		resourceMap, ok := wrapped.(map[string]interface{})
 		if !ok {
-			return nil, "", fmt.Errorf("expected resource field to be map[string]interface{}, got %T", rawResource["resource"])
+			return nil, "", fmt.Errorf("expected resource field to be map[string]interface{}, got %T", wrapped)
 		}
 		resource = resourceMap
-		resource["kind"] = rawKind
-		resource["apiVersion"] = "v1"
-		kind = rawKind
-	} else {
-		resource = rawResource
-		kindStr, ok := resource["kind"].(string)
+		if wrapperKind, ok := rawResource["kind"].(string); ok {
+			resource["kind"] = wrapperKind
+		}
+	}
+
+	kind, ok := resource["kind"].(string)
+	if !ok {
+		return nil, "", fmt.Errorf("expected kind field to be string, got %T", resource["kind"])
+	}
+
+	if apiVersion, _ := resource["apiVersion"].(string); apiVersion == "" {
+		apiVersion, ok := legacyAPIVersions[kind]
 		if !ok {
-			return nil, "", fmt.Errorf("expected kind field to be string, got %T", resource["kind"])
+			return nil, "", fmt.Errorf("cannot infer apiVersion for kind %s", kind)
 		}
-		kind = kindStr
+		resource["apiVersion"] = apiVersion
 	}
 
 	metadata, ok := resource["metadata"].(map[string]interface{})
@@ -59,36 +103,37 @@ func adjustResourceStructure(rawResource map[string]interface{}) (map[string]int
 }
 
 // validateResource checks if the resource has the required metadata fields.
-// It returns an error if any required field is missing.
+// It returns an error if any required field is missing. Whether a namespace is required depends on the
+// scope of the kind in the target cluster, which is checked by requireNamespace before the resource is restored.
 func validateResource(resource map[string]interface{}) error {
 	metadata, ok := resource["metadata"].(map[string]interface{})
 	if !ok {
 		return fmt.Errorf("resource metadata not found")
 	}
-
-	// Get the kind to determine required fields
-	kind, _ := resource["kind"].(string)
-
-	// Namespaces are cluster-scoped and don't require a namespace field
-	var requiredFields []string
-	if kind == "Namespace" {
-		requiredFields = []string{"name"}
-	} else {
-		requiredFields = []string{"name", "namespace"}
+	if _, ok := metadata["name"]; !ok {
+		return fmt.Errorf("missing metadata fields: [name]")
 	}
+	return nil
+}
 
-	missingFields := []string{}
-
-	for _, field := range requiredFields {
-		if _, ok := metadata[field]; !ok {
-			missingFields = append(missingFields, field)
-		}
+// clusterScoped reports whether resource is cluster-scoped, looking up the scope of its kind in the RESTMapper
+// of the target cluster. Kinds the cluster does not serve yet, such as those of CustomResourceDefinitions
+// restored from the same backup, are taken to be cluster-scoped when the resource has no namespace, since
+// the backup records the namespace of every namespaced resource.
+func (m *Manager) clusterScoped(resource map[string]interface{}) bool {
+	ref := resourceReference(resource)
+	namespaced, err := m.k8sClient.IsNamespaced(ref.GroupVersionKind())
+	if err != nil {
+		return ref.GetNamespace() == ""
 	}
+	return !namespaced
+}
 
-	if len(missingFields) > 0 {
-		return fmt.Errorf("missing metadata fields: %v", missingFields)
+// requireNamespace returns an error if resource is namespaced but has no namespace
+func (m *Manager) requireNamespace(resource map[string]interface{}) error {
+	if resourceReference(resource).GetNamespace() == "" && !m.clusterScoped(resource) {
+		return fmt.Errorf("invalid resource structure: missing metadata fields: [namespace]")
 	}
-
 	return nil
 }
 
@@ -103,9 +148,24 @@ func getResourceIdentifiers(resource map[string]interface{}) (string, string, er
 	namespace, _ := metadata["namespace"].(string)
 
 	// For cluster-scoped resources like namespaces, namespace will be empty
-	if namespace == "" && resource["kind"] == "Namespace" {
+	if namespace == "" {
 		namespace = "cluster-scoped"
 	}
 
 	return name, namespace, nil
 }
+
+// resourceReference returns an object holding only the apiVersion, kind, name and namespace of resource,
+// which identifies the resource in the cluster
+func resourceReference(resource map[string]interface{}) *unstructured.Unstructured {
+	ref := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": resource["apiVersion"],
+		"kind":       resource["kind"],
+	}}
+	metadata, _ := resource["metadata"].(map[string]interface{})
+	name, _ := metadata["name"].(string)
+	namespace, _ := metadata["namespace"].(string)
+	ref.SetName(name)
+	ref.SetNamespace(namespace)
+	return ref
+}
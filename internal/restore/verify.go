@@ -0,0 +1,84 @@
+package restore
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"errors"
+	"fmt"
+
+	"github.com/chaoscypher/kube-save-restore/internal/manifest"
+	"github.com/chaoscypher/kube-save-restore/internal/storage"
+)
+
+// VerifyBackup checks the backup at backupPath in s, either a directory or a compressed archive, against its
+// manifest, reading it the same way a restore does. If key is set, the manifest must be signed with the
+// matching private key. It returns the manifest, along with a problem for every file that is missing,
+// modified or not listed in it.
+func VerifyBackup(ctx context.Context, s storage.Storage, backupPath string, key ed25519.PublicKey) (*manifest.Manifest, []error, error) {
+	entries, err := readBackup(ctx, s, backupPath)
+	if err != nil {
+		return nil, nil, err
+	}
+	m, err := readManifest(entries, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	return m, checkEntries(m, entries), nil
+}
+
+// verifyBackup checks that the backup manifest is signed with the private key matching key and that the
+// files of the backup match it exactly, with no file missing, modified or added.
+func verifyBackup(entries []backupEntry, key ed25519.PublicKey) error {
+	m, err := readManifest(entries, key)
+	if err != nil {
+		return fmt.Errorf("refusing to restore: %v", err)
+	}
+	if problems := checkEntries(m, entries); len(problems) > 0 {
+		return fmt.Errorf("refusing to restore: backup does not match its signed manifest: %w", errors.Join(problems...))
+	}
+	return nil
+}
+
+// readManifest parses the manifest of a backup. If key is set, the manifest signature is checked as well.
+func readManifest(entries []backupEntry, key ed25519.PublicKey) (*manifest.Manifest, error) {
+	var manifestData, signature []byte
+	for _, entry := range entries {
+		switch entry.name {
+		case manifest.Filename:
+			manifestData = entry.data
+		case manifest.SignatureFilename:
+			signature = entry.data
+		}
+	}
+	if manifestData == nil {
+		return nil, fmt.Errorf("backup has no %s", manifest.Filename)
+	}
+	if key != nil {
+		if signature == nil {
+			return nil, fmt.Errorf("backup is not signed")
+		}
+		if err := manifest.VerifySignature(manifestData, signature, key); err != nil {
+			return nil, err
+		}
+	}
+	return manifest.Parse(manifestData)
+}
+
+// checkEntries returns a problem for every file of a backup that could not be read or does not match m,
+// and for every file listed in m that is missing from the backup
+func checkEntries(m *manifest.Manifest, entries []backupEntry) []error {
+	var problems []error
+	verifier := m.NewVerifier()
+	for _, entry := range entries {
+		if entry.name == manifest.Filename || entry.name == manifest.SignatureFilename {
+			continue
+		}
+		if entry.err != nil {
+			problems = append(problems, entry.err)
+			continue
+		}
+		verifier.Check(entry.name, bytes.NewReader(entry.data))
+	}
+	return append(problems, verifier.Problems()...)
+}
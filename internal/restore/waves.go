@@ -0,0 +1,173 @@
+package restore
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+// OtherKinds stands for every kind not listed in a restore order
+const OtherKinds = "*"
+
+// DefaultRestoreOrder is the order in which resources are restored, as waves of kinds. Each wave is restored
+// concurrently and finishes before the next one starts, so that resources are created after the resources
+// they depend on: namespaces before what they contain, and the ServiceAccounts, ConfigMaps, Secrets and
+// volumes of pods before the workloads running them. Admission webhooks and NetworkPolicies come last, so
+// that they cannot block or cut off the rest of the restore.
+var DefaultRestoreOrder = [][]string{
+	{"CustomResourceDefinition"},
+	{"Namespace"},
+	{"StorageClass", "PriorityClass", "IngressClass", "PersistentVolume"},
+	{"ServiceAccount"},
+	{"ClusterRole", "Role", "ClusterRoleBinding", "RoleBinding"},
+	{"ConfigMap", "Secret"},
+	{"PersistentVolumeClaim"},
+	{"Service"},
+	{"Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Pod", "Job", "CronJob"},
+	{"HorizontalPodAutoscaler"},
+	{"Ingress"},
+	{OtherKinds},
+	{"MutatingWebhookConfiguration", "ValidatingWebhookConfiguration"},
+	{"NetworkPolicy"},
+}
+
+const (
+	// crdEstablishedTimeout is how long to wait for restored CustomResourceDefinitions to be established
+	crdEstablishedTimeout = time.Minute
+	// crdPollInterval is how often restored CustomResourceDefinitions are checked
+	crdPollInterval = time.Second
+)
+
+// ParseRestoreOrder parses a restore order made of waves separated by ";", each listing kinds separated
+// by ",", such as "Namespace;ConfigMap,Secret;*". Kinds are matched case-insensitively. Kinds that are not
+// listed are restored in the wave holding OtherKinds, or in a last wave if there is none.
+func ParseRestoreOrder(value string) ([][]string, error) {
+	var order [][]string
+	seen := make(map[string]bool)
+	for _, wave := range strings.Split(value, ";") {
+		var kinds []string
+		for _, kind := range strings.Split(wave, ",") {
+			kind = strings.TrimSpace(kind)
+			if kind == "" {
+				continue
+			}
+			if seen[strings.ToLower(kind)] {
+				return nil, fmt.Errorf("kind %s is listed more than once", kind)
+			}
+			seen[strings.ToLower(kind)] = true
+			kinds = append(kinds, kind)
+		}
+		if len(kinds) == 0 {
+			return nil, fmt.Errorf("empty wave in restore order %q", value)
+		}
+		order = append(order, kinds)
+	}
+	return order, nil
+}
+
+// restoreWave is a group of resources restored concurrently
+type restoreWave struct {
+	kinds []string
+	files []resourceFile
+}
+
+// String describes the kinds of a wave for logging
+func (w restoreWave) String() string {
+	if len(w.kinds) == 1 && w.kinds[0] == OtherKinds {
+		return "other resources"
+	}
+	return strings.Join(w.kinds, ", ")
+}
+
+// groupWaves groups resources into the waves of order, leaving out empty waves. Resources that could not
+// be read have no kind and are grouped with OtherKinds, so that their errors are reported.
+func groupWaves(files []resourceFile, order [][]string) []restoreWave {
+	waves := make([]restoreWave, len(order), len(order)+1)
+	waveOfKind := make(map[string]int)
+	otherWave := -1
+	for i, kinds := range order {
+		waves[i].kinds = kinds
+		for _, kind := range kinds {
+			if kind == OtherKinds {
+				otherWave = i
+			}
+			waveOfKind[strings.ToLower(kind)] = i
+		}
+	}
+	if otherWave < 0 {
+		otherWave = len(waves)
+		waves = append(waves, restoreWave{kinds: []string{OtherKinds}})
+	}
+
+	for _, file := range files {
+		i, ok := waveOfKind[strings.ToLower(file.kind)]
+		if !ok {
+			i = otherWave
+		}
+		waves[i].files = append(waves[i].files, file)
+	}
+
+	var nonEmpty []restoreWave
+	for _, wave := range waves {
+		if len(wave.files) > 0 {
+			nonEmpty = append(nonEmpty, wave)
+		}
+	}
+	return nonEmpty
+}
+
+// recordCRD remembers a restored CustomResourceDefinition, so that the restore waits for it to be established
+func (m *Manager) recordCRD(resource map[string]interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.crds = append(m.crds, resourceReference(resource))
+}
+
+// waitForEstablished waits until the CustomResourceDefinitions restored so far are established, so that
+// the API server serves their custom resources before the next waves restore them. CRDs that are not
+// established in time are logged, and their custom resources may fail to restore.
+func (m *Manager) waitForEstablished(ctx context.Context) {
+	m.mu.Lock()
+	pending := m.crds
+	m.crds = nil
+	m.mu.Unlock()
+	if len(pending) == 0 {
+		return
+	}
+
+	m.logger.Infof("Waiting for %d CustomResourceDefinitions to be established", len(pending))
+	err := wait.PollUntilContextTimeout(ctx, crdPollInterval, crdEstablishedTimeout, true, func(ctx context.Context) (bool, error) {
+		var remaining []*unstructured.Unstructured
+		for _, crd := range pending {
+			if !m.isEstablished(ctx, crd) {
+				remaining = append(remaining, crd)
+			}
+		}
+		pending = remaining
+		return len(pending) == 0, nil
+	})
+	if err != nil {
+		for _, crd := range pending {
+			m.logger.Warnf("CustomResourceDefinition %s is not established, its custom resources may fail to restore", crd.GetName())
+		}
+	}
+}
+
+// isEstablished reports whether the Established condition of a CustomResourceDefinition is true
+func (m *Manager) isEstablished(ctx context.Context, crd *unstructured.Unstructured) bool {
+	resourceClient, _, err := m.k8sClient.ResourceFor(crd)
+	if err != nil {
+		return false
+	}
+	live, err := resourceClient.Get(ctx, crd.GetName(), metav1.GetOptions{})
+	if err != nil {
+		return false
+	}
+	status, _ := condition(live, "Established")
+	return status == "True"
+}
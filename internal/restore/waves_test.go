@@ -0,0 +1,169 @@
+package restore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/chaoscypher/kube-save-restore/internal/logger"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestParseRestoreOrder(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		expected  [][]string
+		expectErr bool
+	}{
+		{name: "Waves", value: "Namespace; ConfigMap,Secret ;*", expected: [][]string{{"Namespace"}, {"ConfigMap", "Secret"}, {"*"}}},
+		{name: "Empty wave", value: "Namespace;;Secret", expectErr: true},
+		{name: "Kind listed twice", value: "Secret;secret", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			order, err := ParseRestoreOrder(tt.value)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("ParseRestoreOrder(%q) error = %v; expectErr %v", tt.value, err, tt.expectErr)
+			}
+			if !reflect.DeepEqual(order, tt.expected) {
+				t.Errorf("ParseRestoreOrder(%q) = %v; want %v", tt.value, order, tt.expected)
+			}
+		})
+	}
+}
+
+// TestGroupWaves tests that resources are grouped into the waves of their kind, whatever the directory
+// they are stored in.
+func TestGroupWaves(t *testing.T) {
+	restoreDir := filepath.Join("backups", "k8s-backup-20240101-000000")
+	files := []resourceFile{
+		{path: filepath.Join(restoreDir, "cluster", "deployments", "web.json"), kind: "Deployment"},
+		{path: filepath.Join(restoreDir, "cluster", "configmaps", "settings.json"), kind: "ConfigMap"},
+		{path: filepath.Join(restoreDir, "namespaces", "cluster.json"), kind: "Namespace"},
+		{path: filepath.Join(restoreDir, "cluster", "certificates.cert-manager.io", "tls.json"), kind: "Certificate"},
+		{path: filepath.Join(restoreDir, "broken.json")},
+	}
+
+	tests := []struct {
+		name     string
+		order    [][]string
+		expected [][]string
+	}{
+		{
+			name:     "Default order",
+			order:    DefaultRestoreOrder,
+			expected: [][]string{{"cluster.json"}, {"settings.json"}, {"web.json"}, {"tls.json", "broken.json"}},
+		},
+		{
+			name:     "Other kinds first",
+			order:    [][]string{{"*"}, {"namespace"}, {"Deployment", "ConfigMap"}},
+			expected: [][]string{{"tls.json", "broken.json"}, {"cluster.json"}, {"web.json", "settings.json"}},
+		},
+		{
+			name:     "Other kinds not listed",
+			order:    [][]string{{"Namespace"}},
+			expected: [][]string{{"cluster.json"}, {"web.json", "settings.json", "tls.json", "broken.json"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var names [][]string
+			for _, wave := range groupWaves(files, tt.order) {
+				var waveNames []string
+				for _, file := range wave.files {
+					waveNames = append(waveNames, filepath.Base(file.path))
+				}
+				names = append(names, waveNames)
+			}
+			if !reflect.DeepEqual(names, tt.expected) {
+				t.Errorf("groupWaves() = %v; want %v", names, tt.expected)
+			}
+		})
+	}
+}
+
+// TestPerformRestoreWaves tests that every wave is restored before the next one starts.
+func TestPerformRestoreWaves(t *testing.T) {
+	restoreDir := t.TempDir()
+	for _, dir := range []string{"namespaces", filepath.Join("shop", "deployments"), filepath.Join("shop", "secrets")} {
+		if err := os.MkdirAll(filepath.Join(restoreDir, dir), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+	}
+	writeResourceFile(t, restoreDir, filepath.Join("shop", "deployments", "web.json"), `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web", "namespace": "shop"}}`)
+	writeResourceFile(t, restoreDir, filepath.Join("shop", "secrets", "db.json"), `{"apiVersion": "v1", "kind": "Secret", "metadata": {"name": "db", "namespace": "shop"}}`)
+	writeResourceFile(t, restoreDir, filepath.Join("namespaces", "shop.json"), `{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "shop"}}`)
+
+	client := newTestClient()
+	var created []string
+	client.Dynamic.(*dynamicfake.FakeDynamicClient).PrependReactor("create", "*", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		obj := action.(k8stesting.CreateAction).GetObject().(*unstructured.Unstructured)
+		created = append(created, obj.GetKind())
+		return false, nil, nil
+	})
+	manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG))
+	if err := manager.PerformRestore(restoreDir, false); err != nil {
+		t.Fatalf("PerformRestore() error = %v", err)
+	}
+
+	expected := []string{"Namespace", "Secret", "Deployment"}
+	if !reflect.DeepEqual(created, expected) {
+		t.Errorf("created %v; want %v", created, expected)
+	}
+}
+
+// TestPerformRestoreWaitsForCRDs tests that custom resources are restored once their restored
+// CustomResourceDefinition is established.
+func TestPerformRestoreWaitsForCRDs(t *testing.T) {
+	restoreDir := t.TempDir()
+	for _, dir := range []string{filepath.Join("cluster", "customresourcedefinitions"), filepath.Join("shop", "certificates.cert-manager.io")} {
+		if err := os.MkdirAll(filepath.Join(restoreDir, dir), 0755); err != nil {
+			t.Fatalf("failed to create directory: %v", err)
+		}
+	}
+	writeResourceFile(t, restoreDir, filepath.Join("cluster", "customresourcedefinitions", "certificates.json"),
+		`{"apiVersion": "apiextensions.k8s.io/v1", "kind": "CustomResourceDefinition", "metadata": {"name": "certificates.cert-manager.io"}}`)
+	writeResourceFile(t, restoreDir, filepath.Join("shop", "certificates.cert-manager.io", "tls.json"),
+		`{"apiVersion": "cert-manager.io/v1", "kind": "Certificate", "metadata": {"name": "tls", "namespace": "shop"}}`)
+
+	client := newTestClient()
+	fakeClient := client.Dynamic.(*dynamicfake.FakeDynamicClient)
+	// The CRD is established on the second check
+	var checks int
+	fakeClient.PrependReactor("get", "customresourcedefinitions", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		checks++
+		crd := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "apiextensions.k8s.io/v1",
+			"kind":       "CustomResourceDefinition",
+			"metadata":   map[string]interface{}{"name": action.(k8stesting.GetAction).GetName()},
+		}}
+		if checks > 1 {
+			crd.Object["status"] = map[string]interface{}{
+				"conditions": []interface{}{map[string]interface{}{"type": "Established", "status": "True"}},
+			}
+		}
+		return true, crd, nil
+	})
+	fakeClient.PrependReactor("create", "certificates", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if checks < 2 {
+			return true, nil, apierrors.NewNotFound(action.GetResource().GroupResource(), "tls")
+		}
+		return false, nil, nil
+	})
+
+	manager := NewManager(client, logger.NewLogger(os.Stdout, logger.DEBUG))
+	if err := manager.PerformRestore(restoreDir, false); err != nil {
+		t.Fatalf("PerformRestore() error = %v", err)
+	}
+	if checks != 2 {
+		t.Errorf("checked the CRD %d times; want 2", checks)
+	}
+}
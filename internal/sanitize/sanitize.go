@@ -0,0 +1,203 @@
+package sanitize
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// field is a server-populated field removed from resources before they are restored
+type field struct {
+	// name identifies the field in the list of preserved fields
+	name string
+	// clean removes the field from a resource manifest
+	clean func(resource map[string]interface{})
+	// createOnly is set for spec fields that are only removed when the resource is created. The API server
+	// allocates them on creation, and rejects updates of existing resources that remove them.
+	createOnly bool
+}
+
+// commonFields are removed from resources of every kind. They are set by the API server or by controllers
+// of the cluster the backup was taken from, and either conflict with the target cluster or are rebuilt there.
+var commonFields = []field{
+	{name: "uid", clean: removeMetadata("uid")},
+	{name: "generation", clean: removeMetadata("generation")},
+	{name: "selfLink", clean: removeMetadata("selfLink")},
+	{name: "ownerReferences", clean: removeMetadata("ownerReferences")},
+	{name: "status", clean: func(resource map[string]interface{}) { delete(resource, "status") }},
+	{name: "last-applied-configuration", clean: removeAnnotations("kubectl.kubernetes.io/last-applied-configuration")},
+}
+
+// kindFields are removed from resources of a kind only, in addition to commonFields
+var kindFields = map[string][]field{
+	"Service": {
+		{name: "clusterIP", clean: cleanServiceClusterIPs, createOnly: true},
+		{name: "nodePort", clean: cleanServiceNodePorts, createOnly: true},
+	},
+	"PersistentVolumeClaim": {
+		{name: "volumeName", clean: cleanClaimVolumeName, createOnly: true},
+	},
+	"PersistentVolume": {
+		{name: "claimRef", clean: cleanVolumeClaimRef},
+	},
+	"Deployment": {
+		{name: "revision", clean: removeAnnotations("deployment.kubernetes.io/revision")},
+	},
+	"DaemonSet": {
+		{name: "revision", clean: removeAnnotations("deprecated.daemonset.template.generation")},
+	},
+	"Job": {
+		{name: "selector", clean: cleanJobSelector, createOnly: true},
+	},
+}
+
+// Sanitizer removes server-populated fields from resource manifests, so that they can be restored into
+// any cluster. A nil Sanitizer removes every field.
+type Sanitizer struct {
+	preserve map[string]bool
+}
+
+// New creates a Sanitizer that keeps the named fields. It returns an error if a name is unknown.
+func New(preserve []string) (*Sanitizer, error) {
+	known := make(map[string]bool)
+	for _, name := range Fields() {
+		known[name] = true
+	}
+	s := &Sanitizer{preserve: make(map[string]bool, len(preserve))}
+	for _, name := range preserve {
+		if !known[name] {
+			return nil, fmt.Errorf("unknown field %q. Use one of: %s", name, strings.Join(Fields(), ", "))
+		}
+		s.preserve[name] = true
+	}
+	return s, nil
+}
+
+// Fields returns the names of the fields a Sanitizer removes, in alphabetical order
+func Fields() []string {
+	seen := make(map[string]bool)
+	var names []string
+	add := func(fields []field) {
+		for _, f := range fields {
+			if !seen[f.name] {
+				seen[f.name] = true
+				names = append(names, f.name)
+			}
+		}
+	}
+	add(commonFields)
+	for _, fields := range kindFields {
+		add(fields)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Sanitize removes the server-populated fields of a resource manifest in place, except for the preserved ones
+// and the spec fields removed by SanitizeForCreate
+func (s *Sanitizer) Sanitize(resource map[string]interface{}) {
+	s.clean(resource, false)
+}
+
+// SanitizeForCreate removes the server-populated spec fields of a resource manifest in place, except for the
+// preserved ones. Those fields are allocated by the API server when a resource is created, such as the IP of
+// a Service or the volume of a PersistentVolumeClaim, so they are only removed from resources being created.
+func (s *Sanitizer) SanitizeForCreate(resource map[string]interface{}) {
+	s.clean(resource, true)
+}
+
+// clean removes the fields of a resource manifest that are, or are not, createOnly
+func (s *Sanitizer) clean(resource map[string]interface{}, createOnly bool) {
+	kind, _ := resource["kind"].(string)
+	for _, fields := range [][]field{commonFields, kindFields[kind]} {
+		for _, f := range fields {
+			if f.createOnly == createOnly && (s == nil || !s.preserve[f.name]) {
+				f.clean(resource)
+			}
+		}
+	}
+}
+
+// removeMetadata returns a function removing a metadata field
+func removeMetadata(name string) func(map[string]interface{}) {
+	return func(resource map[string]interface{}) {
+		metadata, _ := resource["metadata"].(map[string]interface{})
+		delete(metadata, name)
+	}
+}
+
+// removeAnnotations returns a function removing annotations, and the annotations field once it is empty
+func removeAnnotations(keys ...string) func(map[string]interface{}) {
+	return func(resource map[string]interface{}) {
+		metadata, _ := resource["metadata"].(map[string]interface{})
+		annotations, ok := metadata["annotations"].(map[string]interface{})
+		if !ok {
+			return
+		}
+		for _, key := range keys {
+			delete(annotations, key)
+		}
+		if len(annotations) == 0 {
+			delete(metadata, "annotations")
+		}
+	}
+}
+
+// specOf returns the spec of a resource manifest, or nil if it has none
+func specOf(resource map[string]interface{}) map[string]interface{} {
+	spec, _ := resource["spec"].(map[string]interface{})
+	return spec
+}
+
+// cleanServiceClusterIPs removes the IPs allocated to a Service from the service range of the source
+// cluster. Headless Services keep their "None" cluster IP.
+func cleanServiceClusterIPs(service map[string]interface{}) {
+	spec := specOf(service)
+	if clusterIP, _ := spec["clusterIP"].(string); clusterIP == "None" {
+		return
+	}
+	delete(spec, "clusterIP")
+	delete(spec, "clusterIPs")
+}
+
+// cleanServiceNodePorts removes the node ports allocated to a Service, which may be taken in the target cluster
+func cleanServiceNodePorts(service map[string]interface{}) {
+	spec := specOf(service)
+	delete(spec, "healthCheckNodePort")
+	ports, _ := spec["ports"].([]interface{})
+	for _, port := range ports {
+		port, _ := port.(map[string]interface{})
+		delete(port, "nodePort")
+	}
+}
+
+// cleanClaimVolumeName removes the volume a PersistentVolumeClaim was bound to, along with the annotations
+// recording the binding, so that the claim is bound again in the target cluster
+func cleanClaimVolumeName(claim map[string]interface{}) {
+	delete(specOf(claim), "volumeName")
+	removeAnnotations("pv.kubernetes.io/bind-completed", "pv.kubernetes.io/bound-by-controller")(claim)
+}
+
+// cleanVolumeClaimRef removes the UID and resource version of the claim a PersistentVolume was bound to,
+// which belong to the source cluster. The volume keeps the namespace and name of the claim, so that it is
+// bound to the restored claim.
+func cleanVolumeClaimRef(volume map[string]interface{}) {
+	claimRef, _ := specOf(volume)["claimRef"].(map[string]interface{})
+	delete(claimRef, "uid")
+	delete(claimRef, "resourceVersion")
+}
+
+// cleanJobSelector removes the selector generated for a Job, along with the labels matching it in the pod
+// template, since they hold the UID of the Job in the source cluster. Jobs with a manual selector are kept.
+func cleanJobSelector(job map[string]interface{}) {
+	spec := specOf(job)
+	if manual, _ := spec["manualSelector"].(bool); manual {
+		return
+	}
+	delete(spec, "selector")
+	template, _ := spec["template"].(map[string]interface{})
+	metadata, _ := template["metadata"].(map[string]interface{})
+	labels, _ := metadata["labels"].(map[string]interface{})
+	delete(labels, "controller-uid")
+	delete(labels, "batch.kubernetes.io/controller-uid")
+}
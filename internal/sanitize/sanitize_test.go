@@ -0,0 +1,170 @@
+package sanitize
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+// decode parses a JSON manifest
+func decode(t *testing.T, manifest string) map[string]interface{} {
+	t.Helper()
+	var resource map[string]interface{}
+	if err := json.Unmarshal([]byte(manifest), &resource); err != nil {
+		t.Fatalf("failed to parse manifest: %v", err)
+	}
+	return resource
+}
+
+func TestSanitize(t *testing.T) {
+	tests := []struct {
+		name     string
+		preserve []string
+		resource string
+		expected string
+	}{
+		{
+			name: "Common fields",
+			resource: `{"apiVersion": "v1", "kind": "ConfigMap",
+				"metadata": {"name": "app", "namespace": "default", "uid": "1234", "generation": 3, "selfLink": "/api/v1/namespaces/default/configmaps/app",
+					"ownerReferences": [{"kind": "Deployment", "name": "web"}],
+					"annotations": {"kubectl.kubernetes.io/last-applied-configuration": "{}"}},
+				"data": {"key": "value"}}`,
+			expected: `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "app", "namespace": "default"}, "data": {"key": "value"}}`,
+		},
+		{
+			name: "Service",
+			resource: `{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "web", "namespace": "default"},
+				"spec": {"type": "LoadBalancer", "clusterIP": "10.0.0.12", "clusterIPs": ["10.0.0.12"], "healthCheckNodePort": 32100,
+					"ports": [{"port": 80, "nodePort": 31080}]},
+				"status": {"loadBalancer": {"ingress": [{"ip": "203.0.113.10"}]}}}`,
+			expected: `{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "web", "namespace": "default"},
+				"spec": {"type": "LoadBalancer", "ports": [{"port": 80}]}}`,
+		},
+		{
+			name:     "Headless Service",
+			resource: `{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "db"}, "spec": {"clusterIP": "None", "clusterIPs": ["None"]}}`,
+			expected: `{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "db"}, "spec": {"clusterIP": "None", "clusterIPs": ["None"]}}`,
+		},
+		{
+			name: "PersistentVolumeClaim",
+			resource: `{"apiVersion": "v1", "kind": "PersistentVolumeClaim",
+				"metadata": {"name": "data", "annotations": {"pv.kubernetes.io/bind-completed": "yes", "pv.kubernetes.io/bound-by-controller": "yes", "team": "db"}},
+				"spec": {"volumeName": "pvc-1234", "storageClassName": "standard"}, "status": {"phase": "Bound"}}`,
+			expected: `{"apiVersion": "v1", "kind": "PersistentVolumeClaim", "metadata": {"name": "data", "annotations": {"team": "db"}},
+				"spec": {"storageClassName": "standard"}}`,
+		},
+		{
+			name: "PersistentVolume",
+			resource: `{"apiVersion": "v1", "kind": "PersistentVolume", "metadata": {"name": "pvc-1234"},
+				"spec": {"claimRef": {"kind": "PersistentVolumeClaim", "namespace": "db", "name": "data", "uid": "5678", "resourceVersion": "42"}}}`,
+			expected: `{"apiVersion": "v1", "kind": "PersistentVolume", "metadata": {"name": "pvc-1234"},
+				"spec": {"claimRef": {"kind": "PersistentVolumeClaim", "namespace": "db", "name": "data"}}}`,
+		},
+		{
+			name: "Deployment",
+			resource: `{"apiVersion": "apps/v1", "kind": "Deployment",
+				"metadata": {"name": "web", "generation": 7, "annotations": {"deployment.kubernetes.io/revision": "7"}},
+				"spec": {"replicas": 2}, "status": {"readyReplicas": 2}}`,
+			expected: `{"apiVersion": "apps/v1", "kind": "Deployment", "metadata": {"name": "web"}, "spec": {"replicas": 2}}`,
+		},
+		{
+			name: "Job with a generated selector",
+			resource: `{"apiVersion": "batch/v1", "kind": "Job", "metadata": {"name": "migrate"},
+				"spec": {"selector": {"matchLabels": {"batch.kubernetes.io/controller-uid": "1234"}},
+					"template": {"metadata": {"labels": {"batch.kubernetes.io/controller-uid": "1234", "controller-uid": "1234", "job-name": "migrate"}}}}}`,
+			expected: `{"apiVersion": "batch/v1", "kind": "Job", "metadata": {"name": "migrate"},
+				"spec": {"template": {"metadata": {"labels": {"job-name": "migrate"}}}}}`,
+		},
+		{
+			name: "Job with a manual selector",
+			resource: `{"apiVersion": "batch/v1", "kind": "Job", "metadata": {"name": "migrate"},
+				"spec": {"manualSelector": true, "selector": {"matchLabels": {"app": "migrate"}}, "template": {"metadata": {"labels": {"app": "migrate"}}}}}`,
+			expected: `{"apiVersion": "batch/v1", "kind": "Job", "metadata": {"name": "migrate"},
+				"spec": {"manualSelector": true, "selector": {"matchLabels": {"app": "migrate"}}, "template": {"metadata": {"labels": {"app": "migrate"}}}}}`,
+		},
+		{
+			name:     "Preserved fields",
+			preserve: []string{"status", "clusterIP", "ownerReferences"},
+			resource: `{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "web", "uid": "1234", "ownerReferences": [{"kind": "Gateway", "name": "public"}]},
+				"spec": {"clusterIP": "10.0.0.12", "ports": [{"port": 80, "nodePort": 31080}]}, "status": {"loadBalancer": {}}}`,
+			expected: `{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "web", "ownerReferences": [{"kind": "Gateway", "name": "public"}]},
+				"spec": {"clusterIP": "10.0.0.12", "ports": [{"port": 80}]}, "status": {"loadBalancer": {}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := New(tt.preserve)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			resource := decode(t, tt.resource)
+			s.Sanitize(resource)
+			s.SanitizeForCreate(resource)
+			if expected := decode(t, tt.expected); !reflect.DeepEqual(resource, expected) {
+				t.Errorf("Sanitize() = %v; want %v", resource, expected)
+			}
+		})
+	}
+}
+
+// TestSanitizeKeepsCreateOnlyFields tests that the spec fields allocated on creation are only removed by
+// SanitizeForCreate, so that existing resources can be updated.
+func TestSanitizeKeepsCreateOnlyFields(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource string
+		expected string
+	}{
+		{
+			name: "Service",
+			resource: `{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "web", "uid": "1234"},
+				"spec": {"clusterIP": "10.0.0.12", "ports": [{"port": 80, "nodePort": 31080}]}}`,
+			expected: `{"apiVersion": "v1", "kind": "Service", "metadata": {"name": "web"},
+				"spec": {"clusterIP": "10.0.0.12", "ports": [{"port": 80, "nodePort": 31080}]}}`,
+		},
+		{
+			name: "PersistentVolumeClaim",
+			resource: `{"apiVersion": "v1", "kind": "PersistentVolumeClaim", "metadata": {"name": "data", "annotations": {"pv.kubernetes.io/bind-completed": "yes"}},
+				"spec": {"volumeName": "pvc-1234"}, "status": {"phase": "Bound"}}`,
+			expected: `{"apiVersion": "v1", "kind": "PersistentVolumeClaim", "metadata": {"name": "data", "annotations": {"pv.kubernetes.io/bind-completed": "yes"}},
+				"spec": {"volumeName": "pvc-1234"}}`,
+		},
+		{
+			name: "Job",
+			resource: `{"apiVersion": "batch/v1", "kind": "Job", "metadata": {"name": "migrate"},
+				"spec": {"selector": {"matchLabels": {"batch.kubernetes.io/controller-uid": "1234"}},
+					"template": {"metadata": {"labels": {"batch.kubernetes.io/controller-uid": "1234"}}}}}`,
+			expected: `{"apiVersion": "batch/v1", "kind": "Job", "metadata": {"name": "migrate"},
+				"spec": {"selector": {"matchLabels": {"batch.kubernetes.io/controller-uid": "1234"}},
+					"template": {"metadata": {"labels": {"batch.kubernetes.io/controller-uid": "1234"}}}}}`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resource := decode(t, tt.resource)
+			var s *Sanitizer
+			s.Sanitize(resource)
+			if expected := decode(t, tt.expected); !reflect.DeepEqual(resource, expected) {
+				t.Errorf("Sanitize() = %v; want %v", resource, expected)
+			}
+		})
+	}
+}
+
+func TestSanitizeNil(t *testing.T) {
+	var s *Sanitizer
+	resource := decode(t, `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "app", "uid": "1234"}}`)
+	s.Sanitize(resource)
+	if expected := decode(t, `{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "app"}}`); !reflect.DeepEqual(resource, expected) {
+		t.Errorf("Sanitize() = %v; want %v", resource, expected)
+	}
+}
+
+func TestNewUnknownField(t *testing.T) {
+	if _, err := New([]string{"status", "spec"}); err == nil {
+		t.Error("New() error = nil; want an error for an unknown field")
+	}
+}
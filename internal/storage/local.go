@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// Local stores files in a directory of the local filesystem
+type Local struct {
+	root string
+}
+
+// NewLocal returns a Local storage for the directory root. Names are resolved relative to root, so an
+// empty root resolves them relative to the working directory and leaves absolute names unchanged.
+func NewLocal(root string) *Local {
+	return &Local{root: root}
+}
+
+// path returns the filesystem path of name
+func (l *Local) path(name string) string {
+	return filepath.Join(l.root, filepath.FromSlash(name))
+}
+
+// WriteFile writes data to the file name, creating its parent directories
+func (l *Local) WriteFile(_ context.Context, name string, data []byte) error {
+	path := l.path(name)
+	// Create the directory structure if it doesn't exist
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("error creating directory: %v", err)
+	}
+
+	// Write the data to the file
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("error writing file: %v", err)
+	}
+	return nil
+}
+
+// Create writes to a temporary file next to name, which is renamed to name when the Writer is closed,
+// so an interrupted write never leaves a truncated file behind under the final name
+func (l *Local) Create(_ context.Context, name string) (Writer, error) {
+	path := l.path(name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, fmt.Errorf("error creating directory: %v", err)
+	}
+	file, err := os.OpenFile(path+".partial", os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("error creating file: %v", err)
+	}
+	return &localWriter{File: file, path: path}, nil
+}
+
+// Open opens the file name
+func (l *Local) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(l.path(name))
+}
+
+// List walks the directory dir and returns the names of the files it contains
+func (l *Local) List(_ context.Context, dir string) ([]string, error) {
+	var names []string
+	err := filepath.Walk(l.path(dir), func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		name := path
+		if l.root != "" {
+			if name, err = filepath.Rel(l.root, path); err != nil {
+				return err
+			}
+		}
+		names = append(names, filepath.ToSlash(name))
+		return nil
+	})
+	return names, err
+}
+
+// ReadDir returns the names of the entries of the directory dir
+func (l *Local) ReadDir(_ context.Context, dir string) ([]string, error) {
+	entries, err := os.ReadDir(l.path(dir))
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	return names, nil
+}
+
+// Delete removes the file name
+func (l *Local) Delete(_ context.Context, name string) error {
+	return os.Remove(l.path(name))
+}
+
+// DeleteAll removes the directory dir and everything it contains
+func (l *Local) DeleteAll(_ context.Context, dir string) error {
+	return os.RemoveAll(l.path(dir))
+}
+
+// Location returns the filesystem path of name
+func (l *Local) Location(name string) string {
+	return l.path(name)
+}
+
+// localWriter writes a file through a temporary file that is renamed into place on Close
+type localWriter struct {
+	*os.File
+	path string
+}
+
+// Close completes the file and moves it to its final location
+func (w *localWriter) Close() error {
+	if err := w.File.Close(); err != nil {
+		os.Remove(w.File.Name())
+		return fmt.Errorf("error finishing file: %v", err)
+	}
+	return os.Rename(w.File.Name(), w.path)
+}
+
+// Abort discards the unfinished file
+func (w *localWriter) Abort() {
+	w.File.Close()
+	os.Remove(w.File.Name())
+}
@@ -0,0 +1,204 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// DefaultS3Endpoint is the endpoint of Amazon S3
+const DefaultS3Endpoint = "s3.amazonaws.com"
+
+// streamPartSize is the size of the parts of uploads whose size is not known in advance. Without it, the
+// client sizes parts for the largest possible object and buffers over 500MiB per upload.
+// Together with the limit of 10000 parts, it allows objects of up to about 156GiB.
+const streamPartSize = 16 << 20
+
+// S3Config configures an S3 storage
+type S3Config struct {
+	// Endpoint is the host and optional port of the S3-compatible service, DefaultS3Endpoint if empty
+	Endpoint string
+	// Region of the bucket. It is looked up from the service if empty.
+	Region string
+	// Bucket holding the backups
+	Bucket string
+	// Prefix is prepended to the keys of all objects, so that backups can share a bucket
+	Prefix string
+	// Insecure connects to the service over plain HTTP
+	Insecure bool
+	// AccessKeyID and SecretAccessKey are static credentials. If they are empty, credentials are taken from
+	// the AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY environment variables, the AWS shared credentials file
+	// or the instance's IAM role.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+// S3 stores files as objects in a bucket of Amazon S3 or an S3-compatible service such as MinIO
+type S3 struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+// NewS3 returns an S3 storage for the bucket described by config
+func NewS3(config S3Config) (*S3, error) {
+	if config.Bucket == "" {
+		return nil, fmt.Errorf("S3 bucket is required")
+	}
+	endpoint := config.Endpoint
+	if endpoint == "" {
+		endpoint = DefaultS3Endpoint
+	}
+
+	creds := credentials.NewStaticV4(config.AccessKeyID, config.SecretAccessKey, config.SessionToken)
+	if config.AccessKeyID == "" {
+		creds = credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.FileAWSCredentials{},
+			&credentials.IAM{},
+		})
+	}
+
+	client, err := minio.New(endpoint, &minio.Options{
+		Creds:  creds,
+		Secure: !config.Insecure,
+		Region: config.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating S3 client: %v", err)
+	}
+	return &S3{client: client, bucket: config.Bucket, prefix: strings.Trim(config.Prefix, "/")}, nil
+}
+
+// key returns the object key of name. Names cannot refer to objects outside of the prefix.
+func (s *S3) key(name string) string {
+	return strings.TrimPrefix(path.Join(s.prefix, path.Clean("/"+name)), "/")
+}
+
+// WriteFile uploads data to the object name
+func (s *S3) WriteFile(ctx context.Context, name string, data []byte) error {
+	_, err := s.client.PutObject(ctx, s.bucket, s.key(name), bytes.NewReader(data), int64(len(data)), minio.PutObjectOptions{})
+	if err != nil {
+		return fmt.Errorf("error uploading %s: %v", s.Location(name), err)
+	}
+	return nil
+}
+
+// Create streams an upload to the object name. Large files are uploaded in parts, and the object is only
+// created once the Writer has been closed.
+func (s *S3) Create(ctx context.Context, name string) (Writer, error) {
+	reader, writer := io.Pipe()
+	w := &s3Writer{PipeWriter: writer, done: make(chan error, 1)}
+	go func() {
+		_, err := s.client.PutObject(ctx, s.bucket, s.key(name), reader, -1, minio.PutObjectOptions{PartSize: streamPartSize})
+		if err != nil {
+			err = fmt.Errorf("error uploading %s: %v", s.Location(name), err)
+		}
+		// Unblock writes if the upload failed early
+		reader.CloseWithError(err)
+		w.done <- err
+	}()
+	return w, nil
+}
+
+// Open downloads the object name
+func (s *S3) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	object, err := s.client.GetObject(ctx, s.bucket, s.key(name), minio.GetObjectOptions{})
+	if err == nil {
+		// GetObject is lazy, so check the object exists before returning it
+		_, err = object.Stat()
+	}
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "NoSuchKey" {
+			return nil, fmt.Errorf("%s: %w", s.Location(name), fs.ErrNotExist)
+		}
+		return nil, fmt.Errorf("error downloading %s: %v", s.Location(name), err)
+	}
+	return object, nil
+}
+
+// List returns the names of all objects below the directory dir
+func (s *S3) List(ctx context.Context, dir string) ([]string, error) {
+	prefix := s.key(dir)
+	if prefix != "" {
+		prefix += "/"
+	}
+	var names []string
+	for object := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if object.Err != nil {
+			return nil, fmt.Errorf("error listing %s: %v", s.Location(dir), object.Err)
+		}
+		name := strings.TrimPrefix(strings.TrimPrefix(object.Key, s.prefix), "/")
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// ReadDir returns the names of the objects and common prefixes directly below the directory dir
+func (s *S3) ReadDir(ctx context.Context, dir string) ([]string, error) {
+	prefix := s.key(dir)
+	if prefix != "" {
+		prefix += "/"
+	}
+	var names []string
+	for object := range s.client.ListObjects(ctx, s.bucket, minio.ListObjectsOptions{Prefix: prefix}) {
+		if object.Err != nil {
+			return nil, fmt.Errorf("error listing %s: %v", s.Location(dir), object.Err)
+		}
+		names = append(names, strings.TrimSuffix(strings.TrimPrefix(object.Key, prefix), "/"))
+	}
+	return names, nil
+}
+
+// Delete removes the object name
+func (s *S3) Delete(ctx context.Context, name string) error {
+	if err := s.client.RemoveObject(ctx, s.bucket, s.key(name), minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("error deleting %s: %v", s.Location(name), err)
+	}
+	return nil
+}
+
+// DeleteAll removes every object below the directory dir
+func (s *S3) DeleteAll(ctx context.Context, dir string) error {
+	names, err := s.List(ctx, dir)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := s.Delete(ctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Location returns the S3 URL of name
+func (s *S3) Location(name string) string {
+	return fmt.Sprintf("s3://%s/%s", s.bucket, s.key(name))
+}
+
+// s3Writer streams data to an upload running in the background
+type s3Writer struct {
+	*io.PipeWriter
+	done chan error
+}
+
+// Close finishes the upload and waits for it to complete
+func (w *s3Writer) Close() error {
+	w.PipeWriter.Close()
+	return <-w.done
+}
+
+// Abort cancels the upload, so that no object is created
+func (w *s3Writer) Abort() {
+	w.PipeWriter.CloseWithError(fmt.Errorf("upload aborted"))
+	<-w.done
+}
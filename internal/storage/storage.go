@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"io"
+	"strings"
+)
+
+// ArchiveSuffixes lists the name suffixes of backups stored as a single compressed tar archive
+var ArchiveSuffixes = []string{".tar.gz", ".tgz", ".tar.zst"}
+
+// IsArchive reports whether name is a backup stored as a compressed tar archive
+func IsArchive(name string) bool {
+	for _, suffix := range ArchiveSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Storage stores the files of backups. Files are identified by names relative to the root of the storage,
+// such as "k8s-backup-20240101-120000/default/configmaps/settings.json", which use forward slashes.
+// Implementations must be safe for concurrent use.
+type Storage interface {
+	// WriteFile stores data under name, replacing any existing file
+	WriteFile(ctx context.Context, name string, data []byte) error
+	// Create returns a Writer that streams a file of unknown size to name. The file only appears under
+	// name once the Writer has been closed successfully.
+	Create(ctx context.Context, name string) (Writer, error)
+	// Open returns the content of the file stored under name. The error wraps fs.ErrNotExist if there is
+	// no such file.
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+	// List returns the names of all files below the directory dir, recursively
+	List(ctx context.Context, dir string) ([]string, error)
+	// ReadDir returns the base names of the files and directories directly below the directory dir
+	ReadDir(ctx context.Context, dir string) ([]string, error)
+	// Delete removes the file stored under name
+	Delete(ctx context.Context, name string) error
+	// DeleteAll removes the directory dir and all files below it
+	DeleteAll(ctx context.Context, dir string) error
+	// Location describes where name is stored, for messages
+	Location(name string) string
+}
+
+// Writer streams a file to a Storage. Close completes the file and Abort discards it.
+type Writer interface {
+	io.Writer
+	Close() error
+	Abort()
+}
+
+// ReadFile returns the content of the file stored under name
+func ReadFile(ctx context.Context, s Storage, name string) ([]byte, error) {
+	reader, err := s.Open(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
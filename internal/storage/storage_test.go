@@ -0,0 +1,352 @@
+package storage
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeS3 is an in-memory stand-in for an S3-compatible service serving a single bucket with path-style
+// requests. It supports the subset of the API used by S3: put, get, head, delete and list objects, and
+// multipart uploads.
+type fakeS3 struct {
+	bucket string
+
+	mu      sync.Mutex
+	objects map[string][]byte
+	uploads map[string]map[int][]byte
+}
+
+// newFakeS3 starts a fakeS3 server for bucket and returns the configuration of an S3 storage using it
+func newFakeS3(t *testing.T, bucket, prefix string) (*fakeS3, S3Config) {
+	t.Helper()
+	fake := &fakeS3{bucket: bucket, objects: map[string][]byte{}, uploads: map[string]map[int][]byte{}}
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+	return fake, S3Config{
+		Endpoint:        strings.TrimPrefix(server.URL, "http://"),
+		Region:          "us-east-1",
+		Bucket:          bucket,
+		Prefix:          prefix,
+		Insecure:        true,
+		AccessKeyID:     "minioadmin",
+		SecretAccessKey: "minioadmin",
+	}
+}
+
+// keys returns the keys of all stored objects
+func (f *fakeS3) keys() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var keys []string
+	for key := range f.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	bucket, key, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucket != f.bucket {
+		writeS3Error(w, http.StatusNotFound, "NoSuchBucket")
+		return
+	}
+	query := r.URL.Query()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	switch {
+	case key == "" && r.Method == http.MethodGet:
+		f.list(w, query.Get("prefix"), query.Get("delimiter"))
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		uploadID := strconv.Itoa(len(f.uploads) + 1)
+		f.uploads[uploadID] = map[int][]byte{}
+		writeXML(w, struct {
+			XMLName  xml.Name `xml:"InitiateMultipartUploadResult"`
+			Bucket   string
+			Key      string
+			UploadId string
+		}{Bucket: bucket, Key: key, UploadId: uploadID})
+	case r.Method == http.MethodPut && query.Has("uploadId"):
+		parts, ok := f.uploads[query.Get("uploadId")]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		number, _ := strconv.Atoi(query.Get("partNumber"))
+		parts[number] = readBody(r)
+		w.Header().Set("ETag", fmt.Sprintf(`"part-%d"`, number))
+	case r.Method == http.MethodPost && query.Has("uploadId"):
+		parts, ok := f.uploads[query.Get("uploadId")]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchUpload")
+			return
+		}
+		numbers := make([]int, 0, len(parts))
+		for number := range parts {
+			numbers = append(numbers, number)
+		}
+		sort.Ints(numbers)
+		var data []byte
+		for _, number := range numbers {
+			data = append(data, parts[number]...)
+		}
+		f.objects[key] = data
+		delete(f.uploads, query.Get("uploadId"))
+		writeXML(w, struct {
+			XMLName xml.Name `xml:"CompleteMultipartUploadResult"`
+			Bucket  string
+			Key     string
+			ETag    string
+		}{Bucket: bucket, Key: key, ETag: `"complete"`})
+	case r.Method == http.MethodDelete && query.Has("uploadId"):
+		delete(f.uploads, query.Get("uploadId"))
+		w.WriteHeader(http.StatusNoContent)
+	case r.Method == http.MethodPut:
+		f.objects[key] = readBody(r)
+		w.Header().Set("ETag", `"object"`)
+	case r.Method == http.MethodGet || r.Method == http.MethodHead:
+		data, ok := f.objects[key]
+		if !ok {
+			writeS3Error(w, http.StatusNotFound, "NoSuchKey")
+			return
+		}
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		w.Header().Set("ETag", `"object"`)
+		w.Header().Set("Last-Modified", "Mon, 01 Jan 2024 12:00:00 GMT")
+		if r.Method == http.MethodGet {
+			w.Write(data)
+		}
+	case r.Method == http.MethodDelete:
+		delete(f.objects, key)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		writeS3Error(w, http.StatusNotImplemented, "NotImplemented")
+	}
+}
+
+// list writes a ListObjectsV2 response for all objects below prefix. With a delimiter, keys containing
+// the delimiter after the prefix are grouped into common prefixes.
+func (f *fakeS3) list(w http.ResponseWriter, prefix, delimiter string) {
+	type content struct {
+		Key  string
+		Size int
+		ETag string
+	}
+	type commonPrefix struct {
+		Prefix string
+	}
+	result := struct {
+		XMLName        xml.Name `xml:"ListBucketResult"`
+		Name           string
+		Prefix         string
+		KeyCount       int
+		IsTruncated    bool
+		Contents       []content
+		CommonPrefixes []commonPrefix
+	}{Name: f.bucket, Prefix: prefix}
+	seen := map[string]bool{}
+	for key, data := range f.objects {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		if i := strings.Index(key[len(prefix):], delimiter); delimiter != "" && i >= 0 {
+			common := key[:len(prefix)+i+len(delimiter)]
+			if !seen[common] {
+				seen[common] = true
+				result.CommonPrefixes = append(result.CommonPrefixes, commonPrefix{Prefix: common})
+			}
+			continue
+		}
+		result.Contents = append(result.Contents, content{Key: key, Size: len(data), ETag: `"object"`})
+	}
+	sort.Slice(result.Contents, func(i, j int) bool { return result.Contents[i].Key < result.Contents[j].Key })
+	sort.Slice(result.CommonPrefixes, func(i, j int) bool { return result.CommonPrefixes[i].Prefix < result.CommonPrefixes[j].Prefix })
+	result.KeyCount = len(result.Contents) + len(result.CommonPrefixes)
+	writeXML(w, result)
+}
+
+// readBody reads a request body, decoding the aws-chunked encoding used for streaming uploads
+func readBody(r *http.Request) []byte {
+	if !strings.HasPrefix(r.Header.Get("X-Amz-Content-Sha256"), "STREAMING-") {
+		data, _ := io.ReadAll(r.Body)
+		return data
+	}
+	var data []byte
+	reader := bufio.NewReader(r.Body)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return data
+		}
+		sizeField, _, _ := strings.Cut(strings.TrimSpace(line), ";")
+		size, err := strconv.ParseInt(sizeField, 16, 64)
+		if err != nil || size == 0 {
+			return data
+		}
+		chunk := make([]byte, size)
+		if _, err := io.ReadFull(reader, chunk); err != nil {
+			return data
+		}
+		data = append(data, chunk...)
+		reader.ReadString('\n')
+	}
+}
+
+func writeXML(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/xml")
+	xml.NewEncoder(w).Encode(v)
+}
+
+func writeS3Error(w http.ResponseWriter, status int, code string) {
+	w.Header().Set("Content-Type", "application/xml")
+	w.WriteHeader(status)
+	xml.NewEncoder(w).Encode(struct {
+		XMLName xml.Name `xml:"Error"`
+		Code    string
+		Message string
+	}{Code: code, Message: code})
+}
+
+// storages returns a Local and an S3 storage to run the same tests against
+func storages(t *testing.T) map[string]Storage {
+	_, config := newFakeS3(t, "backups", "cluster-a")
+	s3, err := NewS3(config)
+	if err != nil {
+		t.Fatalf("NewS3() error = %v", err)
+	}
+	return map[string]Storage{"Local": NewLocal(t.TempDir()), "S3": s3}
+}
+
+func TestStorage(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range storages(t) {
+		t.Run(name, func(t *testing.T) {
+			files := map[string]string{
+				"backup/namespaces/default.json":          `{"kind": "Namespace"}`,
+				"backup/default/configmaps/settings.json": `{"kind": "ConfigMap"}`,
+				"other/namespaces/default.json":           `{}`,
+			}
+			for name, content := range files {
+				if err := s.WriteFile(ctx, name, []byte(content)); err != nil {
+					t.Fatalf("WriteFile(%s) error = %v", name, err)
+				}
+			}
+
+			names, err := s.List(ctx, "backup")
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			sort.Strings(names)
+			expected := []string{"backup/default/configmaps/settings.json", "backup/namespaces/default.json"}
+			if strings.Join(names, ",") != strings.Join(expected, ",") {
+				t.Errorf("List() = %v; want %v", names, expected)
+			}
+
+			data, err := ReadFile(ctx, s, "backup/default/configmaps/settings.json")
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			if string(data) != files["backup/default/configmaps/settings.json"] {
+				t.Errorf("ReadFile() = %s", data)
+			}
+
+			entries, err := s.ReadDir(ctx, "")
+			if err != nil {
+				t.Fatalf("ReadDir() error = %v", err)
+			}
+			sort.Strings(entries)
+			if strings.Join(entries, ",") != "backup,other" {
+				t.Errorf("ReadDir() = %v; want [backup other]", entries)
+			}
+
+			if err := s.DeleteAll(ctx, "backup"); err != nil {
+				t.Fatalf("DeleteAll() error = %v", err)
+			}
+			if names, err := s.List(ctx, "backup"); err == nil && len(names) > 0 {
+				t.Errorf("List() after DeleteAll() = %v; want no files", names)
+			}
+
+			if err := s.Delete(ctx, "other/namespaces/default.json"); err != nil {
+				t.Fatalf("Delete() error = %v", err)
+			}
+			if _, err := s.Open(ctx, "other/namespaces/default.json"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Open() of a deleted file error = %v; want fs.ErrNotExist", err)
+			}
+		})
+	}
+}
+
+func TestStorageCreate(t *testing.T) {
+	ctx := context.Background()
+	for name, s := range storages(t) {
+		t.Run(name, func(t *testing.T) {
+			// Write more than a single part so that S3 uploads use a multipart upload
+			content := bytes.Repeat([]byte("kube-save-restore"), 1<<20)
+			w, err := s.Create(ctx, "backup.tar.gz")
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if _, err := w.Write(content); err != nil {
+				t.Fatalf("Write() error = %v", err)
+			}
+			if _, err := s.Open(ctx, "backup.tar.gz"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Open() before Close() error = %v; want fs.ErrNotExist", err)
+			}
+			if err := w.Close(); err != nil {
+				t.Fatalf("Close() error = %v", err)
+			}
+			data, err := ReadFile(ctx, s, "backup.tar.gz")
+			if err != nil {
+				t.Fatalf("ReadFile() error = %v", err)
+			}
+			if !bytes.Equal(data, content) {
+				t.Errorf("ReadFile() returned %d bytes; want %d", len(data), len(content))
+			}
+
+			w, err = s.Create(ctx, "aborted.tar.gz")
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			w.Write([]byte("partial"))
+			w.Abort()
+			if _, err := s.Open(ctx, "aborted.tar.gz"); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("Open() of an aborted file error = %v; want fs.ErrNotExist", err)
+			}
+		})
+	}
+}
+
+func TestS3Keys(t *testing.T) {
+	fake, config := newFakeS3(t, "backups", "/clusters/prod/")
+	s, err := NewS3(config)
+	if err != nil {
+		t.Fatalf("NewS3() error = %v", err)
+	}
+	if err := s.WriteFile(context.Background(), "../../escape/a.json", []byte("{}")); err != nil {
+		t.Fatalf("WriteFile() error = %v", err)
+	}
+	if keys := fake.keys(); len(keys) != 1 || keys[0] != "clusters/prod/escape/a.json" {
+		t.Errorf("stored keys = %v; want [clusters/prod/escape/a.json]", keys)
+	}
+	if location := s.Location("backup/a.json"); location != "s3://backups/clusters/prod/backup/a.json" {
+		t.Errorf("Location() = %s", location)
+	}
+	if _, err := NewS3(S3Config{}); err == nil {
+		t.Error("NewS3() error = nil; want an error without a bucket")
+	}
+}
@@ -1,20 +1,35 @@
 package main
 
 import (
+	"crypto/ed25519"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime/debug"
+	"strings"
 	"time"
 
 	"context"
 
 	"github.com/chaoscypher/kube-save-restore/internal/backup"
 	"github.com/chaoscypher/kube-save-restore/internal/config"
+	"github.com/chaoscypher/kube-save-restore/internal/encryption"
+	"github.com/chaoscypher/kube-save-restore/internal/filter"
 	"github.com/chaoscypher/kube-save-restore/internal/kubernetes"
 	"github.com/chaoscypher/kube-save-restore/internal/logger"
+	"github.com/chaoscypher/kube-save-restore/internal/manifest"
+	"github.com/chaoscypher/kube-save-restore/internal/prune"
+	"github.com/chaoscypher/kube-save-restore/internal/redact"
 	"github.com/chaoscypher/kube-save-restore/internal/restore"
+	"github.com/chaoscypher/kube-save-restore/internal/sanitize"
+	"github.com/chaoscypher/kube-save-restore/internal/storage"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
+// version is the version of kube-save-restore, set at build time with -ldflags "-X main.version=<version>"
+var version string
+
 // main is the entry point of the application.
 func main() {
 	config := config.ParseFlags()
@@ -22,12 +37,51 @@ func main() {
 
 	if err := run(config, logger); err != nil {
 		logger.Error("Error:", err)
-		os.Exit(1)
+		os.Exit(exitCode(err))
+	}
+}
+
+// Exit codes of the application when it fails
+const (
+	// exitFailure is returned on errors other than resources failing to restore
+	exitFailure = 1
+	// exitPartialRestore is returned when some resources failed to restore and others were restored
+	exitPartialRestore = 2
+	// exitFailedRestore is returned when no resource was restored: every resource attempted failed to
+	// restore, or the restore was rolled back
+	exitFailedRestore = 3
+	// exitRollbackFailed is returned when a restore failed and some of the resources it restored could not
+	// be rolled back, leaving the cluster partly reverted
+	exitRollbackFailed = 4
+)
+
+// exitCode returns the exit code for err, which tells a partially failed restore apart from a restore
+// that restored nothing.
+func exitCode(err error) int {
+	var restoreErr *restore.RestoreError
+	if !errors.As(err, &restoreErr) {
+		return exitFailure
+	}
+	if restoreErr.RollbackFailed {
+		return exitRollbackFailed
 	}
+	if restoreErr.Partial() {
+		return exitPartialRestore
+	}
+	return exitFailedRestore
 }
 
 // run executes the main logic based on the provided configuration and logger.
 func run(config *config.Config, logger logger.LoggerInterface) error {
+	// Verifying a backup does not require access to a cluster
+	if config.Mode == "verify" {
+		return handleVerify(config, logger)
+	}
+	// Neither does pruning old backups
+	if config.Mode == "prune" {
+		return handlePrune(config, logger)
+	}
+
 	kubeconfigPath := getKubeconfigPath(config.KubeConfig, logger)
 
 	k8sClient, err := kubernetes.NewClient(kubeconfigPath, config.Context, kubernetes.DefaultConfigModifier)
@@ -41,7 +95,7 @@ func run(config *config.Config, logger logger.LoggerInterface) error {
 	case "restore":
 		return handleRestore(config, k8sClient, logger)
 	default:
-		return fmt.Errorf("invalid mode: %s. Use 'backup' or 'restore'", config.Mode)
+		return fmt.Errorf("invalid mode: %s. Use 'backup', 'restore', 'verify' or 'prune'", config.Mode)
 	}
 }
 
@@ -62,17 +116,241 @@ func getKubeconfigPath(kubeconfig string, logger logger.LoggerInterface) string
 // handleBackup performs the backup operation using the provided configuration and Kubernetes client.
 func handleBackup(config *config.Config, k8sClient *kubernetes.Client, logger logger.LoggerInterface) error {
 	if config.BackupDir == "" {
-		config.BackupDir = filepath.Join(".", fmt.Sprintf("k8s-backup-%s", time.Now().Format("20060102-150405")))
+		config.BackupDir = filepath.Join(".", prune.NamePrefix+time.Now().Format(prune.TimeLayout))
+	}
+	namespaceFilter, err := filter.New(config.IncludeNamespaces, config.ExcludeNamespaces)
+	if err != nil {
+		return fmt.Errorf("invalid namespace filter: %w", err)
+	}
+	resourceFilter, err := filter.New(config.IncludeResources, config.ExcludeResources)
+	if err != nil {
+		return fmt.Errorf("invalid resource filter: %w", err)
+	}
+	backupStorage, err := newStorage(config)
+	if err != nil {
+		return err
+	}
+	options := []backup.Option{
+		backup.WithStorage(backupStorage),
+		backup.WithDiscovery(config.Discovery),
+		backup.WithNamespaceFilter(namespaceFilter),
+		backup.WithResourceFilter(resourceFilter),
+		backup.WithSelectors(config.Selector, config.FieldSelector),
+		backup.WithPageSize(config.PageSize),
+		backup.WithOutputFormat(config.OutputFormat),
+		backup.WithArchive(config.Archive),
+		backup.WithToolVersion(toolVersion()),
+		backup.WithCluster(clusterInfo(k8sClient, logger)),
+	}
+	if config.SigningKey != "" {
+		signingKey, err := manifest.LoadPrivateKey(config.SigningKey)
+		if err != nil {
+			return err
+		}
+		options = append(options, backup.WithSigningKey(signingKey))
 	}
-	backupManager := backup.NewManager(k8sClient, config.BackupDir, config.DryRun, logger)
+	if config.RedactSecrets {
+		redactor, err := redact.New(config.RedactPatterns)
+		if err != nil {
+			return fmt.Errorf("invalid redact patterns: %w", err)
+		}
+		options = append(options, backup.WithRedactor(redactor))
+	}
+	if config.EncryptionPassphraseFile != "" {
+		passphrase, err := readPassphrase(config.EncryptionPassphraseFile)
+		if err != nil {
+			return err
+		}
+		encrypter, err := encryption.NewEncrypter(passphrase)
+		if err != nil {
+			return err
+		}
+		options = append(options, backup.WithEncryption(encrypter))
+	}
+	backupManager := backup.NewManager(k8sClient, config.BackupDir, config.DryRun, logger, options...)
 	return backupManager.PerformBackup(context.Background())
 }
 
+// handleVerify checks the backup directory or archive named by --backup-dir in the configured storage against
+// its manifest. When a verification key is configured, the manifest must carry a valid signature.
+func handleVerify(config *config.Config, logger logger.LoggerInterface) error {
+	var verifyKey ed25519.PublicKey
+	if config.VerifyKey != "" {
+		var err error
+		if verifyKey, err = manifest.LoadPublicKey(config.VerifyKey); err != nil {
+			return err
+		}
+	}
+	verifyStorage, err := newStorage(config)
+	if err != nil {
+		return err
+	}
+
+	m, problems, err := restore.VerifyBackup(context.Background(), verifyStorage, config.BackupDir, verifyKey)
+	if err != nil {
+		return err
+	}
+	if verifyKey != nil {
+		logger.Info("Manifest signature verified")
+	}
+	logger.Infof("Verifying %d files of backup taken from context %s at %s", len(m.Files), m.Cluster.Context, m.CreatedAt.Format(time.RFC3339))
+
+	for _, problem := range problems {
+		logger.Error(problem)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("backup verification failed: %d problems found in %s", len(problems), config.BackupDir)
+	}
+
+	logger.Infof("Backup verified: all %d files in %s match the manifest", len(m.Files), config.BackupDir)
+	return nil
+}
+
+// newStorage returns the storage backups are written to and read from.
+// S3 credentials are taken from the standard AWS environment variables, shared credentials file or IAM role.
+func newStorage(config *config.Config) (storage.Storage, error) {
+	if config.Storage != "s3" {
+		return storage.NewLocal(""), nil
+	}
+	return storage.NewS3(storage.S3Config{
+		Endpoint: config.S3Endpoint,
+		Region:   config.S3Region,
+		Bucket:   config.S3Bucket,
+		Prefix:   config.S3Prefix,
+		Insecure: config.S3Insecure,
+	})
+}
+
+// readPassphrase reads the encryption passphrase from a file, ignoring surrounding whitespace.
+func readPassphrase(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading encryption passphrase: %w", err)
+	}
+	passphrase := strings.TrimSpace(string(data))
+	if passphrase == "" {
+		return "", fmt.Errorf("encryption passphrase file %s is empty", path)
+	}
+	return passphrase, nil
+}
+
+// handlePrune deletes the backups in the backup directory that the retention policy no longer keeps.
+func handlePrune(config *config.Config, logger logger.LoggerInterface) error {
+	policy := prune.Policy{KeepLast: config.KeepLast, KeepDaily: config.KeepDaily, KeepWeekly: config.KeepWeekly}
+	if config.KeepWithin != "" {
+		keepWithin, err := prune.ParseDuration(config.KeepWithin)
+		if err != nil {
+			return fmt.Errorf("invalid --keep-within: %w", err)
+		}
+		policy.KeepWithin = keepWithin
+	}
+	pruneStorage, err := newStorage(config)
+	if err != nil {
+		return err
+	}
+	dir := config.BackupDir
+	if dir == "" && config.Storage != "s3" {
+		dir = "."
+	}
+	pruneManager := prune.NewManager(pruneStorage, policy, config.DryRun, logger)
+	return pruneManager.PerformPrune(context.Background(), dir)
+}
+
+// clusterInfo describes the cluster the client is connected to for the backup manifest.
+func clusterInfo(k8sClient *kubernetes.Client, logger logger.LoggerInterface) manifest.Cluster {
+	serverVersion, err := k8sClient.ServerVersion()
+	if err != nil {
+		logger.Warnf("Could not determine the cluster version: %v", err)
+	}
+	return manifest.Cluster{Server: k8sClient.Host, Context: k8sClient.Context, ServerVersion: serverVersion}
+}
+
+// toolVersion returns the version of kube-save-restore, taken from the version variable when set at
+// build time and from the module version of the binary otherwise.
+func toolVersion() string {
+	if version != "" {
+		return version
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		return info.Main.Version
+	}
+	return "unknown"
+}
+
 // handleRestore performs the restore operation using the provided configuration and Kubernetes client.
 func handleRestore(config *config.Config, k8sClient *kubernetes.Client, logger logger.LoggerInterface) error {
 	if config.RestoreDir == "" {
 		return fmt.Errorf("--restore-dir flag is required for restore mode")
 	}
-	restoreManager := restore.NewManager(k8sClient, logger)
+	namespaceFilter, err := filter.New(config.IncludeNamespaces, config.ExcludeNamespaces)
+	if err != nil {
+		return fmt.Errorf("invalid namespace filter: %w", err)
+	}
+	resourceFilter, err := filter.New(config.IncludeResources, config.ExcludeResources)
+	if err != nil {
+		return fmt.Errorf("invalid resource filter: %w", err)
+	}
+	selector, err := labels.Parse(config.Selector)
+	if err != nil {
+		return fmt.Errorf("invalid label selector: %w", err)
+	}
+	if config.FieldSelector != "" {
+		logger.Warn("--field-selector only applies to backups and is ignored during restore")
+	}
+	sanitizer, err := sanitize.New(config.PreserveFields)
+	if err != nil {
+		return fmt.Errorf("invalid preserved fields: %w", err)
+	}
+	restoreStorage, err := newStorage(config)
+	if err != nil {
+		return err
+	}
+	order := restore.DefaultRestoreOrder
+	if config.RestoreOrder != "" {
+		order, err = restore.ParseRestoreOrder(config.RestoreOrder)
+		if err != nil {
+			return fmt.Errorf("invalid restore order: %w", err)
+		}
+	}
+	options := []restore.Option{
+		restore.WithStorage(restoreStorage),
+		restore.WithNamespaceFilter(namespaceFilter),
+		restore.WithResourceFilter(resourceFilter),
+		restore.WithLabelSelector(selector),
+		restore.WithExistingResourcePolicy(restore.ExistingResourcePolicy(config.ExistingResourcePolicy)),
+		restore.WithSanitizer(sanitizer),
+		restore.WithNamespaceMapping(config.NamespaceMapping),
+		restore.WithRestoreOrder(order),
+	}
+	if config.IncludeClusterResources {
+		options = append(options, restore.WithClusterResources())
+	}
+	if config.ServerSide {
+		options = append(options, restore.WithServerSideApply(config.ForceConflicts))
+	}
+	if config.Wait {
+		options = append(options, restore.WithWait(config.WaitTimeout))
+	}
+	if config.Atomic {
+		options = append(options, restore.WithAtomic())
+	}
+	if config.MaxErrors > 0 {
+		options = append(options, restore.WithMaxErrors(config.MaxErrors))
+	}
+	if config.VerifyKey != "" {
+		verifyKey, err := manifest.LoadPublicKey(config.VerifyKey)
+		if err != nil {
+			return err
+		}
+		options = append(options, restore.WithVerifyKey(verifyKey))
+	}
+	if config.EncryptionPassphraseFile != "" {
+		passphrase, err := readPassphrase(config.EncryptionPassphraseFile)
+		if err != nil {
+			return err
+		}
+		options = append(options, restore.WithDecryption(encryption.NewDecrypter(passphrase)))
+	}
+	restoreManager := restore.NewManager(k8sClient, logger, options...)
 	return restoreManager.PerformRestore(config.RestoreDir, config.DryRun)
 }
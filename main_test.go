@@ -1,12 +1,19 @@
 package main
 
 import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"testing"
 
 	"github.com/chaoscypher/kube-save-restore/internal/config"
 	"github.com/chaoscypher/kube-save-restore/internal/logger"
+	"github.com/chaoscypher/kube-save-restore/internal/manifest"
+	"github.com/chaoscypher/kube-save-restore/internal/restore"
 )
 
 func TestGetKubeconfigPath(t *testing.T) {
@@ -47,3 +54,101 @@ func TestGetKubeconfigPath(t *testing.T) {
 		})
 	}
 }
+
+func TestHandleVerify(t *testing.T) {
+	logger := logger.SetupLogger(&config.Config{})
+	backupDir := t.TempDir()
+
+	data := []byte(`{"apiVersion": "v1", "kind": "Namespace", "metadata": {"name": "default"}}`)
+	if err := os.MkdirAll(filepath.Join(backupDir, "namespaces"), 0755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(backupDir, "namespaces", "default.json"), data, 0600); err != nil {
+		t.Fatalf("failed to write file: %v", err)
+	}
+	m := &manifest.Manifest{Files: []manifest.File{manifest.NewFile("namespaces/default.json", "Namespace", "", "default", data)}}
+	manifestData, err := m.Marshal()
+	if err != nil {
+		t.Fatalf("failed to marshal manifest: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(backupDir, manifest.Filename), manifestData, 0600); err != nil {
+		t.Fatalf("failed to write manifest: %v", err)
+	}
+
+	if err := handleVerify(&config.Config{BackupDir: backupDir}, logger); err != nil {
+		t.Errorf("handleVerify() error = %v; want nil for an intact backup", err)
+	}
+
+	publicKey, _, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	publicDER, err := x509.MarshalPKIXPublicKey(publicKey)
+	if err != nil {
+		t.Fatalf("failed to marshal public key: %v", err)
+	}
+	verifyKey := filepath.Join(t.TempDir(), "verify.pem")
+	if err := os.WriteFile(verifyKey, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDER}), 0600); err != nil {
+		t.Fatalf("failed to write public key: %v", err)
+	}
+	if err := handleVerify(&config.Config{BackupDir: backupDir, VerifyKey: verifyKey}, logger); err == nil {
+		t.Error("handleVerify() error = nil; want an error for an unsigned backup with a verify key")
+	}
+
+	if err := os.WriteFile(filepath.Join(backupDir, "namespaces", "default.json"), data[:10], 0600); err != nil {
+		t.Fatalf("failed to truncate file: %v", err)
+	}
+	if err := handleVerify(&config.Config{BackupDir: backupDir}, logger); err == nil {
+		t.Error("handleVerify() error = nil; want an error for a truncated backup")
+	}
+}
+
+func TestReadPassphrase(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "passphrase")
+	if err := os.WriteFile(path, []byte("correct horse battery staple\n"), 0600); err != nil {
+		t.Fatalf("failed to write passphrase: %v", err)
+	}
+	passphrase, err := readPassphrase(path)
+	if err != nil {
+		t.Fatalf("readPassphrase() error = %v", err)
+	}
+	if passphrase != "correct horse battery staple" {
+		t.Errorf("readPassphrase() = %q; want the passphrase without the trailing newline", passphrase)
+	}
+
+	empty := filepath.Join(dir, "empty")
+	if err := os.WriteFile(empty, []byte("\n"), 0600); err != nil {
+		t.Fatalf("failed to write passphrase: %v", err)
+	}
+	if _, err := readPassphrase(empty); err == nil {
+		t.Error("readPassphrase() error = nil; want an error for an empty passphrase")
+	}
+}
+
+func TestExitCode(t *testing.T) {
+	failed := []*restore.ResourceError{{File: "shop/secrets/db.json", Kind: "Secret", Namespace: "shop", Name: "db", Err: errors.New("forbidden")}}
+	tests := []struct {
+		name     string
+		err      error
+		expected int
+	}{
+		{name: "Other error", err: errors.New("failed to create Kubernetes client"), expected: exitFailure},
+		{name: "Partial restore", err: &restore.RestoreError{Failed: failed, Restored: 3, Total: 4}, expected: exitPartialRestore},
+		{name: "Failed restore", err: &restore.RestoreError{Failed: failed, Total: 1}, expected: exitFailedRestore},
+		{name: "Wrapped restore error", err: fmt.Errorf("restore: %w", &restore.RestoreError{Failed: failed, Restored: 1, Total: 2}), expected: exitPartialRestore},
+		{name: "Aborted restore", err: fmt.Errorf("restore aborted: %w", &restore.RestoreError{Failed: failed, Restored: 2, Total: 5}), expected: exitPartialRestore},
+		{name: "Aborted before restoring anything", err: fmt.Errorf("restore aborted: %w", &restore.RestoreError{Failed: failed, Total: 5}), expected: exitFailedRestore},
+		{name: "Rolled back restore", err: fmt.Errorf("restore rolled back: %w", &restore.RestoreError{Failed: failed, Restored: 2, Total: 5, RolledBack: true}), expected: exitFailedRestore},
+		{name: "Rolled back restore not ready", err: fmt.Errorf("restore rolled back: %w", errors.Join(&restore.RestoreError{Restored: 5, Total: 5, RolledBack: true}, restore.ErrNotReady)), expected: exitFailedRestore},
+		{name: "Restore not rolled back completely", err: fmt.Errorf("restore failed and 1 of 2 resources could not be rolled back: %w", &restore.RestoreError{Failed: failed, Restored: 2, Total: 5, RollbackFailed: true}), expected: exitRollbackFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if code := exitCode(tt.err); code != tt.expected {
+				t.Errorf("exitCode() = %d; want %d", code, tt.expected)
+			}
+		})
+	}
+}